@@ -0,0 +1,73 @@
+// Command import loads historical sales of a shop from a CSV file. See
+// package importer for the file format.
+//
+//	go run ./cmd/import -shop 3 -file sales.csv -dry-run
+package main
+
+import (
+    "context"
+    "encoding/json"
+    "flag"
+    "log"
+    "os"
+    "os/signal"
+
+    "github.com/dibsnvas/golang-2025/internal/importer"
+    "github.com/dibsnvas/golang-2025/internal/repository"
+)
+
+func main() {
+    shopID := flag.Uint("shop", 0, "shop ID the sales belong to")
+    file := flag.String("file", "", "CSV file, - for stdin")
+    dryRun := flag.Bool("dry-run", false, "validate the file without writing")
+    chunk := flag.Int("chunk", importer.DefaultChunkSize, "sales per database transaction")
+    flag.Parse()
+
+    if *shopID == 0 || *file == "" {
+        flag.Usage()
+        os.Exit(2)
+    }
+
+    dsn := os.Getenv("DB_DSN")
+    if dsn == "" {
+        dsn = "host=localhost user=postgres password=postgres dbname=sales_ops port=5432 sslmode=disable"
+    }
+
+    in := os.Stdin
+    if *file != "-" {
+        f, err := os.Open(*file)
+        if err != nil {
+            log.Fatalf("Failed to open %s: %v", *file, err)
+        }
+        defer f.Close()
+        in = f
+    }
+
+    db, err := repository.NewDB(dsn)
+    if err != nil {
+        log.Fatalf("Failed to connect DB: %v", err)
+    }
+
+    // Ctrl+C прерывает импорт между строками; записанные части остаются
+    ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+    defer stop()
+
+    report, importErr := importer.ImportSales(ctx, db, in, importer.Options{
+        ShopID:    *shopID,
+        DryRun:    *dryRun,
+        ChunkSize: *chunk,
+    })
+    if report != nil {
+        enc := json.NewEncoder(os.Stdout)
+        enc.SetIndent("", "  ")
+        if err := enc.Encode(report); err != nil {
+            log.Printf("Failed to write report: %v", err)
+        }
+    }
+    if importErr != nil {
+        log.Fatalf("Import failed: %v", importErr)
+    }
+    if report.Failed > 0 {
+        os.Exit(1)
+    }
+}
@@ -19,9 +19,9 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/attendance/clock-out": {
-            "post": {
-                "description": "Record the clock-out time for an employee",
+        "/advances": {
+            "get": {
+                "description": "List advances and loans, newest first, optionally by employee and status",
                 "consumes": [
                     "application/json"
                 ],
@@ -29,18 +29,180 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Attendance"
+                    "Advances"
                 ],
-                "summary": "Clock-out for an employee",
+                "summary": "List salary advances",
                 "parameters": [
                     {
+                        "type": "integer",
                         "description": "Employee ID",
-                        "name": "clockOutRequest",
+                        "name": "employee_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "open or repaid",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 50, max 500)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Entries to skip",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Record money paid to an employee ahead of salary. Either installment (amount per salary) or installments (number of equal parts) sets the repayment schedule; an advance without either is recovered in one payment. From the first pay period ending on or after recover_from, every regular salary payment and payroll line deducts the installment (after income tax, never more than the balance or the pay left) until the advance is repaid",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Advances"
+                ],
+                "summary": "Create a salary advance or loan",
+                "parameters": [
+                    {
+                        "description": "Advance data",
+                        "name": "createAdvanceRequest",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/delivery.clockOutRequest"
+                            "$ref": "#/definitions/delivery.createAdvanceRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.SalaryAdvance"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/advances/employee/{employee_id}": {
+            "get": {
+                "description": "Open advances and loans of an employee with the total outstanding balance and what the next regular salary payment will recover",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Advances"
+                ],
+                "summary": "Get employee advance balance",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Employee ID",
+                        "name": "employee_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
                         }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/advances/{id}": {
+            "get": {
+                "description": "Retrieve an advance or loan with the salary deductions that recovered it and the projected schedule of the remaining installments",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Advances"
+                ],
+                "summary": "Get salary advance",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Advance ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -75,9 +237,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/salary/pay": {
+        "/attendance/clock-out": {
             "post": {
-                "description": "Record a salary payment for an employee",
+                "description": "Record the clock-out time for an employee",
                 "consumes": [
                     "application/json"
                 ],
@@ -85,23 +247,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Salary"
+                    "Attendance"
                 ],
-                "summary": "Pay salary to an employee",
+                "summary": "Clock-out for an employee",
                 "parameters": [
                     {
-                        "description": "Salary payment data",
-                        "name": "PaySalaryRequest",
+                        "description": "Employee ID",
+                        "name": "clockOutRequest",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/delivery.PaySalaryRequest"
+                            "$ref": "#/definitions/delivery.clockOutRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -114,6 +276,13 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -124,9 +293,30 @@ const docTemplate = `{
                 }
             }
         },
-        "/salary/{id}": {
+        "/catalog/metrics": {
             "get": {
-                "description": "Retrieve salary payment details using salary ID",
+                "description": "Per-operation counters of calls to the catalog service (calls, successes, failures, retries, calls rejected by the open circuit breaker) and the current circuit breaker state",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Catalog"
+                ],
+                "summary": "Catalog client metrics",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/commission/assignments": {
+            "post": {
+                "description": "Assign a commission plan to an employee starting from a date, optionally until a date",
                 "consumes": [
                     "application/json"
                 ],
@@ -134,23 +324,26 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Salary"
+                    "Commission"
                 ],
-                "summary": "Get salary payment by ID",
+                "summary": "Assign a commission plan to an employee",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Salary ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Assignment data",
+                        "name": "assignCommissionPlanRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/delivery.assignCommissionPlanRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/models.SalaryPayment"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "400": {
@@ -177,9 +370,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/sales": {
+        "/commission/plans": {
             "post": {
-                "description": "Register a new sales transaction for an employee",
+                "description": "Create a flat, tiered (by monthly revenue) or per-category commission plan. Rates are percentages",
                 "consumes": [
                     "application/json"
                 ],
@@ -187,17 +380,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Sales"
+                    "Commission"
                 ],
-                "summary": "Create a sales transaction",
+                "summary": "Create a commission plan",
                 "parameters": [
                     {
-                        "description": "Sale data",
-                        "name": "createSaleRequest",
+                        "description": "Plan definition",
+                        "name": "createCommissionPlanRequest",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/delivery.createSaleRequest"
+                            "$ref": "#/definitions/delivery.createCommissionPlanRequest"
                         }
                     }
                 ],
@@ -226,9 +419,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/sales/employee/{employee_id}": {
+        "/commission/plans/{id}": {
             "get": {
-                "description": "Get total sales count and amount by employee ID and date",
+                "description": "Retrieve a commission plan with its tiers and category rates",
                 "consumes": [
                     "application/json"
                 ],
@@ -236,31 +429,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Sales"
+                    "Commission"
                 ],
-                "summary": "Get sales by employee and date",
+                "summary": "Get commission plan by ID",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Employee ID",
-                        "name": "employee_id",
+                        "description": "Plan ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Date in YYYY-MM-DD format",
-                        "name": "date",
-                        "in": "query",
-                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/models.CommissionPlan"
                         }
                     },
                     "400": {
@@ -270,6 +455,13 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -279,66 +471,5579 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "delivery.PaySalaryRequest": {
-            "type": "object",
-            "properties": {
-                "amount": {
-                    "type": "number"
-                },
-                "employee_id": {
-                    "type": "integer"
-                },
-                "paid_at": {
-                    "description": "можно не указывать и взять time.Now()",
-                    "type": "string"
-                },
-                "pay_period_end": {
-                    "type": "string"
-                },
-                "pay_period_start": {
-                    "description": "строка, чтобы потом распарсить \"YYYY-MM-DD\"",
-                    "type": "string"
-                }
+        },
+        "/commission/statement/{employee_id}": {
+            "get": {
+                "description": "Compute an employee's commission for a pay period from their sales, net of returns, using the assigned plan",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Commission"
+                ],
+                "summary": "Get commission statement",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Employee ID",
+                        "name": "employee_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Pay period start in YYYY-MM-DD format",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Pay period end (inclusive) in YYYY-MM-DD format",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/delivery.commissionStatement"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/company": {
+            "get": {
+                "description": "Company details printed on payslips, payslip templates and overtime rules, with defaults filled in",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Company"
+                ],
+                "summary": "Get company settings",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.CompanySettings"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Set company details, payslip templates (text/template for PDF, html/template for HTML; empty for the defaults) and overtime rules: hours above overtime_weekly_hours in a week are paid at overtime_multiplier times the hourly rate. The bank fields (validated company IBAN and BIC, currency, CSV column layout and delimiter) are used for salary bank file exports",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Company"
+                ],
+                "summary": "Update company settings",
+                "parameters": [
+                    {
+                        "description": "Company settings",
+                        "name": "companySettingsRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/delivery.companySettingsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.CompanySettings"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/customers": {
+            "post": {
+                "description": "Register a customer, optionally with a loyalty card number",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Customers"
+                ],
+                "summary": "Create a customer",
+                "parameters": [
+                    {
+                        "description": "Customer data",
+                        "name": "createCustomerRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/delivery.createCustomerRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/customers/{id}": {
+            "get": {
+                "description": "Retrieve customer details and points balance",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Customers"
+                ],
+                "summary": "Get customer by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Customer ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Customer"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/customers/{id}/loyalty": {
+            "get": {
+                "description": "Points balance, its money value and the points history (newest first)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Customers"
+                ],
+                "summary": "Get customer loyalty points",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Customer ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 50, max 500)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Entries to skip",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/customers/{id}/purchases": {
+            "get": {
+                "description": "Sales and returns of a customer, newest first, including voided ones",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Customers"
+                ],
+                "summary": "Get customer purchases",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Customer ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 50, max 500)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Entries to skip",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/deductions/rules": {
+            "get": {
+                "description": "List active deduction rules in calculation order, optionally only those that apply to an employee",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Deductions"
+                ],
+                "summary": "List deduction rules",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Employee ID",
+                        "name": "employee_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.DeductionRule"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a rule withheld from the gross pay of every new salary payment and payroll line, for one employee or for everyone. pension and social take employee_rate (and the employer's employer_rate) percent of gross up to base_ceiling and reduce taxable pay; income_tax is progressive by brackets (rate above each threshold) or a flat employee_rate on taxable pay; fixed takes amount; garnishment takes amount or employee_rate percent of what is left, keeping at least protected_net. base_ceiling and brackets apply to all pay of a pay period, counting earlier payments for it; fixed and garnishment rules apply to regular payments only. Deductions never make net pay negative",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Deductions"
+                ],
+                "summary": "Create a deduction rule",
+                "parameters": [
+                    {
+                        "description": "Rule data",
+                        "name": "deductionRuleRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/delivery.deductionRuleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.DeductionRule"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/deductions/rules/{id}": {
+            "delete": {
+                "description": "Stop applying a deduction rule to new salary payments and payroll lines. Recorded deductions are kept",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Deductions"
+                ],
+                "summary": "Deactivate a deduction rule",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Rule ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/employees": {
+            "post": {
+                "description": "Register an employee in a shop",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Employees"
+                ],
+                "summary": "Create an employee",
+                "parameters": [
+                    {
+                        "description": "Employee data",
+                        "name": "createEmployeeRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/delivery.createEmployeeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/employees/{id}": {
+            "get": {
+                "description": "Retrieve employee details",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Employees"
+                ],
+                "summary": "Get employee by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Employee ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Employee"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/employees/{id}/approval-code": {
+            "put": {
+                "description": "Set the code (at least 6 characters) a manager uses to approve overrides such as late sale voids. Only a bcrypt hash is stored. After 5 failed attempts in a row the code is locked for 15 minutes and approvals answer 429; setting a new code lifts the lock",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Employees"
+                ],
+                "summary": "Set manager approval code",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Employee ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Approval code",
+                        "name": "setApprovalCodeRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/delivery.setApprovalCodeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/employees/{id}/bank-account": {
+            "get": {
+                "description": "Bank account of an employee with the IBAN masked",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Employees"
+                ],
+                "summary": "Get employee bank account",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Employee ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.EmployeeBankAccount"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Store the account salary is transferred to. The IBAN is validated (country length and mod-97 check digits) and stored encrypted with BANK_DATA_KEY; responses only show it masked. 503 when no key is configured",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Employees"
+                ],
+                "summary": "Set employee bank account",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Employee ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Bank account",
+                        "name": "bankAccountRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/delivery.bankAccountRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.EmployeeBankAccount"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/employees/{id}/pay": {
+            "put": {
+                "description": "Set the pay type (monthly salary or hourly) and rate used by payroll runs",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Employees"
+                ],
+                "summary": "Set employee pay",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Employee ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Pay type and rate",
+                        "name": "setPayRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/delivery.setPayRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/gift-cards/{code}": {
+            "get": {
+                "description": "Look up a gift card or store credit by its code",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Gift cards"
+                ],
+                "summary": "Get gift card balance",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Card code",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/gift-cards/{code}/movements": {
+            "get": {
+                "description": "List all balance changes of a gift card with the sales that caused them",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Gift cards"
+                ],
+                "summary": "Get gift card movements",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Card code",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/loyalty/rules": {
+            "get": {
+                "description": "List active loyalty rules, optionally only those that apply in a shop",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Loyalty"
+                ],
+                "summary": "List loyalty rules",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Shop ID",
+                        "name": "shop_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.LoyaltyRule"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Award points_per_unit points per currency unit of line revenue. Rules can be limited to a shop, an item or a category; the most specific matching rule applies to each line",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Loyalty"
+                ],
+                "summary": "Create a loyalty rule",
+                "parameters": [
+                    {
+                        "description": "Rule data",
+                        "name": "loyaltyRuleRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/delivery.loyaltyRuleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.LoyaltyRule"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/loyalty/rules/{id}": {
+            "delete": {
+                "description": "Stop applying a loyalty rule to new sales",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Loyalty"
+                ],
+                "summary": "Deactivate a loyalty rule",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Rule ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/payroll/runs": {
+            "get": {
+                "description": "List payroll runs, newest period first, optionally by status",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Payroll"
+                ],
+                "summary": "List payroll runs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "draft, approved or paid",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.PayrollRun"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a draft payroll run for a pay period and a set of shops. A line is computed for every active employee of the shops: monthly salary prorated by days, or worked hours times the hourly rate with weekly overtime at the company overtime multiplier, plus commission for the period and the employee's approved salary adjustments effective by period_end that are not paid yet, less the deductions of the employee's deduction rules. Employees already in a run for an overlapping period are rejected with 409, unless their salary payment from that run was reversed; employees who already have a regular salary payment (not reversed) for an overlapping period are skipped and listed in skipped_employee_ids",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Payroll"
+                ],
+                "summary": "Create a payroll run",
+                "parameters": [
+                    {
+                        "description": "Run data",
+                        "name": "createPayrollRunRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/delivery.createPayrollRunRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/payroll/runs/{id}": {
+            "get": {
+                "description": "Retrieve a payroll run, its lines with their deductions and totals. Lines whose salary payment was reversed are left out of totals and summed in reversed",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Payroll"
+                ],
+                "summary": "Get payroll run",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Run ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Discard a draft run and its lines. Their salary adjustments go back to be paid later",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Payroll"
+                ],
+                "summary": "Delete a draft payroll run",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Run ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/payroll/runs/{id}/approve": {
+            "post": {
+                "description": "Approve a draft run with a manager approval code. Lines can no longer be changed. Runs with a negative line are rejected with 422",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Payroll"
+                ],
+                "summary": "Approve a payroll run",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Run ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Manager approval",
+                        "name": "approvePayrollRunRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/delivery.approvePayrollRunRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/payroll/runs/{id}/bank-export": {
+            "post": {
+                "description": "Export the net pay transfers of a paid payroll run (409 before it is paid, as paying can still change net amounts), leaving out lines whose salary payment was reversed, as ISO 20022 pain.001.001.03 XML or as CSV in the company layout (bank_csv_layout, bank_csv_delimiter). All IBANs are validated first; if any employee lacks a valid account nothing is exported and 422 lists the problems. A run without transfers is rejected with 422. Every export is recorded with its message ID; exporting a run again, or a run some of whose payments were exported with POST /salary/bank-export, requires reexport=true, otherwise 409 returns the previous export or those salary_ids. The message ID, number of transfers and control sum are returned in the X-Message-Id, X-Transaction-Count and X-Control-Sum headers. Payments made outside the run, such as reissues of its reversed lines, are exported with POST /salary/bank-export",
+                "produces": [
+                    "text/xml",
+                    "text/csv"
+                ],
+                "tags": [
+                    "Payroll"
+                ],
+                "summary": "Export payroll run bank file",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Run ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "pain001 (default) or csv",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Requested execution date in YYYY-MM-DD format (default: today)",
+                        "name": "execution_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Export a run that was exported before",
+                        "name": "reexport",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/payroll/runs/{id}/lines/{line_id}": {
+            "put": {
+                "description": "Set a manual adjustment (bonus or correction, may be negative) on a line of a draft run. The line amount, its deductions and the run total are recomputed",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Payroll"
+                ],
+                "summary": "Adjust a payroll line",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Run ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Line ID",
+                        "name": "line_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Adjustment",
+                        "name": "adjustPayrollLineRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/delivery.adjustPayrollLineRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.PayrollLine"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Remove an employee from a draft run, e.g. to pay them separately. The line's salary adjustments go back to be paid later",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Payroll"
+                ],
+                "summary": "Remove a payroll line",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Run ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Line ID",
+                        "name": "line_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/payroll/runs/{id}/pay": {
+            "post": {
+                "description": "Mark an approved run as paid and record a regular salary payment for every line with a positive amount or reimbursements, with the line's gross, deductions, reimbursements, net pay and employer contributions; the line's salary adjustments are marked paid and advance installments withheld by the lines reduce the advance balances (an installment already recovered by another payment since the draft was computed is withheld only up to what is still owed). Adjustments of lines without pay go back to be paid later. The payments and the status change are written in one database transaction; if an employee already has a regular payment for an overlapping period nothing is written and 409 is returned",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Payroll"
+                ],
+                "summary": "Pay a payroll run",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Run ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Payment date",
+                        "name": "payPayrollRunRequest",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/delivery.payPayrollRunRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/payroll/runs/{id}/reopen": {
+            "post": {
+                "description": "Return an approved run that is not paid yet to draft with a manager approval code, e.g. when paying it fails. Its lines can then be adjusted or removed, or the run deleted, and it must be approved again",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Payroll"
+                ],
+                "summary": "Reopen a payroll run",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Run ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Manager approval",
+                        "name": "approvePayrollRunRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/delivery.approvePayrollRunRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/promotions": {
+            "get": {
+                "description": "List promotions valid at the given date (default: now), optionally only those applicable to a shop",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Promotions"
+                ],
+                "summary": "List promotions",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Shop ID",
+                        "name": "shop_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Date in YYYY-MM-DD format",
+                        "name": "date",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Promotion"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a line-level (percentage, fixed_amount, buy_x_get_y, bundle) or check-level (percentage, fixed_amount) promotion with a validity window and optional shop scope",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Promotions"
+                ],
+                "summary": "Create a promotion",
+                "parameters": [
+                    {
+                        "description": "Promotion definition",
+                        "name": "createPromotionRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/delivery.createPromotionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/promotions/{id}": {
+            "get": {
+                "description": "Retrieve a promotion definition",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Promotions"
+                ],
+                "summary": "Get promotion by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Promotion ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Promotion"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/reports/leaderboard": {
+            "get": {
+                "description": "Per-employee revenue, check count, average basket, items per check, revenue per hour worked and return rate, ranked within the employee's shop and across the chain. Use format=csv for a CSV export",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "tags": [
+                    "Reports"
+                ],
+                "summary": "Employee performance leaderboard",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Start date in YYYY-MM-DD format",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date (inclusive) in YYYY-MM-DD format",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only employees whose main shop in the period is this shop",
+                        "name": "shop_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "json (default) or csv",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/reports/sales-heatmap": {
+            "get": {
+                "description": "Sales by day-of-week and hour-of-day for a shop, joined with attendance to show staff on shift, sales per staff-hour and suggested staffing levels",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Reports"
+                ],
+                "summary": "Hourly sales heatmap with staffing recommendation",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Shop ID",
+                        "name": "shop_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start date in YYYY-MM-DD format",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date (inclusive) in YYYY-MM-DD format",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "Revenue one employee should handle per hour; defaults to the shop's average for the period",
+                        "name": "target_sales_per_staff_hour",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Minimum recommended staff for any hour with sales (default 1)",
+                        "name": "min_staff",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/reports/tax-summary": {
+            "get": {
+                "description": "Net, tax and gross amounts of sold items grouped by shop, tax category and rate; returns are included as negative amounts",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Reports"
+                ],
+                "summary": "Tax summary for a period",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Start date in YYYY-MM-DD format",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date (inclusive) in YYYY-MM-DD format",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Shop ID",
+                        "name": "shop_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/salary": {
+            "get": {
+                "description": "List salary payments, newest pay period first, optionally for one employee and for pay periods overlapping from..to, with gross, deduction, reimbursement, net and employer contribution totals. Reversal entries are listed with negated amounts, so the totals net out reversed payments",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Salary"
+                ],
+                "summary": "List salary payments",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Employee ID",
+                        "name": "employee_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Period start in YYYY-MM-DD format",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Period end (inclusive) in YYYY-MM-DD format",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 50, max 500)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Entries to skip",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/salary/adjustments": {
+            "get": {
+                "description": "List adjustments, newest effective date first, by employee, type, status and effective date range, with the count and amount per type for all matching adjustments",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Salary"
+                ],
+                "summary": "List salary adjustments",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Employee ID",
+                        "name": "employee_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "bonus, correction or reimbursement",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "pending, approved, rejected or paid",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Effective from in YYYY-MM-DD format",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Effective to (inclusive) in YYYY-MM-DD format",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 50, max 500)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Entries to skip",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Request a one-off bonus, correction or reimbursement for an employee with a reason code: bonus (performance, holiday, sales_target, referral, retention, other), correction (underpayment, overpayment, hours, rate, other; the amount may be negative) or reimbursement (travel, meals, equipment, training, other). It is pending until a manager approves it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Salary"
+                ],
+                "summary": "Create a salary adjustment",
+                "parameters": [
+                    {
+                        "description": "Adjustment data",
+                        "name": "createAdjustmentRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/delivery.createAdjustmentRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.SalaryAdjustment"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/salary/adjustments/{id}": {
+            "get": {
+                "description": "Retrieve a salary adjustment with its status and the payroll line or payment it is paid with",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Salary"
+                ],
+                "summary": "Get salary adjustment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Adjustment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.SalaryAdjustment"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/salary/adjustments/{id}/approve": {
+            "post": {
+                "description": "Approve a pending adjustment with a manager approval code. It is then paid with the employee's next payroll run (if effective by the end of its period), with a salary payment listing it in adjustment_ids, or on its own",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Salary"
+                ],
+                "summary": "Approve a salary adjustment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Adjustment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Manager approval",
+                        "name": "reviewAdjustmentRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/delivery.reviewAdjustmentRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.SalaryAdjustment"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/salary/adjustments/{id}/pay": {
+            "post": {
+                "description": "Pay an approved adjustment outside payroll as a supplemental salary payment for its effective date. Bonuses and corrections are subject to the deduction rules; reimbursements are paid in full. Negative corrections cannot be paid on their own",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Salary"
+                ],
+                "summary": "Pay a salary adjustment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Adjustment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Payment date",
+                        "name": "payAdjustmentRequest",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/delivery.payAdjustmentRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/salary/adjustments/{id}/reject": {
+            "post": {
+                "description": "Reject a pending adjustment with a manager approval code; it will never be paid",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Salary"
+                ],
+                "summary": "Reject a salary adjustment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Adjustment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Manager approval",
+                        "name": "reviewAdjustmentRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/delivery.reviewAdjustmentRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.SalaryAdjustment"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/salary/bank-export": {
+            "post": {
+                "description": "Export the net pay of the listed salary payments, such as those made with POST /salary/pay, POST /salary/adjustments/{id}/pay or POST /salary/{id}/reissue, in the same formats as a payroll run export. Reversed payments and reversal entries cannot be exported (409 and 400). Payments already exported on their own or with their payroll run need reexport=true, otherwise 409 lists their salary_ids. All IBANs are validated first; if any employee lacks a valid account nothing is exported and 422 lists the problems. Every export is recorded with its message ID and payments; the message ID, number of transfers and control sum are returned in the X-Message-Id, X-Transaction-Count and X-Control-Sum headers",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "text/xml",
+                    "text/csv"
+                ],
+                "tags": [
+                    "Salary"
+                ],
+                "summary": "Export salary payments bank file",
+                "parameters": [
+                    {
+                        "description": "Payments and export options",
+                        "name": "salaryBankExportRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/delivery.salaryBankExportRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/salary/employee/{employee_id}": {
+            "get": {
+                "description": "Salary payments of an employee paid in a year, newest first, with year-to-date gross, deductions and net totals",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Salary"
+                ],
+                "summary": "Get employee pay history",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Employee ID",
+                        "name": "employee_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Calendar year of payment (default: current year)",
+                        "name": "year",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 50, max 500)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Entries to skip",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/salary/pay": {
+            "post": {
+                "description": "Record a salary payment of gross amount for an employee. The employee's deduction rules are applied, regular payments also recover the installments of due advances, and the deduction lines, net pay and employer contributions are stored with the payment. Approved adjustments listed in adjustment_ids are paid with it: bonuses and corrections are added to gross pay, reimbursements to net pay. The amount must be positive (it may be 0 when adjustments are given; such a payment is supplemental) and the pay period must not end before it starts. Adjustments must be effective on or before pay_period_end. Regular payments of an employee cannot cover overlapping pay periods (409); supplemental payments such as bonuses can",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Salary"
+                ],
+                "summary": "Pay salary to an employee",
+                "parameters": [
+                    {
+                        "description": "Salary payment data",
+                        "name": "PaySalaryRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/delivery.PaySalaryRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/salary/{id}": {
+            "get": {
+                "description": "Retrieve salary payment details using salary ID, with its deduction lines",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Salary"
+                ],
+                "summary": "Get salary payment by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Salary ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.SalaryPayment"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/salary/{id}/payslip": {
+            "get": {
+                "description": "Render the payslip of a salary payment as HTML or PDF using the company templates: earnings (hours, rates, overtime, commission, adjustments), deductions, net pay and year-to-date totals. A PDF is password protected when the X-Payslip-Password header is set",
+                "produces": [
+                    "text/html",
+                    "application/pdf"
+                ],
+                "tags": [
+                    "Salary"
+                ],
+                "summary": "Get payslip",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Salary ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "html (default) or pdf",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Password to open the PDF",
+                        "name": "X-Payslip-Password",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/salary/{id}/reissue": {
+            "post": {
+                "description": "Replace a wrongly recorded payment in one transaction: it is reversed as by POST /salary/{id}/reverse and a corrected payment of the same type is recorded with gross amount plus the original's salary adjustments, for the original pay period unless another is given. Deductions and advance recoveries of the corrected payment are computed anew. The corrected payment has reissue_of_id set to the original",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Salary"
+                ],
+                "summary": "Reissue a salary payment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Salary ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Corrected payment and manager approval",
+                        "name": "reissueSalaryRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/delivery.reissueSalaryRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/salary/{id}/reverse": {
+            "post": {
+                "description": "Cancel a wrongly recorded payment with a manager approval code. A reversal entry for the same employee and pay period is recorded with all amounts and deduction lines negated, so reports and year-to-date totals net the payment out; the original payment is kept unchanged except for reversed_at. Advance installments it recovered are given back and its salary adjustments become payable again. A regular payment's pay period is free for a new payment afterwards",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Salary"
+                ],
+                "summary": "Reverse a salary payment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Salary ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Reason and manager approval",
+                        "name": "reverseSalaryRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/delivery.reverseSalaryRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/sales": {
+            "post": {
+                "description": "Register a new sales transaction for an employee. Active promotions for the shop are applied automatically, then tax is calculated per line using the shop tax settings; an item whose tax category has neither a shop nor a default rate is rejected with 400. Payments (split tender) must cover the total; cash overpayment is returned as change. With type \"return\" the item quantities and total are stored as negative amounts and stock is restocked. Sale prices are checked against the catalog: mismatches beyond PRICE_TOLERANCE and items unknown to the catalog are rejected with 422, and a catalog outage with 503 (both are flagged instead when PRICE_MISMATCH_MODE=flag), unless a manager approves the override with price_override_by and price_override_code. With STOCK_MODE=reserve all items are reserved in the catalog before the sale is stored; out-of-stock items return 409 and reservations are released on any failure. A repeated client_id returns the existing sale with 200. With customer_id or loyalty_card the sale is attached to a customer and earns loyalty points by the loyalty rules; points are redeemed as a check discount (redeem_points) or as a loyalty_points payment, at LOYALTY_POINT_VALUE per point",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Sales"
+                ],
+                "summary": "Create a sales transaction",
+                "parameters": [
+                    {
+                        "description": "Sale data",
+                        "name": "createSaleRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/delivery.createSaleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/sales/batch": {
+            "post": {
+                "description": "Store sales queued by a POS while it was offline. Each sale needs a client-generated UUID (client_id) and its original transaction_time, which is kept as the sale time. Sales are processed in order and independently: a sale whose client_id is already stored is reported as duplicate and not stored again, so a batch can be resent safely. Price mismatches are flagged instead of rejected and stock is deducted after the fact. At most 500 sales per request",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Sales"
+                ],
+                "summary": "Sync offline sales",
+                "parameters": [
+                    {
+                        "description": "Queued sales",
+                        "name": "createSalesBatchRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/delivery.createSalesBatchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/sales/employee/{employee_id}": {
+            "get": {
+                "description": "Get total sales count and amount by employee ID and date; voided sales are excluded",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Sales"
+                ],
+                "summary": "Get sales by employee and date",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Employee ID",
+                        "name": "employee_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Date in YYYY-MM-DD format",
+                        "name": "date",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/sales/import": {
+            "post": {
+                "description": "Load past sales of a shop from its previous system. The CSV has one row per sale item; rows of a sale share external_id and must be consecutive. Required columns: external_id, transaction_time, employee_id, item_id, quantity, price; optional: type, payment_method, discount, category, tax_category, tax_rate. Send the file as multipart field \"file\" or as the raw request body. Sales with invalid or split rows are rejected with an error on each affected row; a row that is not valid CSV is reported on its own line and only fails the sale whose rows surround it; sales imported earlier are skipped. With dry_run=true nothing is written. Imported sales get no receipt numbers",
+                "consumes": [
+                    "text/csv",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Sales"
+                ],
+                "summary": "Import historical sales from CSV",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Shop ID",
+                        "name": "shop_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only validate the file",
+                        "name": "dry_run",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Sales per database transaction (default 1000)",
+                        "name": "chunk_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "file",
+                        "description": "CSV file",
+                        "name": "file",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/importer.Report"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/sales/{id}/receipt": {
+            "get": {
+                "description": "Render the receipt of a sales transaction as plain text (fixed width, ESC/POS-friendly), HTML or PDF using the shop's templates",
+                "produces": [
+                    "text/plain",
+                    "text/html",
+                    "application/pdf"
+                ],
+                "tags": [
+                    "Sales"
+                ],
+                "summary": "Get sale receipt",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Transaction ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "text (default), html or pdf",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Characters per line for text and PDF (default 42)",
+                        "name": "width",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/sales/{id}/void": {
+            "post": {
+                "description": "Mark a sale as voided. The cashier who rang it up can void it within the void window (SALE_VOID_WINDOW, default 15m); otherwise a manager approval code is required. Voided sales are kept, excluded from all totals, and their stock movement, gift card movements and loyalty points are reversed",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Sales"
+                ],
+                "summary": "Void a sales transaction",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Transaction ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Void data",
+                        "name": "voidSaleRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/delivery.voidSaleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/shops": {
+            "post": {
+                "description": "Register a shop with its receipt details, receipt numbering settings and optional receipt templates",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Shops"
+                ],
+                "summary": "Create a shop",
+                "parameters": [
+                    {
+                        "description": "Shop data",
+                        "name": "shopRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/delivery.shopRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/shops/{id}": {
+            "get": {
+                "description": "Retrieve shop details",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Shops"
+                ],
+                "summary": "Get shop by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Shop ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Shop"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Replace shop details, including receipt header, footer and templates",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Shops"
+                ],
+                "summary": "Update a shop",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Shop ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Shop data",
+                        "name": "shopRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/delivery.shopRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Shop"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/shops/{id}/payment-methods": {
+            "get": {
+                "description": "List payment methods accepted in a shop; shops without configuration accept cash, card, gift_card and loyalty_points",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Payments"
+                ],
+                "summary": "Get shop payment methods",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Shop ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Replace the set of payment methods accepted in a shop. Methods must be among cash, card, gift_card and loyalty_points",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Payments"
+                ],
+                "summary": "Set shop payment methods",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Shop ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Payment methods",
+                        "name": "setPaymentMethodsRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/delivery.setPaymentMethodsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/tax/rates": {
+            "get": {
+                "description": "List tax rates, optionally only those that apply to a shop (shop-specific and defaults)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tax"
+                ],
+                "summary": "List tax rates",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Shop ID",
+                        "name": "shop_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.TaxRate"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create or update the rate (in percent) of a tax category, for all shops (shop_id 0) or one shop. Sales with a category that has no rate for their shop and no default rate are rejected, so exempt categories need a rate of 0",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tax"
+                ],
+                "summary": "Set a tax rate",
+                "parameters": [
+                    {
+                        "description": "Tax rate",
+                        "name": "setTaxRateRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/delivery.setTaxRateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.TaxRate"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/tax/shops/{shop_id}": {
+            "get": {
+                "description": "Retrieve tax settings of a shop; shops without settings use tax-inclusive prices with half-up rounding per line",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tax"
+                ],
+                "summary": "Get shop tax settings",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Shop ID",
+                        "name": "shop_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ShopTaxSettings"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Configure whether shop prices include tax and how tax is rounded",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tax"
+                ],
+                "summary": "Set shop tax settings",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Shop ID",
+                        "name": "shop_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Tax settings",
+                        "name": "shopTaxSettingsRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/delivery.shopTaxSettingsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ShopTaxSettings"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "delivery.PaySalaryRequest": {
+            "type": "object",
+            "properties": {
+                "adjustment_ids": {
+                    "description": "утверждённые корректировки, выплачиваемые вместе с зарплатой",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "amount": {
+                    "type": "number"
+                },
+                "employee_id": {
+                    "type": "integer"
+                },
+                "paid_at": {
+                    "description": "можно не указывать и взять time.Now()",
+                    "type": "string"
+                },
+                "pay_period_end": {
+                    "type": "string"
+                },
+                "pay_period_start": {
+                    "description": "строка, чтобы потом распарсить \"YYYY-MM-DD\"",
+                    "type": "string"
+                },
+                "payment_type": {
+                    "description": "regular (по умолчанию) или supplemental",
+                    "type": "string"
+                }
+            }
+        },
+        "delivery.adjustPayrollLineRequest": {
+            "type": "object",
+            "properties": {
+                "adjustment": {
+                    "description": "заменяет прежнюю корректировку",
+                    "type": "number"
+                },
+                "note": {
+                    "type": "string"
+                }
+            }
+        },
+        "delivery.approvePayrollRunRequest": {
+            "type": "object",
+            "properties": {
+                "approval_code": {
+                    "type": "string"
+                },
+                "manager_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "delivery.assignCommissionPlanRequest": {
+            "type": "object",
+            "properties": {
+                "effective_from": {
+                    "description": "\"YYYY-MM-DD\"",
+                    "type": "string"
+                },
+                "effective_to": {
+                    "description": "можно не указывать — бессрочно",
+                    "type": "string"
+                },
+                "employee_id": {
+                    "type": "integer"
+                },
+                "plan_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "delivery.bankAccountRequest": {
+            "type": "object",
+            "properties": {
+                "bic": {
+                    "type": "string"
+                },
+                "holder_name": {
+                    "type": "string"
+                },
+                "iban": {
+                    "type": "string"
+                }
+            }
+        },
+        "delivery.batchSaleRequest": {
+            "type": "object",
+            "properties": {
+                "client_id": {
+                    "description": "UUID с кассы; повторная отправка не создаёт второй чек",
+                    "type": "string"
+                },
+                "customer_id": {
+                    "description": "покупатель; можно указать loyalty_card вместо него",
+                    "type": "integer"
+                },
+                "employee_id": {
+                    "type": "integer"
+                },
+                "gift_cards": {
+                    "description": "продаваемые подарочные карты",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/delivery.giftCardRequest"
+                    }
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "type": "object",
+                        "properties": {
+                            "category": {
+                                "type": "string"
+                            },
+                            "item_id": {
+                                "type": "integer"
+                            },
+                            "price_at_sale": {
+                                "type": "number"
+                            },
+                            "quantity": {
+                                "type": "integer"
+                            },
+                            "tax_category": {
+                                "description": "по умолчанию \"standard\"",
+                                "type": "string"
+                            }
+                        }
+                    }
+                },
+                "loyalty_card": {
+                    "description": "номер карты лояльности",
+                    "type": "string"
+                },
+                "payment_method": {
+                    "description": "если payments не переданы — оплата одним способом на всю сумму",
+                    "type": "string"
+                },
+                "payments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/delivery.paymentRequest"
+                    }
+                },
+                "price_override_by": {
+                    "description": "менеджер, разрешивший цены не из каталога",
+                    "type": "integer"
+                },
+                "price_override_code": {
+                    "description": "его код подтверждения",
+                    "type": "string"
+                },
+                "redeem_points": {
+                    "description": "баллы, списываемые в счёт скидки на чек",
+                    "type": "integer"
+                },
+                "shop_id": {
+                    "type": "integer"
+                },
+                "transaction_time": {
+                    "description": "RFC 3339, время продажи на кассе",
+                    "type": "string"
+                },
+                "type": {
+                    "description": "\"sale\" (по умолчанию) или \"return\"",
+                    "type": "string"
+                }
+            }
+        },
+        "delivery.clockOutRequest": {
+            "type": "object",
+            "properties": {
+                "employee_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "delivery.commissionLine": {
+            "type": "object",
+            "properties": {
+                "basis": {
+                    "type": "string"
+                },
+                "commission": {
+                    "type": "number"
+                },
+                "rate": {
+                    "type": "number"
+                },
+                "revenue": {
+                    "type": "number"
+                }
+            }
+        },
+        "delivery.commissionStatement": {
+            "type": "object",
+            "properties": {
+                "commission": {
+                    "type": "number"
+                },
+                "employee_id": {
+                    "type": "integer"
+                },
+                "gross_sales": {
+                    "type": "number"
+                },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/delivery.commissionLine"
+                    }
+                },
+                "net_revenue": {
+                    "type": "number"
+                },
+                "period_end": {
+                    "type": "string"
+                },
+                "period_start": {
+                    "type": "string"
+                },
+                "plan_id": {
+                    "type": "integer"
+                },
+                "plan_name": {
+                    "type": "string"
+                },
+                "plan_type": {
+                    "type": "string"
+                },
+                "returns": {
+                    "type": "number"
+                }
+            }
+        },
+        "delivery.companySettingsRequest": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "bank_bic": {
+                    "type": "string"
+                },
+                "bank_csv_delimiter": {
+                    "description": "один символ, например \";\"",
+                    "type": "string"
+                },
+                "bank_csv_layout": {
+                    "description": "например \"name,iban,amount,reference\"",
+                    "type": "string"
+                },
+                "bank_iban": {
+                    "type": "string"
+                },
+                "currency": {
+                    "description": "пусто — EUR",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "overtime_multiplier": {
+                    "description": "0 — 1.5",
+                    "type": "number"
+                },
+                "overtime_weekly_hours": {
+                    "description": "0 — 40",
+                    "type": "number"
+                },
+                "payslip_footer": {
+                    "type": "string"
+                },
+                "payslip_html_template": {
+                    "type": "string"
+                },
+                "payslip_text_template": {
+                    "description": "шаблон для PDF",
+                    "type": "string"
+                },
+                "tax_number": {
+                    "type": "string"
+                }
+            }
+        },
+        "delivery.createAdjustmentRequest": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "description": "correction может быть отрицательной",
+                    "type": "number"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "effective_date": {
+                    "description": "YYYY-MM-DD; можно не указывать и взять сегодня",
+                    "type": "string"
+                },
+                "employee_id": {
+                    "type": "integer"
+                },
+                "reason_code": {
+                    "type": "string"
+                },
+                "type": {
+                    "description": "bonus, correction или reimbursement",
+                    "type": "string"
+                }
+            }
+        },
+        "delivery.createAdvanceRequest": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "description": "выдано сотруднику",
+                    "type": "number"
+                },
+                "employee_id": {
+                    "type": "integer"
+                },
+                "installment": {
+                    "type": "number"
+                },
+                "installments": {
+                    "description": "вместо installment: число равных долей",
+                    "type": "integer"
+                },
+                "issued_at": {
+                    "description": "YYYY-MM-DD; можно не указывать и взять сегодня",
+                    "type": "string"
+                },
+                "kind": {
+                    "description": "advance (по умолчанию) или loan",
+                    "type": "string"
+                },
+                "note": {
+                    "type": "string"
+                },
+                "recover_from": {
+                    "description": "YYYY-MM-DD; можно не указывать и взять issued_at",
+                    "type": "string"
+                }
+            }
+        },
+        "delivery.createCommissionPlanRequest": {
+            "type": "object",
+            "properties": {
+                "category_rates": {
+                    "type": "array",
+                    "items": {
+                        "type": "object",
+                        "properties": {
+                            "category": {
+                                "type": "string"
+                            },
+                            "rate": {
+                                "type": "number"
+                            }
+                        }
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "plan_type": {
+                    "description": "flat, tiered или category",
+                    "type": "string"
+                },
+                "rate": {
+                    "description": "проценты: 2.5 = 2.5%",
+                    "type": "number"
+                },
+                "tiers": {
+                    "type": "array",
+                    "items": {
+                        "type": "object",
+                        "properties": {
+                            "min_revenue": {
+                                "type": "number"
+                            },
+                            "rate": {
+                                "type": "number"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "delivery.createCustomerRequest": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "loyalty_card": {
+                    "description": "номер карты лояльности, необязательно",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "phone": {
+                    "type": "string"
+                }
+            }
+        },
+        "delivery.createEmployeeRequest": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "pay_rate": {
+                    "description": "оклад в месяц или ставка в час",
+                    "type": "number"
+                },
+                "pay_type": {
+                    "description": "monthly, hourly или пусто",
+                    "type": "string"
+                },
+                "role": {
+                    "description": "cashier (по умолчанию) или manager",
+                    "type": "string"
+                },
+                "shop_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "delivery.createPayrollRunRequest": {
+            "type": "object",
+            "properties": {
+                "period_end": {
+                    "description": "YYYY-MM-DD, включительно",
+                    "type": "string"
+                },
+                "period_start": {
+                    "description": "YYYY-MM-DD",
+                    "type": "string"
+                },
+                "shop_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "delivery.createPromotionRequest": {
+            "type": "object",
+            "properties": {
+                "buy_quantity": {
+                    "type": "integer"
+                },
+                "free_quantity": {
+                    "type": "integer"
+                },
+                "item_id": {
+                    "type": "integer"
+                },
+                "min_subtotal": {
+                    "type": "number"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "promotion_type": {
+                    "description": "percentage, fixed_amount, buy_x_get_y, bundle",
+                    "type": "string"
+                },
+                "scope": {
+                    "description": "line или check",
+                    "type": "string"
+                },
+                "shop_id": {
+                    "type": "integer"
+                },
+                "valid_from": {
+                    "description": "\"YYYY-MM-DD\"",
+                    "type": "string"
+                },
+                "valid_to": {
+                    "description": "включительно",
+                    "type": "string"
+                },
+                "value": {
+                    "type": "number"
+                }
+            }
+        },
+        "delivery.createSaleRequest": {
+            "type": "object",
+            "properties": {
+                "client_id": {
+                    "description": "UUID с кассы; повторная отправка не создаёт второй чек",
+                    "type": "string"
+                },
+                "customer_id": {
+                    "description": "покупатель; можно указать loyalty_card вместо него",
+                    "type": "integer"
+                },
+                "employee_id": {
+                    "type": "integer"
+                },
+                "gift_cards": {
+                    "description": "продаваемые подарочные карты",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/delivery.giftCardRequest"
+                    }
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "type": "object",
+                        "properties": {
+                            "category": {
+                                "type": "string"
+                            },
+                            "item_id": {
+                                "type": "integer"
+                            },
+                            "price_at_sale": {
+                                "type": "number"
+                            },
+                            "quantity": {
+                                "type": "integer"
+                            },
+                            "tax_category": {
+                                "description": "по умолчанию \"standard\"",
+                                "type": "string"
+                            }
+                        }
+                    }
+                },
+                "loyalty_card": {
+                    "description": "номер карты лояльности",
+                    "type": "string"
+                },
+                "payment_method": {
+                    "description": "если payments не переданы — оплата одним способом на всю сумму",
+                    "type": "string"
+                },
+                "payments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/delivery.paymentRequest"
+                    }
+                },
+                "price_override_by": {
+                    "description": "менеджер, разрешивший цены не из каталога",
+                    "type": "integer"
+                },
+                "price_override_code": {
+                    "description": "его код подтверждения",
+                    "type": "string"
+                },
+                "redeem_points": {
+                    "description": "баллы, списываемые в счёт скидки на чек",
+                    "type": "integer"
+                },
+                "shop_id": {
+                    "type": "integer"
+                },
+                "type": {
+                    "description": "\"sale\" (по умолчанию) или \"return\"",
+                    "type": "string"
+                }
+            }
+        },
+        "delivery.createSalesBatchRequest": {
+            "type": "object",
+            "properties": {
+                "sales": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/delivery.batchSaleRequest"
+                    }
+                }
+            }
+        },
+        "delivery.deductionRuleRequest": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "base_ceiling": {
+                    "type": "number"
+                },
+                "brackets": {
+                    "type": "array",
+                    "items": {
+                        "type": "object",
+                        "properties": {
+                            "rate": {
+                                "type": "number"
+                            },
+                            "threshold": {
+                                "type": "number"
+                            }
+                        }
+                    }
+                },
+                "employee_id": {
+                    "description": "не указан — для всех сотрудников",
+                    "type": "integer"
+                },
+                "employee_rate": {
+                    "description": "проценты: 2.5 = 2.5%",
+                    "type": "number"
+                },
+                "employer_rate": {
+                    "type": "number"
+                },
+                "kind": {
+                    "description": "income_tax, pension, social, fixed или garnishment",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "protected_net": {
+                    "type": "number"
+                }
+            }
+        },
+        "delivery.giftCardRequest": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "code": {
+                    "description": "номер напечатанной карты; если пусто — генерируется",
+                    "type": "string"
+                }
+            }
+        },
+        "delivery.loyaltyRuleRequest": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "description": "пусто — любая категория",
+                    "type": "string"
+                },
+                "item_id": {
+                    "description": "0 — любой товар",
+                    "type": "integer"
+                },
+                "points_per_unit": {
+                    "type": "number"
+                },
+                "shop_id": {
+                    "description": "0 — во всех магазинах",
+                    "type": "integer"
+                }
+            }
+        },
+        "delivery.payAdjustmentRequest": {
+            "type": "object",
+            "properties": {
+                "paid_at": {
+                    "description": "YYYY-MM-DD; можно не указывать и взять time.Now()",
+                    "type": "string"
+                }
+            }
+        },
+        "delivery.payPayrollRunRequest": {
+            "type": "object",
+            "properties": {
+                "paid_at": {
+                    "description": "YYYY-MM-DD; можно не указывать и взять time.Now()",
+                    "type": "string"
+                }
+            }
+        },
+        "delivery.paymentRequest": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "method": {
+                    "type": "string"
+                },
+                "reference": {
+                    "description": "номер авторизации карты и т.п.",
+                    "type": "string"
+                }
+            }
+        },
+        "delivery.reissueSalaryRequest": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "description": "исправленное брутто без корректировок исходной выплаты",
+                    "type": "number"
+                },
+                "approval_code": {
+                    "description": "код подтверждения менеджера",
+                    "type": "string"
+                },
+                "manager_id": {
+                    "type": "integer"
+                },
+                "paid_at": {
+                    "description": "YYYY-MM-DD; можно не указывать и взять time.Now()",
+                    "type": "string"
+                },
+                "pay_period_end": {
+                    "type": "string"
+                },
+                "pay_period_start": {
+                    "description": "YYYY-MM-DD; по умолчанию как у исходной выплаты",
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
+        "delivery.reverseSalaryRequest": {
+            "type": "object",
+            "properties": {
+                "approval_code": {
+                    "description": "код подтверждения менеджера",
+                    "type": "string"
+                },
+                "manager_id": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "reversed_at": {
+                    "description": "YYYY-MM-DD; можно не указывать и взять time.Now()",
+                    "type": "string"
+                }
+            }
+        },
+        "delivery.reviewAdjustmentRequest": {
+            "type": "object",
+            "properties": {
+                "approval_code": {
+                    "type": "string"
+                },
+                "manager_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "delivery.salaryBankExportRequest": {
+            "type": "object",
+            "properties": {
+                "execution_date": {
+                    "description": "YYYY-MM-DD, по умолчанию сегодня",
+                    "type": "string"
+                },
+                "format": {
+                    "description": "pain001 (по умолчанию) или csv",
+                    "type": "string"
+                },
+                "reexport": {
+                    "type": "boolean"
+                },
+                "salary_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "delivery.setApprovalCodeRequest": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                }
+            }
+        },
+        "delivery.setPayRequest": {
+            "type": "object",
+            "properties": {
+                "pay_rate": {
+                    "type": "number"
+                },
+                "pay_type": {
+                    "type": "string"
+                }
+            }
+        },
+        "delivery.setPaymentMethodsRequest": {
+            "type": "object",
+            "properties": {
+                "methods": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "delivery.setTaxRateRequest": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "type": "string"
+                },
+                "rate": {
+                    "description": "проценты: 12 = 12%",
+                    "type": "number"
+                },
+                "shop_id": {
+                    "description": "0 — ставка по умолчанию для всех магазинов",
+                    "type": "integer"
+                }
+            }
+        },
+        "delivery.shopRequest": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "phone": {
+                    "type": "string"
+                },
+                "receipt_continuous": {
+                    "description": "не сбрасывать нумерацию в начале года",
+                    "type": "boolean"
+                },
+                "receipt_footer": {
+                    "type": "string"
+                },
+                "receipt_header": {
+                    "type": "string"
+                },
+                "receipt_html_template": {
+                    "type": "string"
+                },
+                "receipt_prefix": {
+                    "description": "{SHOP}, {YYYY}, {YY}",
+                    "type": "string"
+                },
+                "receipt_text_template": {
+                    "type": "string"
+                },
+                "tax_number": {
+                    "type": "string"
+                }
+            }
+        },
+        "delivery.shopTaxSettingsRequest": {
+            "type": "object",
+            "properties": {
+                "prices_include_tax": {
+                    "type": "boolean"
+                },
+                "rounding_level": {
+                    "description": "line (по умолчанию) или total",
+                    "type": "string"
+                },
+                "rounding_mode": {
+                    "description": "half_up (по умолчанию), half_even, down",
+                    "type": "string"
+                }
+            }
+        },
+        "delivery.voidSaleRequest": {
+            "type": "object",
+            "properties": {
+                "approval_code": {
+                    "description": "код подтверждения менеджера",
+                    "type": "string"
+                },
+                "employee_id": {
+                    "description": "кто аннулирует",
+                    "type": "integer"
+                },
+                "manager_id": {
+                    "description": "нужен после окна аннулирования",
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
+        "importer.Report": {
+            "type": "object",
+            "properties": {
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/importer.RowError"
+                    }
+                },
+                "errors_truncated": {
+                    "description": "ErrorsTruncated — в Errors попали не все ошибки",
+                    "type": "boolean"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "imported": {
+                    "description": "в режиме dry_run — сколько было бы записано",
+                    "type": "integer"
+                },
+                "rows": {
+                    "type": "integer"
+                },
+                "sales": {
+                    "type": "integer"
+                },
+                "skipped": {
+                    "description": "уже импортированы ранее",
+                    "type": "integer"
+                }
+            }
+        },
+        "importer.RowError": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "external_id": {
+                    "type": "string"
+                },
+                "line": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.CommissionCategoryRate": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "planID": {
+                    "type": "integer"
+                },
+                "rate": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.CommissionPlan": {
+            "type": "object",
+            "properties": {
+                "categoryRates": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CommissionCategoryRate"
+                    }
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "planType": {
+                    "type": "string"
+                },
+                "rate": {
+                    "description": "flat rate, or default rate for categories without their own",
+                    "type": "number"
+                },
+                "tiers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CommissionTier"
+                    }
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CommissionTier": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "minRevenue": {
+                    "type": "number"
+                },
+                "planID": {
+                    "type": "integer"
+                },
+                "rate": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.CompanySettings": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "bankBIC": {
+                    "type": "string"
+                },
+                "bankCSVDelimiter": {
+                    "description": "один символ; пусто — \",\"",
+                    "type": "string"
+                },
+                "bankCSVLayout": {
+                    "description": "колонки через запятую; пусто — по умолчанию",
+                    "type": "string"
+                },
+                "bankIBAN": {
+                    "description": "счёт, с которого платится зарплата",
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "currency": {
+                    "description": "ISO 4217; пусто — EUR",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "overtimeMultiplier": {
+                    "description": "0 — 1.5",
+                    "type": "number"
+                },
+                "overtimeWeeklyHours": {
+                    "description": "0 — 40 часов",
+                    "type": "number"
+                },
+                "payslipFooter": {
+                    "type": "string"
+                },
+                "payslipHTMLTemplate": {
+                    "description": "html/template; пусто — шаблон по умолчанию",
+                    "type": "string"
+                },
+                "payslipTextTemplate": {
+                    "description": "text/template для PDF; пусто — шаблон по умолчанию",
+                    "type": "string"
+                },
+                "taxNumber": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Customer": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "loyaltyCard": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "phone": {
+                    "type": "string"
+                },
+                "pointsBalance": {
+                    "type": "integer"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.DeductionRule": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "amount": {
+                    "description": "фиксированная сумма за выплату",
+                    "type": "number"
+                },
+                "baseCeiling": {
+                    "description": "предел базы взносов за выплату; 0 — без предела",
+                    "type": "number"
+                },
+                "brackets": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TaxBracket"
+                    }
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "employeeID": {
+                    "description": "nil — для всех сотрудников",
+                    "type": "integer"
+                },
+                "employeeRate": {
+                    "description": "удерживается с сотрудника",
+                    "type": "number"
+                },
+                "employerRate": {
+                    "description": "платит работодатель сверх начисленного",
+                    "type": "number"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "protectedNet": {
+                    "description": "для garnishment: сколько должно остаться к выплате",
+                    "type": "number"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Employee": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "payRate": {
+                    "description": "оклад в месяц или ставка в час",
+                    "type": "number"
+                },
+                "payType": {
+                    "description": "monthly, hourly или пусто — без оклада",
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "shopID": {
+                    "type": "integer"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.EmployeeBankAccount": {
+            "type": "object",
+            "properties": {
+                "bic": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "employeeID": {
+                    "type": "integer"
+                },
+                "holderName": {
+                    "type": "string"
+                },
+                "ibanmasked": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.LoyaltyRule": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "category": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "itemID": {
+                    "type": "integer"
+                },
+                "pointsPerUnit": {
+                    "type": "number"
+                },
+                "shopID": {
+                    "type": "integer"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.PayrollLine": {
+            "type": "object",
+            "properties": {
+                "adjustment": {
+                    "description": "ручная корректировка, может быть отрицательной",
+                    "type": "number"
+                },
+                "adjustmentLines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SalaryAdjustment"
+                    }
+                },
+                "adjustmentNote": {
+                    "type": "string"
+                },
+                "amount": {
+                    "description": "брутто",
+                    "type": "number"
+                },
+                "basePay": {
+                    "type": "number"
+                },
+                "bonuses": {
+                    "type": "number"
+                },
+                "commission": {
+                    "type": "number"
+                },
+                "corrections": {
+                    "type": "number"
+                },
+                "deductionLines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SalaryDeduction"
+                    }
+                },
+                "deductions": {
+                    "type": "number"
+                },
+                "employeeID": {
+                    "type": "integer"
+                },
+                "employerContributions": {
+                    "type": "number"
+                },
+                "hours": {
+                    "description": "обычные часы для почасовой оплаты",
+                    "type": "number"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "netAmount": {
+                    "description": "к перечислению",
+                    "type": "number"
+                },
+                "overtimeHours": {
+                    "description": "часы сверх недельной нормы",
+                    "type": "number"
+                },
+                "overtimePay": {
+                    "type": "number"
+                },
+                "payRate": {
+                    "type": "number"
+                },
+                "payType": {
+                    "type": "string"
+                },
+                "reimbursements": {
+                    "description": "не входят в брутто",
+                    "type": "number"
+                },
+                "runID": {
+                    "type": "integer"
+                },
+                "salaryPaymentID": {
+                    "type": "integer"
+                },
+                "shopID": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.PayrollRun": {
+            "type": "object",
+            "properties": {
+                "approvedAt": {
+                    "type": "string"
+                },
+                "approvedBy": {
+                    "type": "integer"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PayrollLine"
+                    }
+                },
+                "paidAt": {
+                    "type": "string"
+                },
+                "periodEnd": {
+                    "description": "включительно",
+                    "type": "string"
+                },
+                "periodStart": {
+                    "type": "string"
+                },
+                "shopIDs": {
+                    "description": "через запятую, например \"1,3\"",
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "totalAmount": {
+                    "type": "number"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
             }
         },
-        "delivery.clockOutRequest": {
+        "models.Promotion": {
             "type": "object",
             "properties": {
-                "employee_id": {
+                "active": {
+                    "type": "boolean"
+                },
+                "buyQuantity": {
+                    "type": "integer"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "freeQuantity": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "itemID": {
+                    "description": "0 — любой товар (только для line)",
+                    "type": "integer"
+                },
+                "minSubtotal": {
+                    "description": "порог суммы чека для check-скидок",
+                    "type": "number"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "promotionType": {
+                    "type": "string"
+                },
+                "scope": {
+                    "type": "string"
+                },
+                "shopID": {
+                    "description": "0 — во всех магазинах",
                     "type": "integer"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "validFrom": {
+                    "type": "string"
+                },
+                "validTo": {
+                    "description": "последний день, включительно",
+                    "type": "string"
+                },
+                "value": {
+                    "type": "number"
                 }
             }
         },
-        "delivery.createSaleRequest": {
+        "models.SalaryAdjustment": {
             "type": "object",
             "properties": {
-                "employee_id": {
+                "amount": {
+                    "type": "number"
+                },
+                "approvedAt": {
+                    "type": "string"
+                },
+                "approvedBy": {
+                    "description": "менеджер, утвердивший или отклонивший",
                     "type": "integer"
                 },
-                "items": {
-                    "type": "array",
-                    "items": {
-                        "type": "object",
-                        "properties": {
-                            "item_id": {
-                                "type": "integer"
-                            },
-                            "price_at_sale": {
-                                "type": "number"
-                            },
-                            "quantity": {
-                                "type": "integer"
-                            }
-                        }
-                    }
+                "createdAt": {
+                    "type": "string"
                 },
-                "payment_method": {
+                "description": {
                     "type": "string"
                 },
-                "shop_id": {
+                "effectiveDate": {
+                    "description": "к какому дню относится",
+                    "type": "string"
+                },
+                "employeeID": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "payrollLineID": {
+                    "type": "integer"
+                },
+                "reasonCode": {
+                    "type": "string"
+                },
+                "salaryPaymentID": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.SalaryAdvance": {
+            "type": "object",
+            "properties": {
+                "balance": {
+                    "description": "осталось вернуть",
+                    "type": "number"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "employeeID": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "installment": {
+                    "description": "удерживается с каждой зарплаты",
+                    "type": "number"
+                },
+                "issuedAt": {
+                    "type": "string"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "note": {
+                    "type": "string"
+                },
+                "principal": {
+                    "type": "number"
+                },
+                "recoverFrom": {
+                    "description": "первый период, с которого удерживаем",
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.SalaryDeduction": {
+            "type": "object",
+            "properties": {
+                "advanceID": {
+                    "type": "integer"
+                },
+                "amount": {
+                    "description": "удержано с сотрудника",
+                    "type": "number"
+                },
+                "base": {
+                    "description": "сумма, с которой считали",
+                    "type": "number"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "employerAmount": {
+                    "description": "взнос работодателя",
+                    "type": "number"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "payrollLineID": {
+                    "type": "integer"
+                },
+                "ruleID": {
+                    "type": "integer"
+                },
+                "salaryPaymentID": {
                     "type": "integer"
                 }
             }
@@ -346,15 +6051,41 @@ const docTemplate = `{
         "models.SalaryPayment": {
             "type": "object",
             "properties": {
+                "adjustmentLines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SalaryAdjustment"
+                    }
+                },
                 "amount": {
+                    "description": "начислено (брутто)",
+                    "type": "number"
+                },
+                "approvedBy": {
+                    "description": "менеджер, утвердивший сторно",
+                    "type": "integer"
+                },
+                "deductionLines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SalaryDeduction"
+                    }
+                },
+                "deductions": {
                     "type": "number"
                 },
                 "employeeID": {
                     "type": "integer"
                 },
+                "employerContributions": {
+                    "type": "number"
+                },
                 "id": {
                     "type": "integer"
                 },
+                "netAmount": {
+                    "type": "number"
+                },
                 "paidAt": {
                     "type": "string"
                 },
@@ -363,6 +6094,142 @@ const docTemplate = `{
                 },
                 "payPeriodStart": {
                     "type": "string"
+                },
+                "paymentType": {
+                    "type": "string"
+                },
+                "payrollRunID": {
+                    "description": "ведомость, по которой выплачено",
+                    "type": "integer"
+                },
+                "reason": {
+                    "description": "причина сторно или перевыпуска",
+                    "type": "string"
+                },
+                "reimbursements": {
+                    "type": "number"
+                },
+                "reissueOfID": {
+                    "description": "выплата взамен сторнированной",
+                    "type": "integer"
+                },
+                "reversalOfID": {
+                    "description": "для reversal: сторнируемая выплата",
+                    "type": "integer"
+                },
+                "reversedAt": {
+                    "description": "выплата сторнирована",
+                    "type": "string"
+                }
+            }
+        },
+        "models.Shop": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "phone": {
+                    "type": "string"
+                },
+                "receiptContinuous": {
+                    "description": "true — нумерация без сброса в начале года",
+                    "type": "boolean"
+                },
+                "receiptFooter": {
+                    "type": "string"
+                },
+                "receiptHTMLTemplate": {
+                    "description": "html/template; пусто — шаблон по умолчанию",
+                    "type": "string"
+                },
+                "receiptHeader": {
+                    "type": "string"
+                },
+                "receiptPrefix": {
+                    "description": "{SHOP}, {YYYY}, {YY}; пусто — \"{SHOP}-{YYYY}-\"",
+                    "type": "string"
+                },
+                "receiptTextTemplate": {
+                    "description": "text/template; пусто — шаблон по умолчанию",
+                    "type": "string"
+                },
+                "taxNumber": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ShopTaxSettings": {
+            "type": "object",
+            "properties": {
+                "pricesIncludeTax": {
+                    "type": "boolean"
+                },
+                "roundingLevel": {
+                    "description": "line или total",
+                    "type": "string"
+                },
+                "roundingMode": {
+                    "description": "half_up, half_even, down",
+                    "type": "string"
+                },
+                "shopID": {
+                    "type": "integer"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.TaxBracket": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "rate": {
+                    "type": "number"
+                },
+                "ruleID": {
+                    "type": "integer"
+                },
+                "threshold": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.TaxRate": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "rate": {
+                    "type": "number"
+                },
+                "shopID": {
+                    "type": "integer"
+                },
+                "updatedAt": {
+                    "type": "string"
                 }
             }
         }
@@ -0,0 +1,77 @@
+package banking
+
+import (
+    "crypto/aes"
+    "crypto/cipher"
+    "crypto/rand"
+    "encoding/base64"
+    "errors"
+    "fmt"
+    "os"
+)
+
+// ErrNoKey is returned by CipherFromEnv when BANK_DATA_KEY is not set.
+var ErrNoKey = errors.New("BANK_DATA_KEY is not set")
+
+// Cipher encrypts bank details at rest with AES-256-GCM. Ciphertexts are
+// base64 of nonce followed by the sealed data.
+type Cipher struct {
+    aead cipher.AEAD
+}
+
+// NewCipher creates a Cipher from a 32-byte key.
+func NewCipher(key []byte) (*Cipher, error) {
+    if len(key) != 32 {
+        return nil, fmt.Errorf("bank data key must be 32 bytes, got %d", len(key))
+    }
+    block, err := aes.NewCipher(key)
+    if err != nil {
+        return nil, err
+    }
+    aead, err := cipher.NewGCM(block)
+    if err != nil {
+        return nil, err
+    }
+    return &Cipher{aead: aead}, nil
+}
+
+// CipherFromEnv reads the key from BANK_DATA_KEY, base64-encoded.
+func CipherFromEnv() (*Cipher, error) {
+    v := os.Getenv("BANK_DATA_KEY")
+    if v == "" {
+        return nil, ErrNoKey
+    }
+    key, err := base64.StdEncoding.DecodeString(v)
+    if err != nil {
+        return nil, fmt.Errorf("BANK_DATA_KEY must be base64: %w", err)
+    }
+    return NewCipher(key)
+}
+
+// Encrypt seals plaintext. additional binds the ciphertext to its owner (e.g.
+// the employee ID), so it cannot be copied to another row.
+func (c *Cipher) Encrypt(plaintext, additional string) (string, error) {
+    nonce := make([]byte, c.aead.NonceSize())
+    if _, err := rand.Read(nonce); err != nil {
+        return "", err
+    }
+    sealed := c.aead.Seal(nonce, nonce, []byte(plaintext), []byte(additional))
+    return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a value produced by Encrypt with the same additional data.
+func (c *Cipher) Decrypt(ciphertext, additional string) (string, error) {
+    data, err := base64.StdEncoding.DecodeString(ciphertext)
+    if err != nil {
+        return "", err
+    }
+    if len(data) < c.aead.NonceSize() {
+        return "", errors.New("ciphertext is too short")
+    }
+    nonce, sealed := data[:c.aead.NonceSize()], data[c.aead.NonceSize():]
+    plaintext, err := c.aead.Open(nil, nonce, sealed, []byte(additional))
+    if err != nil {
+        return "", err
+    }
+    return string(plaintext), nil
+}
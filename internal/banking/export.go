@@ -0,0 +1,269 @@
+package banking
+
+import (
+    "encoding/csv"
+    "encoding/xml"
+    "fmt"
+    "io"
+    "math"
+    "strings"
+    "time"
+)
+
+// Transfer is one salary transfer of a batch.
+type Transfer struct {
+    EndToEndID string
+    Name       string
+    IBAN       string
+    BIC        string
+    Amount     float64
+    Reference  string // назначение платежа
+}
+
+// Batch is a set of transfers from one debtor account.
+type Batch struct {
+    MessageID     string
+    Created       time.Time
+    ExecutionDate time.Time
+    DebtorName    string
+    DebtorIBAN    string
+    DebtorBIC     string
+    Currency      string
+    Transfers     []Transfer
+}
+
+func cents(amount float64) int64 {
+    return int64(math.Round(amount * 100))
+}
+
+func formatCents(c int64) string {
+    return fmt.Sprintf("%d.%02d", c/100, c%100)
+}
+
+// ControlSum is the sum of all transfer amounts, computed in cents.
+func (b *Batch) ControlSum() string {
+    var sum int64
+    for _, t := range b.Transfers {
+        sum += cents(t.Amount)
+    }
+    return formatCents(sum)
+}
+
+// limit cuts s to the maximum length of a pain.001 text field.
+func limit(s string, n int) string {
+    r := []rune(s)
+    if len(r) > n {
+        r = r[:n]
+    }
+    return string(r)
+}
+
+// Элементы pain.001.001.03 в порядке схемы
+type painDocument struct {
+    XMLName xml.Name `xml:"urn:iso:std:iso:20022:tech:xsd:pain.001.001.03 Document"`
+    Initn   struct {
+        GrpHdr struct {
+            MsgID    string `xml:"MsgId"`
+            CreDtTm  string `xml:"CreDtTm"`
+            NbOfTxs  int    `xml:"NbOfTxs"`
+            CtrlSum  string `xml:"CtrlSum"`
+            InitgPty struct {
+                Nm string `xml:"Nm"`
+            } `xml:"InitgPty"`
+        } `xml:"GrpHdr"`
+        PmtInf painPaymentInfo `xml:"PmtInf"`
+    } `xml:"CstmrCdtTrfInitn"`
+}
+
+type painPaymentInfo struct {
+    PmtInfID    string       `xml:"PmtInfId"`
+    PmtMtd      string       `xml:"PmtMtd"`
+    BtchBookg   bool         `xml:"BtchBookg"`
+    NbOfTxs     int          `xml:"NbOfTxs"`
+    CtrlSum     string       `xml:"CtrlSum"`
+    PmtTpInf    painTypeInfo `xml:"PmtTpInf"`
+    ReqdExctnDt string       `xml:"ReqdExctnDt"`
+    Dbtr        painParty    `xml:"Dbtr"`
+    DbtrAcct    painAccount  `xml:"DbtrAcct"`
+    DbtrAgt     painAgent    `xml:"DbtrAgt"`
+    ChrgBr      string       `xml:"ChrgBr"`
+    Txs         []painTx     `xml:"CdtTrfTxInf"`
+}
+
+type painTypeInfo struct {
+    SvcLvl *struct {
+        Cd string `xml:"Cd"`
+    } `xml:"SvcLvl,omitempty"`
+    CtgyPurp struct {
+        Cd string `xml:"Cd"`
+    } `xml:"CtgyPurp"`
+}
+
+type painParty struct {
+    Nm string `xml:"Nm"`
+}
+
+type painAccount struct {
+    IBAN string `xml:"Id>IBAN"`
+    Ccy  string `xml:"Ccy,omitempty"`
+}
+
+// encoding/xml writes the parents of an empty a>b field, so optional
+// elements are pointers.
+type painAgent struct {
+    BIC   string     `xml:"FinInstnId>BIC,omitempty"`
+    Other *painOther `xml:"FinInstnId>Othr,omitempty"`
+}
+
+type painOther struct {
+    ID string `xml:"Id"`
+}
+
+type painRemittance struct {
+    Ustrd string `xml:"Ustrd"`
+}
+
+type painTx struct {
+    EndToEndID string `xml:"PmtId>EndToEndId"`
+    Amt        struct {
+        Value string `xml:",chardata"`
+        Ccy   string `xml:"Ccy,attr"`
+    } `xml:"Amt>InstdAmt"`
+    CdtrAgt  *painAgent      `xml:"CdtrAgt,omitempty"`
+    Cdtr     painParty       `xml:"Cdtr"`
+    CdtrAcct painAccount     `xml:"CdtrAcct"`
+    Purp     string          `xml:"Purp>Cd"`
+    RmtInf   *painRemittance `xml:"RmtInf,omitempty"`
+}
+
+func agent(bic string) painAgent {
+    if bic == "" {
+        return painAgent{Other: &painOther{ID: "NOTPROVIDED"}}
+    }
+    return painAgent{BIC: bic}
+}
+
+// WritePain001 writes the batch as an ISO 20022 pain.001.001.03 customer
+// credit transfer initiation with category purpose SALA.
+func WritePain001(w io.Writer, b *Batch) error {
+    var doc painDocument
+    hdr := &doc.Initn.GrpHdr
+    hdr.MsgID = limit(b.MessageID, 35)
+    hdr.CreDtTm = b.Created.Format("2006-01-02T15:04:05")
+    hdr.NbOfTxs = len(b.Transfers)
+    hdr.CtrlSum = b.ControlSum()
+    hdr.InitgPty.Nm = limit(b.DebtorName, 70)
+
+    info := &doc.Initn.PmtInf
+    info.PmtInfID = limit(b.MessageID, 35)
+    info.PmtMtd = "TRF"
+    info.BtchBookg = true
+    info.NbOfTxs = len(b.Transfers)
+    info.CtrlSum = b.ControlSum()
+    // SEPA — только для евро
+    if b.Currency == "EUR" {
+        info.PmtTpInf.SvcLvl = &struct {
+            Cd string `xml:"Cd"`
+        }{Cd: "SEPA"}
+    }
+    info.PmtTpInf.CtgyPurp.Cd = "SALA"
+    info.ReqdExctnDt = b.ExecutionDate.Format("2006-01-02")
+    info.Dbtr.Nm = limit(b.DebtorName, 70)
+    info.DbtrAcct = painAccount{IBAN: b.DebtorIBAN, Ccy: b.Currency}
+    info.DbtrAgt = agent(b.DebtorBIC)
+    info.ChrgBr = "SLEV"
+
+    for _, t := range b.Transfers {
+        tx := painTx{
+            EndToEndID: limit(t.EndToEndID, 35),
+            Cdtr:       painParty{Nm: limit(t.Name, 70)},
+            CdtrAcct:   painAccount{IBAN: t.IBAN},
+            Purp:       "SALA",
+        }
+        tx.Amt.Value = formatCents(cents(t.Amount))
+        tx.Amt.Ccy = b.Currency
+        if t.BIC != "" {
+            tx.CdtrAgt = &painAgent{BIC: t.BIC}
+        }
+        if t.Reference != "" {
+            tx.RmtInf = &painRemittance{Ustrd: limit(t.Reference, 140)}
+        }
+        info.Txs = append(info.Txs, tx)
+    }
+
+    if _, err := io.WriteString(w, xml.Header); err != nil {
+        return err
+    }
+    enc := xml.NewEncoder(w)
+    enc.Indent("", "  ")
+    if err := enc.Encode(doc); err != nil {
+        return err
+    }
+    _, err := io.WriteString(w, "\n")
+    return err
+}
+
+// CSVColumns are the columns a CSV layout can use.
+var CSVColumns = []string{"name", "iban", "bic", "amount", "currency", "reference", "end_to_end_id", "execution_date"}
+
+// DefaultCSVLayout is used when no layout is configured.
+const DefaultCSVLayout = "name,iban,bic,amount,currency,reference"
+
+// ParseCSVLayout splits a comma-separated list of column names and checks
+// them against CSVColumns.
+func ParseCSVLayout(layout string) ([]string, error) {
+    if strings.TrimSpace(layout) == "" {
+        layout = DefaultCSVLayout
+    }
+    var columns []string
+    for _, col := range strings.Split(layout, ",") {
+        col = strings.TrimSpace(col)
+        known := false
+        for _, c := range CSVColumns {
+            known = known || c == col
+        }
+        if !known {
+            return nil, fmt.Errorf("unknown CSV column %q, use %s", col, strings.Join(CSVColumns, ", "))
+        }
+        columns = append(columns, col)
+    }
+    return columns, nil
+}
+
+// WriteCSV writes one row per transfer with the given columns, preceded by a
+// header row.
+func WriteCSV(w io.Writer, b *Batch, columns []string, delimiter rune) error {
+    cw := csv.NewWriter(w)
+    cw.Comma = delimiter
+    if err := cw.Write(columns); err != nil {
+        return err
+    }
+    for _, t := range b.Transfers {
+        row := make([]string, len(columns))
+        for i, col := range columns {
+            switch col {
+            case "name":
+                row[i] = t.Name
+            case "iban":
+                row[i] = t.IBAN
+            case "bic":
+                row[i] = t.BIC
+            case "amount":
+                row[i] = formatCents(cents(t.Amount))
+            case "currency":
+                row[i] = b.Currency
+            case "reference":
+                row[i] = t.Reference
+            case "end_to_end_id":
+                row[i] = t.EndToEndID
+            case "execution_date":
+                row[i] = b.ExecutionDate.Format("2006-01-02")
+            }
+        }
+        if err := cw.Write(row); err != nil {
+            return err
+        }
+    }
+    cw.Flush()
+    return cw.Error()
+}
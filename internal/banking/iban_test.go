@@ -0,0 +1,52 @@
+package banking
+
+import "testing"
+
+func TestValidateIBAN(t *testing.T) {
+    tests := []struct {
+        name    string
+        iban    string
+        wantErr bool
+    }{
+        {"germany", "DE89370400440532013000", false},
+        {"united kingdom", "GB82WEST12345698765432", false},
+        {"netherlands", "NL91ABNA0417164300", false},
+        {"france with letters in the account", "FR1420041010050500013M02606", false},
+        {"norway, shortest", "NO9386011117947", false},
+        {"normalized input", NormalizeIBAN("de89 3704 0044 0532 0130 00"), false},
+        {"wrong check digits", "DE88370400440532013000", true},
+        {"changed account digit", "DE89370400440532013001", true},
+        {"too short", "DE8937040044", true},
+        {"too long", "DE89370400440532013000000000000000000", true},
+        {"wrong length for country", "DE893704004405320130001", true},
+        {"lower case", "de89370400440532013000", true},
+        {"country code with digit", "D189370400440532013000", true},
+        {"letters in check digits", "DEAB370400440532013000", true},
+        {"punctuation", "DE89-3704-0044-0532-0130-00", true},
+        {"spaces", "DE89 3704 0044 0532 0130 00", true},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            err := ValidateIBAN(tt.iban)
+            if (err != nil) != tt.wantErr {
+                t.Errorf("ValidateIBAN(%q) = %v, want error %v", tt.iban, err, tt.wantErr)
+            }
+        })
+    }
+}
+
+func TestMaskIBAN(t *testing.T) {
+    tests := []struct {
+        iban string
+        want string
+    }{
+        {"DE89370400440532013000", "DE****************3000"},
+        {"NO9386011117947", "NO*********7947"},
+        {"DE89", "DE89"},
+    }
+    for _, tt := range tests {
+        if got := MaskIBAN(tt.iban); got != tt.want {
+            t.Errorf("MaskIBAN(%q) = %q, want %q", tt.iban, got, tt.want)
+        }
+    }
+}
@@ -0,0 +1,83 @@
+package catalog
+
+import (
+    "errors"
+    "sync"
+    "time"
+)
+
+// ErrCircuitOpen is returned without calling the catalog while the circuit
+// breaker is open.
+var ErrCircuitOpen = errors.New("catalog service unavailable: circuit open")
+
+const (
+    BreakerClosed   = "closed"
+    BreakerOpen     = "open"
+    BreakerHalfOpen = "half_open"
+)
+
+// breaker opens after threshold consecutive failures. After cooldown a single
+// probe request is let through; its result closes or reopens the circuit.
+type breaker struct {
+    threshold int
+    cooldown  time.Duration
+
+    mu       sync.Mutex
+    state    string
+    failures int
+    openedAt time.Time
+    probing  bool
+}
+
+func newBreaker(threshold int, cooldown time.Duration) *breaker {
+    return &breaker{threshold: threshold, cooldown: cooldown, state: BreakerClosed}
+}
+
+func (b *breaker) allow() bool {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+
+    switch b.state {
+    case BreakerOpen:
+        if time.Since(b.openedAt) < b.cooldown {
+            return false
+        }
+        b.state = BreakerHalfOpen
+        b.probing = true
+        return true
+    case BreakerHalfOpen:
+        // Пока пробный запрос не завершился, остальные не пускаем
+        if b.probing {
+            return false
+        }
+        b.probing = true
+        return true
+    default:
+        return true
+    }
+}
+
+func (b *breaker) success() {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    b.state = BreakerClosed
+    b.failures = 0
+    b.probing = false
+}
+
+func (b *breaker) failure() {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    b.failures++
+    b.probing = false
+    if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+        b.state = BreakerOpen
+        b.openedAt = time.Now()
+    }
+}
+
+func (b *breaker) currentState() string {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    return b.state
+}
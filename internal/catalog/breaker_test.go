@@ -0,0 +1,90 @@
+package catalog
+
+import (
+    "context"
+    "errors"
+    "net/http"
+    "sync/atomic"
+    "testing"
+    "time"
+)
+
+// errFailed stands for any error other than ErrCircuitOpen in TestBreaker.
+var errFailed = errors.New("catalog call failed")
+
+func TestBreaker(t *testing.T) {
+    const cooldown = 50 * time.Millisecond
+    var healthy int32
+    srv, requests := catalogServer(t, func(int64) int {
+        if atomic.LoadInt32(&healthy) == 1 {
+            return http.StatusOK
+        }
+        return http.StatusInternalServerError
+    })
+    c := NewClient(Config{BaseURL: srv.URL, BreakerThreshold: 2, BreakerCooldown: cooldown})
+
+    steps := []struct {
+        name         string
+        wait         bool // дождаться конца паузы
+        healthy      bool
+        wantErr      error
+        wantState    string
+        wantRequests int64
+    }{
+        {"first failure", false, false, errFailed, BreakerClosed, 1},
+        {"threshold reached", false, false, errFailed, BreakerOpen, 2},
+        {"open circuit rejects without a request", false, false, ErrCircuitOpen, BreakerOpen, 2},
+        {"failed probe reopens", true, false, errFailed, BreakerOpen, 3},
+        {"reopened circuit rejects", false, false, ErrCircuitOpen, BreakerOpen, 3},
+        {"successful probe closes", true, true, nil, BreakerClosed, 4},
+        {"closed circuit", false, true, nil, BreakerClosed, 5},
+    }
+    for _, s := range steps {
+        if s.wait {
+            time.Sleep(cooldown)
+        }
+        if s.healthy {
+            atomic.StoreInt32(&healthy, 1)
+        }
+        _, err := c.Prices(context.Background(), []uint{1})
+        switch {
+        case s.wantErr == nil && err != nil:
+            t.Fatalf("%s: Prices() error = %v, want none", s.name, err)
+        case s.wantErr == ErrCircuitOpen && !errors.Is(err, ErrCircuitOpen):
+            t.Fatalf("%s: Prices() error = %v, want %v", s.name, err, ErrCircuitOpen)
+        case s.wantErr == errFailed && (err == nil || errors.Is(err, ErrCircuitOpen)):
+            t.Fatalf("%s: Prices() error = %v, want a failed call", s.name, err)
+        }
+        if got := c.BreakerState(); got != s.wantState {
+            t.Fatalf("%s: breaker state = %s, want %s", s.name, got, s.wantState)
+        }
+        if got := atomic.LoadInt64(requests); got != s.wantRequests {
+            t.Fatalf("%s: catalog got %d requests, want %d", s.name, got, s.wantRequests)
+        }
+    }
+    if got := c.Metrics.Snapshot()["prices"].Rejected; got != 2 {
+        t.Errorf("rejected calls = %d, want 2", got)
+    }
+}
+
+func TestBreakerHalfOpenLetsOneProbeThrough(t *testing.T) {
+    b := newBreaker(1, time.Millisecond)
+    b.failure()
+    if b.allow() {
+        t.Fatal("open breaker allowed a call before the cooldown")
+    }
+    time.Sleep(2 * time.Millisecond)
+    if !b.allow() {
+        t.Fatal("breaker did not allow a probe after the cooldown")
+    }
+    if got := b.currentState(); got != BreakerHalfOpen {
+        t.Fatalf("state = %s, want %s", got, BreakerHalfOpen)
+    }
+    if b.allow() {
+        t.Fatal("half-open breaker allowed a second call while the probe is running")
+    }
+    b.success()
+    if !b.allow() || b.currentState() != BreakerClosed {
+        t.Fatal("breaker did not close after a successful probe")
+    }
+}
@@ -0,0 +1,60 @@
+package catalog
+
+import (
+    "context"
+    "sync"
+    "time"
+)
+
+// CachedPrices keeps prices returned by Source for TTL and only asks it for
+// items that are missing or expired.
+type CachedPrices struct {
+    Source PriceLookup
+    TTL    time.Duration
+
+    mu      sync.Mutex
+    entries map[uint]cachedPrice
+}
+
+type cachedPrice struct {
+    price   float64
+    expires time.Time
+}
+
+func NewCachedPrices(source PriceLookup, ttl time.Duration) *CachedPrices {
+    return &CachedPrices{Source: source, TTL: ttl, entries: map[uint]cachedPrice{}}
+}
+
+func (c *CachedPrices) Prices(ctx context.Context, itemIDs []uint) (map[uint]float64, error) {
+    now := time.Now()
+    prices := make(map[uint]float64, len(itemIDs))
+    var missing []uint
+
+    c.mu.Lock()
+    for _, id := range itemIDs {
+        if e, ok := c.entries[id]; ok && now.Before(e.expires) {
+            prices[id] = e.price
+        } else {
+            missing = append(missing, id)
+        }
+    }
+    c.mu.Unlock()
+
+    if len(missing) == 0 {
+        return prices, nil
+    }
+
+    fetched, err := c.Source.Prices(ctx, missing)
+    if err != nil {
+        return nil, err
+    }
+
+    c.mu.Lock()
+    for id, price := range fetched {
+        c.entries[id] = cachedPrice{price: price, expires: now.Add(c.TTL)}
+        prices[id] = price
+    }
+    c.mu.Unlock()
+
+    return prices, nil
+}
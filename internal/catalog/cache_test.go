@@ -0,0 +1,64 @@
+package catalog
+
+import (
+    "context"
+    "net/http"
+    "sync/atomic"
+    "testing"
+    "time"
+)
+
+func TestCachedPricesTTL(t *testing.T) {
+    const ttl = 100 * time.Millisecond
+    srv, requests := catalogServer(t, always(http.StatusOK))
+    prices := NewCachedPrices(NewClient(Config{BaseURL: srv.URL}), ttl)
+    ctx := context.Background()
+
+    steps := []struct {
+        name         string
+        wait         time.Duration
+        ids          []uint
+        wantRequests int64
+    }{
+        {"first lookup asks the catalog", 0, []uint{1, 2}, 1},
+        {"cached items", 0, []uint{2, 1}, 1},
+        {"only a new item is missing", 0, []uint{1, 3}, 2},
+        {"expired items are asked again", ttl + 20*time.Millisecond, []uint{1}, 3},
+    }
+    for _, s := range steps {
+        time.Sleep(s.wait)
+        got, err := prices.Prices(ctx, s.ids)
+        if err != nil {
+            t.Fatalf("%s: Prices() error = %v", s.name, err)
+        }
+        for _, id := range s.ids {
+            if got[id] != 9.99 {
+                t.Errorf("%s: price of item %d = %v, want 9.99", s.name, id, got[id])
+            }
+        }
+        if n := atomic.LoadInt64(requests); n != s.wantRequests {
+            t.Errorf("%s: catalog got %d requests, want %d", s.name, n, s.wantRequests)
+        }
+    }
+}
+
+func TestCachedPricesDoesNotCacheErrors(t *testing.T) {
+    srv, requests := catalogServer(t, func(n int64) int {
+        if n == 1 {
+            return http.StatusInternalServerError
+        }
+        return http.StatusOK
+    })
+    prices := NewCachedPrices(NewClient(Config{BaseURL: srv.URL}), time.Minute)
+
+    if _, err := prices.Prices(context.Background(), []uint{1}); err == nil {
+        t.Fatal("Prices() succeeded on a failing catalog")
+    }
+    got, err := prices.Prices(context.Background(), []uint{1})
+    if err != nil || got[1] != 9.99 {
+        t.Fatalf("Prices() = %v, %v, want item 1 at 9.99", got, err)
+    }
+    if n := atomic.LoadInt64(requests); n != 2 {
+        t.Errorf("catalog got %d requests, want 2", n)
+    }
+}
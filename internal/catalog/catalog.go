@@ -0,0 +1,163 @@
+// Package catalog is the client side of the external catalog/inventory
+// service.
+package catalog
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "fmt"
+    "io"
+    "math/rand"
+    "net/http"
+    "net/url"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// PriceLookup returns current catalog prices by item ID. Items unknown to the
+// catalog are missing from the result.
+type PriceLookup interface {
+    Prices(ctx context.Context, itemIDs []uint) (map[uint]float64, error)
+}
+
+// Client talks to the catalog service over HTTP. Every call goes through the
+// circuit breaker, is retried with jittered exponential backoff on network
+// errors and 5xx answers, and is counted in Metrics. Config.BaseURL can point
+// at an httptest.Server in tests.
+type Client struct {
+    Config  Config
+    Metrics *Metrics
+
+    http    *http.Client
+    breaker *breaker
+}
+
+func NewClient(cfg Config) *Client {
+    cfg.defaults()
+    cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+    return &Client{
+        Config:  cfg,
+        Metrics: newMetrics(),
+        http:    &http.Client{Timeout: cfg.Timeout},
+        breaker: newBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown),
+    }
+}
+
+// BreakerState returns closed, open or half_open.
+func (c *Client) BreakerState() string {
+    return c.breaker.currentState()
+}
+
+// do sends a request for operation op. Requests that change stock must carry
+// an idempotency key, otherwise they are not retried: the catalog might have
+// applied a request whose answer got lost. The caller closes the response
+// body; a final 5xx answer is returned as an error.
+func (c *Client) do(ctx context.Context, op, method, path string, body []byte, idempotencyKey string) (*http.Response, error) {
+    c.Metrics.record(op, func(s *OperationStats) { s.Calls++ })
+    if !c.breaker.allow() {
+        c.Metrics.record(op, func(s *OperationStats) { s.Rejected++ })
+        return nil, ErrCircuitOpen
+    }
+
+    retries := c.Config.MaxRetries
+    if method != http.MethodGet && idempotencyKey == "" {
+        retries = 0
+    }
+
+    for attempt := 0; ; attempt++ {
+        resp, err := c.send(ctx, method, path, body, idempotencyKey)
+        if err == nil && resp.StatusCode < 500 {
+            c.breaker.success()
+            c.Metrics.record(op, func(s *OperationStats) { s.Successes++ })
+            return resp, nil
+        }
+        if err == nil {
+            resp.Body.Close()
+            err = fmt.Errorf("catalog %s: unexpected status %s", op, resp.Status)
+        }
+
+        if attempt >= retries || ctx.Err() != nil {
+            c.breaker.failure()
+            c.Metrics.record(op, func(s *OperationStats) { s.Failures++ })
+            return nil, err
+        }
+
+        c.Metrics.record(op, func(s *OperationStats) { s.Retries++ })
+        select {
+        case <-time.After(c.backoff(attempt)):
+        case <-ctx.Done():
+            c.breaker.failure()
+            c.Metrics.record(op, func(s *OperationStats) { s.Failures++ })
+            return nil, ctx.Err()
+        }
+    }
+}
+
+func (c *Client) send(ctx context.Context, method, path string, body []byte, idempotencyKey string) (*http.Response, error) {
+    var reader io.Reader
+    if body != nil {
+        reader = bytes.NewReader(body)
+    }
+    req, err := http.NewRequestWithContext(ctx, method, c.Config.BaseURL+path, reader)
+    if err != nil {
+        return nil, err
+    }
+    if body != nil {
+        req.Header.Set("Content-Type", "application/json")
+    }
+    if idempotencyKey != "" {
+        req.Header.Set("Idempotency-Key", idempotencyKey)
+    }
+    return c.http.Do(req)
+}
+
+// backoff returns RetryBackoff·2^attempt with "equal jitter": half of the
+// delay is fixed and the other half random, so concurrent clients spread out.
+func (c *Client) backoff(attempt int) time.Duration {
+    d := c.Config.RetryBackoff << attempt
+    half := int64(d / 2)
+    return time.Duration(half + rand.Int63n(half+1))
+}
+
+type priceResponse struct {
+    Items []struct {
+        ItemID uint    `json:"item_id"`
+        Price  float64 `json:"price"`
+    } `json:"items"`
+}
+
+// Prices calls GET {BaseURL}/items/prices?ids=1,2,3, which answers
+// {"items": [{"item_id": 1, "price": 9.99}, ...]}. All attempts together are
+// limited to Config.PricesBudget, since a sale waits for the answer.
+func (c *Client) Prices(ctx context.Context, itemIDs []uint) (map[uint]float64, error) {
+    ctx, cancel := context.WithTimeout(ctx, c.Config.PricesBudget)
+    defer cancel()
+
+    ids := make([]string, len(itemIDs))
+    for i, id := range itemIDs {
+        ids[i] = strconv.FormatUint(uint64(id), 10)
+    }
+
+    resp, err := c.do(ctx, "prices", http.MethodGet,
+        "/items/prices?ids="+url.QueryEscape(strings.Join(ids, ",")), nil, "")
+    if err != nil {
+        return nil, err
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode != http.StatusOK {
+        return nil, fmt.Errorf("catalog prices: unexpected status %s", resp.Status)
+    }
+
+    var body priceResponse
+    if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+        return nil, fmt.Errorf("catalog prices: %w", err)
+    }
+
+    prices := make(map[uint]float64, len(body.Items))
+    for _, it := range body.Items {
+        prices[it.ItemID] = it.Price
+    }
+    return prices, nil
+}
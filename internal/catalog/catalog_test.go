@@ -0,0 +1,139 @@
+package catalog
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "sync/atomic"
+    "testing"
+    "time"
+)
+
+// catalogServer answers GET /items/prices with price 9.99 for every requested
+// item. The n-th request gets status(n) (counted from 1), and a non-200 status
+// has no body. It returns the number of requests served so far.
+func catalogServer(t *testing.T, status func(n int64) int) (*httptest.Server, *int64) {
+    t.Helper()
+    var requests int64
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        n := atomic.AddInt64(&requests, 1)
+        if r.URL.Path != "/items/prices" {
+            http.NotFound(w, r)
+            return
+        }
+        if code := status(n); code != http.StatusOK {
+            w.WriteHeader(code)
+            return
+        }
+        var items []string
+        for _, id := range strings.Split(r.URL.Query().Get("ids"), ",") {
+            items = append(items, fmt.Sprintf(`{"item_id": %s, "price": 9.99}`, id))
+        }
+        fmt.Fprintf(w, `{"items": [%s]}`, strings.Join(items, ","))
+    }))
+    t.Cleanup(srv.Close)
+    return srv, &requests
+}
+
+func always(code int) func(int64) int {
+    return func(int64) int { return code }
+}
+
+func TestPricesRetries(t *testing.T) {
+    tests := []struct {
+        name         string
+        status       func(n int64) int
+        maxRetries   int
+        wantErr      bool
+        wantRequests int64
+        wantStats    OperationStats
+    }{
+        {
+            name: "succeeds after two server errors",
+            status: func(n int64) int {
+                if n <= 2 {
+                    return http.StatusBadGateway
+                }
+                return http.StatusOK
+            },
+            maxRetries:   2,
+            wantRequests: 3,
+            wantStats:    OperationStats{Calls: 1, Successes: 1, Retries: 2},
+        },
+        {
+            name:         "gives up after the last retry",
+            status:       always(http.StatusServiceUnavailable),
+            maxRetries:   2,
+            wantErr:      true,
+            wantRequests: 3,
+            wantStats:    OperationStats{Calls: 1, Failures: 1, Retries: 2},
+        },
+        {
+            name:         "client errors are not retried",
+            status:       always(http.StatusBadRequest),
+            maxRetries:   2,
+            wantErr:      true,
+            wantRequests: 1,
+            wantStats:    OperationStats{Calls: 1, Successes: 1},
+        },
+        {
+            name:         "no retries configured",
+            status:       always(http.StatusInternalServerError),
+            wantErr:      true,
+            wantRequests: 1,
+            wantStats:    OperationStats{Calls: 1, Failures: 1},
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            srv, requests := catalogServer(t, tt.status)
+            c := NewClient(Config{BaseURL: srv.URL, MaxRetries: tt.maxRetries, RetryBackoff: time.Millisecond})
+
+            prices, err := c.Prices(context.Background(), []uint{1, 2})
+            if (err != nil) != tt.wantErr {
+                t.Fatalf("Prices() error = %v, want error %v", err, tt.wantErr)
+            }
+            if !tt.wantErr && (prices[1] != 9.99 || prices[2] != 9.99) {
+                t.Errorf("Prices() = %v, want 9.99 for items 1 and 2", prices)
+            }
+            if got := atomic.LoadInt64(requests); got != tt.wantRequests {
+                t.Errorf("catalog got %d requests, want %d", got, tt.wantRequests)
+            }
+            if got := c.Metrics.Snapshot()["prices"]; got != tt.wantStats {
+                t.Errorf("stats = %+v, want %+v", got, tt.wantStats)
+            }
+        })
+    }
+}
+
+func TestPricesBudget(t *testing.T) {
+    // Каждая попытка укладывается в Timeout, но все вместе — нет
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        select {
+        case <-time.After(150 * time.Millisecond):
+            w.WriteHeader(http.StatusServiceUnavailable)
+        case <-r.Context().Done():
+        }
+    }))
+    defer srv.Close()
+    c := NewClient(Config{
+        BaseURL:      srv.URL,
+        Timeout:      time.Second,
+        MaxRetries:   10,
+        RetryBackoff: time.Millisecond,
+        PricesBudget: 400 * time.Millisecond,
+    })
+
+    start := time.Now()
+    _, err := c.Prices(context.Background(), []uint{1})
+    elapsed := time.Since(start)
+    if !errors.Is(err, context.DeadlineExceeded) {
+        t.Errorf("Prices() error = %v, want %v", err, context.DeadlineExceeded)
+    }
+    if elapsed > 700*time.Millisecond {
+        t.Errorf("Prices() took %s, want about the 400ms budget", elapsed)
+    }
+}
@@ -0,0 +1,101 @@
+package catalog
+
+import (
+    "log"
+    "os"
+    "strconv"
+    "time"
+)
+
+// Config configures the catalog client. Zero values fall back to the
+// defaults below.
+type Config struct {
+    BaseURL string
+    // Timeout — ограничение на одну попытку запроса
+    Timeout time.Duration
+    // MaxRetries — сколько раз повторять запрос после сетевой ошибки или ответа 5xx
+    MaxRetries int
+    // RetryBackoff — базовая пауза перед повтором, удваивается с каждой попыткой
+    RetryBackoff time.Duration
+    // BreakerThreshold — после скольких неудач подряд запросы перестают отправляться
+    BreakerThreshold int
+    // BreakerCooldown — через сколько после размыкания пробуется один запрос
+    BreakerCooldown time.Duration
+    // PricesBudget — общее время на запрос цен со всеми повторами; продажа ждёт этот запрос
+    PricesBudget time.Duration
+}
+
+const (
+    DefaultBaseURL          = "http://catalog-service"
+    DefaultTimeout          = 2 * time.Second
+    DefaultMaxRetries       = 2
+    DefaultRetryBackoff     = 100 * time.Millisecond
+    DefaultBreakerThreshold = 5
+    DefaultBreakerCooldown  = 30 * time.Second
+    DefaultPricesBudget     = 3 * time.Second
+)
+
+func (c *Config) defaults() {
+    if c.BaseURL == "" {
+        c.BaseURL = DefaultBaseURL
+    }
+    if c.Timeout == 0 {
+        c.Timeout = DefaultTimeout
+    }
+    if c.RetryBackoff == 0 {
+        c.RetryBackoff = DefaultRetryBackoff
+    }
+    if c.BreakerThreshold == 0 {
+        c.BreakerThreshold = DefaultBreakerThreshold
+    }
+    if c.BreakerCooldown == 0 {
+        c.BreakerCooldown = DefaultBreakerCooldown
+    }
+    if c.PricesBudget == 0 {
+        c.PricesBudget = DefaultPricesBudget
+    }
+}
+
+// ConfigFromEnv reads CATALOG_URL, CATALOG_TIMEOUT, CATALOG_MAX_RETRIES,
+// CATALOG_RETRY_BACKOFF, CATALOG_BREAKER_THRESHOLD, CATALOG_BREAKER_COOLDOWN
+// and CATALOG_PRICES_BUDGET. Invalid values are logged and replaced by the
+// defaults.
+func ConfigFromEnv() Config {
+    cfg := Config{
+        BaseURL:          os.Getenv("CATALOG_URL"),
+        Timeout:          envDuration("CATALOG_TIMEOUT", DefaultTimeout),
+        MaxRetries:       envInt("CATALOG_MAX_RETRIES", DefaultMaxRetries),
+        RetryBackoff:     envDuration("CATALOG_RETRY_BACKOFF", DefaultRetryBackoff),
+        BreakerThreshold: envInt("CATALOG_BREAKER_THRESHOLD", DefaultBreakerThreshold),
+        BreakerCooldown:  envDuration("CATALOG_BREAKER_COOLDOWN", DefaultBreakerCooldown),
+        PricesBudget:     envDuration("CATALOG_PRICES_BUDGET", DefaultPricesBudget),
+    }
+    cfg.defaults()
+    return cfg
+}
+
+func envDuration(name string, def time.Duration) time.Duration {
+    v := os.Getenv(name)
+    if v == "" {
+        return def
+    }
+    d, err := time.ParseDuration(v)
+    if err != nil || d <= 0 {
+        log.Printf("Invalid %s %q, using %s", name, v, def)
+        return def
+    }
+    return d
+}
+
+func envInt(name string, def int) int {
+    v := os.Getenv(name)
+    if v == "" {
+        return def
+    }
+    n, err := strconv.Atoi(v)
+    if err != nil || n < 0 {
+        log.Printf("Invalid %s %q, using %d", name, v, def)
+        return def
+    }
+    return n
+}
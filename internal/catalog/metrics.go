@@ -0,0 +1,47 @@
+package catalog
+
+import "sync"
+
+// OperationStats counts calls of one client operation. A call is a success
+// when the catalog answered with a non-5xx status, possibly after retries,
+// and a failure when it did not; Rejected calls were stopped by the open
+// circuit breaker.
+type OperationStats struct {
+    Calls     int64 `json:"calls"`
+    Successes int64 `json:"successes"`
+    Failures  int64 `json:"failures"`
+    Retries   int64 `json:"retries"`
+    Rejected  int64 `json:"rejected"`
+}
+
+// Metrics collects OperationStats per operation name.
+type Metrics struct {
+    mu  sync.Mutex
+    ops map[string]*OperationStats
+}
+
+func newMetrics() *Metrics {
+    return &Metrics{ops: map[string]*OperationStats{}}
+}
+
+func (m *Metrics) record(op string, fn func(s *OperationStats)) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    s, ok := m.ops[op]
+    if !ok {
+        s = &OperationStats{}
+        m.ops[op] = s
+    }
+    fn(s)
+}
+
+// Snapshot returns a copy of the current counters.
+func (m *Metrics) Snapshot() map[string]OperationStats {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    out := make(map[string]OperationStats, len(m.ops))
+    for op, s := range m.ops {
+        out[op] = *s
+    }
+    return out
+}
@@ -0,0 +1,135 @@
+package catalog
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "net/http"
+    "net/url"
+)
+
+// ErrOutOfStock is returned by Reserve when the catalog cannot hold the
+// requested quantities.
+var ErrOutOfStock = errors.New("out of stock")
+
+// StockItem is one line of a stock request. Quantity is always positive; the
+// operation decides the direction.
+type StockItem struct {
+    ItemID   uint `json:"item_id"`
+    Quantity int  `json:"quantity"`
+}
+
+// StockReserver holds stock for a sale until it is committed or released.
+type StockReserver interface {
+    Reserve(ctx context.Context, key string, items []StockItem) (reservationID string, err error)
+    Commit(ctx context.Context, reservationID string) error
+    Release(ctx context.Context, reservationID string) error
+}
+
+// StockUpdater changes stock after the fact.
+type StockUpdater interface {
+    Deduct(ctx context.Context, key string, items []StockItem) error
+    Restock(ctx context.Context, key string, items []StockItem) error
+}
+
+type stockRequest struct {
+    Items []StockItem `json:"items"`
+}
+
+type reserveResponse struct {
+    ReservationID string `json:"reservation_id"`
+}
+
+// Reserve calls POST {BaseURL}/inventory/reservations with all items in one
+// request. The catalog answers 409 Conflict when any item is short; the
+// error then wraps ErrOutOfStock and the catalog's message.
+func (c *Client) Reserve(ctx context.Context, key string, items []StockItem) (string, error) {
+    body, err := json.Marshal(stockRequest{Items: items})
+    if err != nil {
+        return "", err
+    }
+
+    resp, err := c.do(ctx, "reserve", http.MethodPost, "/inventory/reservations", body, key)
+    if err != nil {
+        return "", err
+    }
+    defer resp.Body.Close()
+
+    switch resp.StatusCode {
+    case http.StatusOK, http.StatusCreated:
+    case http.StatusConflict:
+        var out struct {
+            Error string `json:"error"`
+        }
+        if json.NewDecoder(resp.Body).Decode(&out) == nil && out.Error != "" {
+            return "", fmt.Errorf("%w: %s", ErrOutOfStock, out.Error)
+        }
+        return "", ErrOutOfStock
+    default:
+        return "", fmt.Errorf("catalog reserve: unexpected status %s", resp.Status)
+    }
+
+    var out reserveResponse
+    if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+        return "", fmt.Errorf("catalog reserve: %w", err)
+    }
+    if out.ReservationID == "" {
+        return "", errors.New("catalog reserve: empty reservation_id")
+    }
+    return out.ReservationID, nil
+}
+
+// Commit turns a reservation into a stock deduction.
+func (c *Client) Commit(ctx context.Context, reservationID string) error {
+    return c.reservationAction(ctx, reservationID, "commit")
+}
+
+// Release returns reserved stock. Releasing an unknown or expired reservation
+// is not an error.
+func (c *Client) Release(ctx context.Context, reservationID string) error {
+    return c.reservationAction(ctx, reservationID, "release")
+}
+
+func (c *Client) reservationAction(ctx context.Context, reservationID, action string) error {
+    // Повтор commit/release безопасен, ключом служит сама резервация
+    resp, err := c.do(ctx, action, http.MethodPost,
+        "/inventory/reservations/"+url.PathEscape(reservationID)+"/"+action, nil, reservationID+":"+action)
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode >= 300 && !(action == "release" && resp.StatusCode == http.StatusNotFound) {
+        return fmt.Errorf("catalog %s: unexpected status %s", action, resp.Status)
+    }
+    return nil
+}
+
+// Deduct calls POST {BaseURL}/inventory/deduct with all items in one request.
+func (c *Client) Deduct(ctx context.Context, key string, items []StockItem) error {
+    return c.updateStock(ctx, "deduct", key, items)
+}
+
+// Restock calls POST {BaseURL}/inventory/restock with all items in one request.
+func (c *Client) Restock(ctx context.Context, key string, items []StockItem) error {
+    return c.updateStock(ctx, "restock", key, items)
+}
+
+func (c *Client) updateStock(ctx context.Context, op, key string, items []StockItem) error {
+    body, err := json.Marshal(stockRequest{Items: items})
+    if err != nil {
+        return err
+    }
+
+    resp, err := c.do(ctx, op, http.MethodPost, "/inventory/"+op, body, key)
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode >= 300 {
+        return fmt.Errorf("catalog %s: unexpected status %s", op, resp.Status)
+    }
+    return nil
+}
@@ -0,0 +1,530 @@
+package delivery
+
+import (
+    "errors"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/dibsnvas/golang-2025/internal/models"
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+)
+
+var (
+    errAdjustmentNotFound     = errors.New("adjustment not found")
+    errAdjustmentsUnavailable = errors.New("adjustments must be approved and not yet paid or in a payroll run")
+)
+
+// Допустимые коды причин для каждого типа корректировки
+var adjustmentReasonCodes = map[string][]string{
+    models.AdjustmentBonus:         {"performance", "holiday", "sales_target", "referral", "retention", "other"},
+    models.AdjustmentCorrection:    {"underpayment", "overpayment", "hours", "rate", "other"},
+    models.AdjustmentReimbursement: {"travel", "meals", "equipment", "training", "other"},
+}
+
+type AdjustmentHandler struct {
+    DB *gorm.DB
+}
+
+func NewAdjustmentHandler(db *gorm.DB) *AdjustmentHandler {
+    return &AdjustmentHandler{DB: db}
+}
+
+type adjustmentSums struct {
+    Bonuses        float64
+    Corrections    float64
+    Reimbursements float64
+}
+
+func sumAdjustments(adjustments []models.SalaryAdjustment) adjustmentSums {
+    var s adjustmentSums
+    for _, a := range adjustments {
+        switch a.Type {
+        case models.AdjustmentBonus:
+            s.Bonuses += a.Amount
+        case models.AdjustmentCorrection:
+            s.Corrections += a.Amount
+        case models.AdjustmentReimbursement:
+            s.Reimbursements += a.Amount
+        }
+    }
+    s.Bonuses = roundMoney(s.Bonuses)
+    s.Corrections = roundMoney(s.Corrections)
+    s.Reimbursements = roundMoney(s.Reimbursements)
+    return s
+}
+
+// Gross is the part of the adjustments that is pay.
+func (s adjustmentSums) Gross() float64 {
+    return roundMoney(s.Bonuses + s.Corrections)
+}
+
+// adjustmentDescription is how an adjustment is shown on a payslip, e.g.
+// "Bonus (sales target): Q3 plan".
+func adjustmentDescription(a models.SalaryAdjustment) string {
+    d := strings.ToUpper(a.Type[:1]) + a.Type[1:] + " (" + strings.ReplaceAll(a.ReasonCode, "_", " ") + ")"
+    if a.Description != "" {
+        d += ": " + a.Description
+    }
+    return d
+}
+
+// payableAdjustments returns the approved adjustments of the employees that
+// are effective by asOf and not yet in a payroll run or paid.
+func payableAdjustments(db *gorm.DB, employeeIDs []uint, asOf time.Time) ([]models.SalaryAdjustment, error) {
+    var adjustments []models.SalaryAdjustment
+    err := db.Where("employee_id IN ? AND status = ? AND effective_date <= ? AND payroll_line_id IS NULL AND salary_payment_id IS NULL",
+        employeeIDs, models.AdjustmentApproved, asOf).
+        Order("id").Find(&adjustments).Error
+    return adjustments, err
+}
+
+// loadPaymentAdjustments loads the adjustments to be paid with a salary
+// payment of an employee. All of them must be approved and not yet taken.
+func loadPaymentAdjustments(db *gorm.DB, employeeID uint, ids []uint) ([]models.SalaryAdjustment, error) {
+    if len(ids) == 0 {
+        return nil, nil
+    }
+    unique := map[uint]bool{}
+    for _, id := range ids {
+        unique[id] = true
+    }
+
+    var adjustments []models.SalaryAdjustment
+    if err := db.Where("id IN ?", ids).Order("id").Find(&adjustments).Error; err != nil {
+        return nil, err
+    }
+    if len(adjustments) != len(unique) {
+        return nil, errAdjustmentNotFound
+    }
+    for _, a := range adjustments {
+        if a.EmployeeID != employeeID || a.Status != models.AdjustmentApproved || a.PayrollLineID != nil || a.SalaryPaymentID != nil {
+            return nil, errAdjustmentsUnavailable
+        }
+    }
+    return adjustments, nil
+}
+
+// attachAdjustments links approved, unattached adjustments to a payroll line
+// or payment. If another payment took one of them in the meantime it fails
+// with errAdjustmentsUnavailable.
+func attachAdjustments(db *gorm.DB, adjustments []models.SalaryAdjustment, updates map[string]interface{}) error {
+    if len(adjustments) == 0 {
+        return nil
+    }
+    ids := make([]uint, len(adjustments))
+    for i, a := range adjustments {
+        ids[i] = a.ID
+    }
+    res := db.Model(&models.SalaryAdjustment{}).
+        Where("id IN ? AND status = ? AND payroll_line_id IS NULL AND salary_payment_id IS NULL", ids, models.AdjustmentApproved).
+        Updates(updates)
+    if res.Error != nil {
+        return res.Error
+    }
+    if res.RowsAffected != int64(len(ids)) {
+        return errAdjustmentsUnavailable
+    }
+    return nil
+}
+
+// detachAdjustments returns the adjustments of removed payroll lines to the
+// pool for the next payment.
+func detachAdjustments(db *gorm.DB, lines *gorm.DB) error {
+    return db.Model(&models.SalaryAdjustment{}).Where("payroll_line_id IN (?)", lines).
+        Update("payroll_line_id", nil).Error
+}
+
+type createAdjustmentRequest struct {
+    EmployeeID    uint    `json:"employee_id"`
+    Type          string  `json:"type"` // bonus, correction или reimbursement
+    ReasonCode    string  `json:"reason_code"`
+    Description   string  `json:"description"`
+    Amount        float64 `json:"amount"`         // correction может быть отрицательной
+    EffectiveDate string  `json:"effective_date"` // YYYY-MM-DD; можно не указывать и взять сегодня
+}
+
+type reviewAdjustmentRequest struct {
+    ManagerID    uint   `json:"manager_id"`
+    ApprovalCode string `json:"approval_code"`
+}
+
+type payAdjustmentRequest struct {
+    PaidAt string `json:"paid_at"` // YYYY-MM-DD; можно не указывать и взять time.Now()
+}
+
+// CreateAdjustment requests a bonus, correction or reimbursement
+// @Summary Create a salary adjustment
+// @Description Request a one-off bonus, correction or reimbursement for an employee with a reason code: bonus (performance, holiday, sales_target, referral, retention, other), correction (underpayment, overpayment, hours, rate, other; the amount may be negative) or reimbursement (travel, meals, equipment, training, other). It is pending until a manager approves it
+// @Tags Salary
+// @Accept json
+// @Produce json
+// @Param createAdjustmentRequest body createAdjustmentRequest true "Adjustment data"
+// @Success 201 {object} models.SalaryAdjustment
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /salary/adjustments [post]
+func (h *AdjustmentHandler) CreateAdjustment(c *gin.Context) {
+    var req createAdjustmentRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    codes, ok := adjustmentReasonCodes[req.Type]
+    if !ok {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "type must be bonus, correction or reimbursement"})
+        return
+    }
+    known := false
+    for _, code := range codes {
+        known = known || code == req.ReasonCode
+    }
+    if !known {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "reason_code for " + req.Type + " must be one of " + strings.Join(codes, ", ")})
+        return
+    }
+    amount := roundMoney(req.Amount)
+    if amount == 0 || (amount < 0 && req.Type != models.AdjustmentCorrection) {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "amount must be positive (only corrections may be negative)"})
+        return
+    }
+    if req.ReasonCode == "other" && req.Description == "" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "description is required for reason_code other"})
+        return
+    }
+
+    effective := time.Now().UTC().Truncate(24 * time.Hour)
+    if req.EffectiveDate != "" {
+        var err error
+        if effective, err = time.Parse("2006-01-02", req.EffectiveDate); err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "invalid effective_date"})
+            return
+        }
+    }
+
+    var employee models.Employee
+    if err := h.DB.Where("id = ?", req.EmployeeID).Limit(1).Find(&employee).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    if employee.ID == 0 {
+        c.JSON(http.StatusNotFound, gin.H{"error": "employee not found"})
+        return
+    }
+
+    adjustment := models.SalaryAdjustment{
+        EmployeeID:    employee.ID,
+        Type:          req.Type,
+        ReasonCode:    req.ReasonCode,
+        Description:   req.Description,
+        Amount:        amount,
+        EffectiveDate: effective,
+        Status:        models.AdjustmentPending,
+    }
+    if err := h.DB.Create(&adjustment).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusCreated, adjustment)
+}
+
+// loadAdjustment writes 400/404/500 itself and returns nil on failure.
+func (h *AdjustmentHandler) loadAdjustment(c *gin.Context) *models.SalaryAdjustment {
+    id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+        return nil
+    }
+
+    var adjustment models.SalaryAdjustment
+    if err := h.DB.First(&adjustment, id).Error; err != nil {
+        if err == gorm.ErrRecordNotFound {
+            c.JSON(http.StatusNotFound, gin.H{"error": "adjustment not found"})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
+        return nil
+    }
+    return &adjustment
+}
+
+// GetAdjustment returns a salary adjustment
+// @Summary Get salary adjustment
+// @Description Retrieve a salary adjustment with its status and the payroll line or payment it is paid with
+// @Tags Salary
+// @Accept json
+// @Produce json
+// @Param id path int true "Adjustment ID"
+// @Success 200 {object} models.SalaryAdjustment
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /salary/adjustments/{id} [get]
+func (h *AdjustmentHandler) GetAdjustment(c *gin.Context) {
+    adjustment := h.loadAdjustment(c)
+    if adjustment == nil {
+        return
+    }
+    c.JSON(http.StatusOK, adjustment)
+}
+
+// reviewAdjustment moves a pending adjustment to approved or rejected.
+func (h *AdjustmentHandler) reviewAdjustment(c *gin.Context, status string) {
+    var req reviewAdjustmentRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    adjustment := h.loadAdjustment(c)
+    if adjustment == nil {
+        return
+    }
+    if adjustment.Status != models.AdjustmentPending {
+        c.JSON(http.StatusConflict, gin.H{"error": "adjustment is not pending"})
+        return
+    }
+
+    ok, err := checkManagerApproval(h.DB, req.ManagerID, req.ApprovalCode)
+    if err != nil {
+        c.JSON(approvalErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+    if !ok {
+        c.JSON(http.StatusForbidden, gin.H{"error": "invalid manager approval"})
+        return
+    }
+
+    now := time.Now()
+    res := h.DB.Model(&models.SalaryAdjustment{}).
+        Where("id = ? AND status = ?", adjustment.ID, models.AdjustmentPending).
+        Updates(map[string]interface{}{
+            "status":      status,
+            "approved_by": req.ManagerID,
+            "approved_at": now,
+        })
+    if res.Error != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": res.Error.Error()})
+        return
+    }
+    if res.RowsAffected == 0 {
+        c.JSON(http.StatusConflict, gin.H{"error": "adjustment is not pending"})
+        return
+    }
+
+    adjustment.Status = status
+    adjustment.ApprovedBy = &req.ManagerID
+    adjustment.ApprovedAt = &now
+    c.JSON(http.StatusOK, adjustment)
+}
+
+// ApproveAdjustment approves a pending salary adjustment
+// @Summary Approve a salary adjustment
+// @Description Approve a pending adjustment with a manager approval code. It is then paid with the employee's next payroll run (if effective by the end of its period), with a salary payment listing it in adjustment_ids, or on its own
+// @Tags Salary
+// @Accept json
+// @Produce json
+// @Param id path int true "Adjustment ID"
+// @Param reviewAdjustmentRequest body reviewAdjustmentRequest true "Manager approval"
+// @Success 200 {object} models.SalaryAdjustment
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 429 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /salary/adjustments/{id}/approve [post]
+func (h *AdjustmentHandler) ApproveAdjustment(c *gin.Context) {
+    h.reviewAdjustment(c, models.AdjustmentApproved)
+}
+
+// RejectAdjustment rejects a pending salary adjustment
+// @Summary Reject a salary adjustment
+// @Description Reject a pending adjustment with a manager approval code; it will never be paid
+// @Tags Salary
+// @Accept json
+// @Produce json
+// @Param id path int true "Adjustment ID"
+// @Param reviewAdjustmentRequest body reviewAdjustmentRequest true "Manager approval"
+// @Success 200 {object} models.SalaryAdjustment
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 429 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /salary/adjustments/{id}/reject [post]
+func (h *AdjustmentHandler) RejectAdjustment(c *gin.Context) {
+    h.reviewAdjustment(c, models.AdjustmentRejected)
+}
+
+// PayAdjustment pays an approved adjustment on its own
+// @Summary Pay a salary adjustment
+// @Description Pay an approved adjustment outside payroll as a supplemental salary payment for its effective date. Bonuses and corrections are subject to the deduction rules; reimbursements are paid in full. Negative corrections cannot be paid on their own
+// @Tags Salary
+// @Accept json
+// @Produce json
+// @Param id path int true "Adjustment ID"
+// @Param payAdjustmentRequest body payAdjustmentRequest false "Payment date"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /salary/adjustments/{id}/pay [post]
+func (h *AdjustmentHandler) PayAdjustment(c *gin.Context) {
+    var req payAdjustmentRequest
+    if c.Request.ContentLength != 0 {
+        if err := c.ShouldBindJSON(&req); err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+            return
+        }
+    }
+    paidAt := time.Now()
+    if req.PaidAt != "" {
+        var err error
+        if paidAt, err = time.Parse("2006-01-02", req.PaidAt); err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "invalid paid_at"})
+            return
+        }
+    }
+
+    adjustment := h.loadAdjustment(c)
+    if adjustment == nil {
+        return
+    }
+    if adjustment.Status != models.AdjustmentApproved || adjustment.PayrollLineID != nil || adjustment.SalaryPaymentID != nil {
+        c.JSON(http.StatusConflict, gin.H{"error": errAdjustmentsUnavailable.Error()})
+        return
+    }
+    if adjustment.Amount <= 0 {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "negative corrections are recovered through payroll or a salary payment"})
+        return
+    }
+
+    sums := sumAdjustments([]models.SalaryAdjustment{*adjustment})
+    w, err := computeWithholding(h.DB, adjustment.EmployeeID, sums.Gross(), false, adjustment.EffectiveDate, adjustment.EffectiveDate)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    salary := models.SalaryPayment{
+        EmployeeID:            adjustment.EmployeeID,
+        PayPeriodStart:        adjustment.EffectiveDate,
+        PayPeriodEnd:          adjustment.EffectiveDate,
+        Amount:                sums.Gross(),
+        Deductions:            w.Deductions,
+        Reimbursements:        sums.Reimbursements,
+        NetAmount:             roundMoney(w.Net + sums.Reimbursements),
+        EmployerContributions: w.EmployerContributions,
+        PaidAt:                paidAt,
+        PaymentType:           models.SalaryPaymentSupplemental,
+        DeductionLines:        w.Lines,
+    }
+    if err := recordSalaryPayment(h.DB, &salary, []models.SalaryAdjustment{*adjustment}); err != nil {
+        c.JSON(salaryPaymentErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusCreated, gin.H{
+        "adjustment_id": adjustment.ID,
+        "salary_id":     salary.ID,
+        "gross":         salary.Amount,
+        "deductions":    salary.Deductions,
+        "net_amount":    salary.NetAmount,
+    })
+}
+
+// ListAdjustments returns salary adjustments with totals by type
+// @Summary List salary adjustments
+// @Description List adjustments, newest effective date first, by employee, type, status and effective date range, with the count and amount per type for all matching adjustments
+// @Tags Salary
+// @Accept json
+// @Produce json
+// @Param employee_id query int false "Employee ID"
+// @Param type query string false "bonus, correction or reimbursement"
+// @Param status query string false "pending, approved, rejected or paid"
+// @Param from query string false "Effective from in YYYY-MM-DD format"
+// @Param to query string false "Effective to (inclusive) in YYYY-MM-DD format"
+// @Param limit query int false "Page size (default 50, max 500)"
+// @Param offset query int false "Entries to skip"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /salary/adjustments [get]
+func (h *AdjustmentHandler) ListAdjustments(c *gin.Context) {
+    limit, offset, ok := parsePagination(c)
+    if !ok {
+        return
+    }
+
+    query := h.DB.Model(&models.SalaryAdjustment{})
+    if v := c.Query("employee_id"); v != "" {
+        employeeID, err := strconv.ParseUint(v, 10, 64)
+        if err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "invalid employee_id"})
+            return
+        }
+        query = query.Where("employee_id = ?", employeeID)
+    }
+    if v := c.Query("type"); v != "" {
+        query = query.Where("type = ?", v)
+    }
+    if v := c.Query("status"); v != "" {
+        query = query.Where("status = ?", v)
+    }
+    if v := c.Query("from"); v != "" {
+        from, err := time.Parse("2006-01-02", v)
+        if err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from, use YYYY-MM-DD"})
+            return
+        }
+        query = query.Where("effective_date >= ?", from)
+    }
+    if v := c.Query("to"); v != "" {
+        to, err := time.Parse("2006-01-02", v)
+        if err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to, use YYYY-MM-DD"})
+            return
+        }
+        query = query.Where("effective_date <= ?", to)
+    }
+
+    var byType []struct {
+        Type   string  `json:"type"`
+        Count  int64   `json:"count"`
+        Amount float64 `json:"amount"`
+    }
+    if err := query.Session(&gorm.Session{}).
+        Select("type, COUNT(*) AS count, COALESCE(SUM(amount), 0) AS amount").
+        Group("type").Order("type").Scan(&byType).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    var total int64
+    for i := range byType {
+        byType[i].Amount = roundMoney(byType[i].Amount)
+        total += byType[i].Count
+    }
+
+    var adjustments []models.SalaryAdjustment
+    if err := query.Order("effective_date DESC, id DESC").Limit(limit).Offset(offset).
+        Find(&adjustments).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "adjustments": adjustments,
+        "by_type":     byType,
+        "total":       total,
+        "limit":       limit,
+        "offset":      offset,
+    })
+}
@@ -0,0 +1,309 @@
+package delivery
+
+import (
+    "fmt"
+    "math"
+    "net/http"
+    "strconv"
+    "time"
+
+    "github.com/dibsnvas/golang-2025/internal/models"
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+)
+
+// Не больше пяти лет еженедельных удержаний
+const maxAdvanceInstallments = 260
+
+type AdvanceHandler struct {
+    DB *gorm.DB
+}
+
+func NewAdvanceHandler(db *gorm.DB) *AdvanceHandler {
+    return &AdvanceHandler{DB: db}
+}
+
+// dueAdvances returns the open advances of an employee that are recovered
+// from pay for a period ending at periodEnd, oldest first.
+func dueAdvances(db *gorm.DB, employeeID uint, periodEnd time.Time) ([]models.SalaryAdvance, error) {
+    var advances []models.SalaryAdvance
+    err := db.Where("employee_id = ? AND status = ? AND balance > 0 AND recover_from <= ?",
+        employeeID, models.AdvanceOpen, periodEnd).
+        Order("issued_at, id").Find(&advances).Error
+    return advances, err
+}
+
+func advanceDescription(a *models.SalaryAdvance) string {
+    if a.Kind == models.AdvanceKindLoan {
+        return fmt.Sprintf("Loan #%d repayment", a.ID)
+    }
+    return fmt.Sprintf("Advance #%d recovery", a.ID)
+}
+
+type advanceInstallment struct {
+    Number       int     `json:"number"`
+    Amount       float64 `json:"amount"`
+    BalanceAfter float64 `json:"balance_after"`
+}
+
+// advanceSchedule projects the remaining installments of an advance, one per
+// future regular salary payment, assuming each pays the full installment.
+func advanceSchedule(a models.SalaryAdvance) []advanceInstallment {
+    var schedule []advanceInstallment
+    balance := a.Balance
+    for n := 1; balance > 0 && a.Installment > 0 && n <= maxAdvanceInstallments; n++ {
+        amount := math.Min(a.Installment, balance)
+        balance = roundMoney(balance - amount)
+        schedule = append(schedule, advanceInstallment{Number: n, Amount: roundMoney(amount), BalanceAfter: balance})
+    }
+    return schedule
+}
+
+type createAdvanceRequest struct {
+    EmployeeID   uint    `json:"employee_id"`
+    Kind         string  `json:"kind"`   // advance (по умолчанию) или loan
+    Amount       float64 `json:"amount"` // выдано сотруднику
+    Installment  float64 `json:"installment"`
+    Installments int     `json:"installments"` // вместо installment: число равных долей
+    IssuedAt     string  `json:"issued_at"`    // YYYY-MM-DD; можно не указывать и взять сегодня
+    RecoverFrom  string  `json:"recover_from"` // YYYY-MM-DD; можно не указывать и взять issued_at
+    Note         string  `json:"note"`
+}
+
+// CreateAdvance records an advance or loan paid to an employee
+// @Summary Create a salary advance or loan
+// @Description Record money paid to an employee ahead of salary. Either installment (amount per salary) or installments (number of equal parts) sets the repayment schedule; an advance without either is recovered in one payment. From the first pay period ending on or after recover_from, every regular salary payment and payroll line deducts the installment (after income tax, never more than the balance or the pay left) until the advance is repaid
+// @Tags Advances
+// @Accept json
+// @Produce json
+// @Param createAdvanceRequest body createAdvanceRequest true "Advance data"
+// @Success 201 {object} models.SalaryAdvance
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /advances [post]
+func (h *AdvanceHandler) CreateAdvance(c *gin.Context) {
+    var req createAdvanceRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    if req.Kind == "" {
+        req.Kind = models.AdvanceKindAdvance
+    }
+    if req.Kind != models.AdvanceKindAdvance && req.Kind != models.AdvanceKindLoan {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "kind must be advance or loan"})
+        return
+    }
+    amount := roundMoney(req.Amount)
+    if amount <= 0 {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "amount must be positive"})
+        return
+    }
+    if req.Installment < 0 || req.Installments < 0 || (req.Installment > 0 && req.Installments > 0) {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "set either a positive installment or a positive number of installments"})
+        return
+    }
+
+    installment := roundMoney(req.Installment)
+    switch {
+    case req.Installments > 0:
+        // Округляем вверх, чтобы последняя доля не оказалась лишней копейкой
+        installment = math.Ceil(toCents(amount/float64(req.Installments))) / 100
+    case installment == 0 && req.Kind == models.AdvanceKindAdvance:
+        installment = amount
+    case installment == 0:
+        c.JSON(http.StatusBadRequest, gin.H{"error": "loans need installment or installments"})
+        return
+    }
+    if installment > amount {
+        installment = amount
+    }
+    if math.Ceil(amount/installment) > maxAdvanceInstallments {
+        c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("repayment must not take more than %d installments", maxAdvanceInstallments)})
+        return
+    }
+
+    issuedAt := time.Now()
+    if req.IssuedAt != "" {
+        var err error
+        if issuedAt, err = time.Parse("2006-01-02", req.IssuedAt); err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "invalid issued_at"})
+            return
+        }
+    }
+    recoverFrom := time.Date(issuedAt.Year(), issuedAt.Month(), issuedAt.Day(), 0, 0, 0, 0, time.UTC)
+    if req.RecoverFrom != "" {
+        var err error
+        if recoverFrom, err = time.Parse("2006-01-02", req.RecoverFrom); err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "invalid recover_from"})
+            return
+        }
+    }
+
+    var employee models.Employee
+    if err := h.DB.Where("id = ?", req.EmployeeID).Limit(1).Find(&employee).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    if employee.ID == 0 {
+        c.JSON(http.StatusNotFound, gin.H{"error": "employee not found"})
+        return
+    }
+
+    advance := models.SalaryAdvance{
+        EmployeeID:  employee.ID,
+        Kind:        req.Kind,
+        Principal:   amount,
+        Installment: installment,
+        Balance:     amount,
+        IssuedAt:    issuedAt,
+        RecoverFrom: recoverFrom,
+        Note:        req.Note,
+        Status:      models.AdvanceOpen,
+    }
+    if err := h.DB.Create(&advance).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusCreated, advance)
+}
+
+// GetAdvance returns an advance with its repayments and schedule
+// @Summary Get salary advance
+// @Description Retrieve an advance or loan with the salary deductions that recovered it and the projected schedule of the remaining installments
+// @Tags Advances
+// @Accept json
+// @Produce json
+// @Param id path int true "Advance ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /advances/{id} [get]
+func (h *AdvanceHandler) GetAdvance(c *gin.Context) {
+    id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+        return
+    }
+
+    var advance models.SalaryAdvance
+    if err := h.DB.First(&advance, id).Error; err != nil {
+        if err == gorm.ErrRecordNotFound {
+            c.JSON(http.StatusNotFound, gin.H{"error": "advance not found"})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
+        return
+    }
+
+    // Только удержания из проведённых выплат; строки черновых ведомостей ещё не погашение
+    var repayments []models.SalaryDeduction
+    if err := h.DB.Where("advance_id = ? AND salary_payment_id IS NOT NULL", advance.ID).
+        Order("id").Find(&repayments).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "advance":    advance,
+        "repayments": repayments,
+        "schedule":   advanceSchedule(advance),
+    })
+}
+
+// ListAdvances returns salary advances
+// @Summary List salary advances
+// @Description List advances and loans, newest first, optionally by employee and status
+// @Tags Advances
+// @Accept json
+// @Produce json
+// @Param employee_id query int false "Employee ID"
+// @Param status query string false "open or repaid"
+// @Param limit query int false "Page size (default 50, max 500)"
+// @Param offset query int false "Entries to skip"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /advances [get]
+func (h *AdvanceHandler) ListAdvances(c *gin.Context) {
+    limit, offset, ok := parsePagination(c)
+    if !ok {
+        return
+    }
+
+    query := h.DB.Model(&models.SalaryAdvance{})
+    if v := c.Query("employee_id"); v != "" {
+        employeeID, err := strconv.ParseUint(v, 10, 64)
+        if err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "invalid employee_id"})
+            return
+        }
+        query = query.Where("employee_id = ?", employeeID)
+    }
+    if status := c.Query("status"); status != "" {
+        query = query.Where("status = ?", status)
+    }
+
+    var total int64
+    if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    var advances []models.SalaryAdvance
+    if err := query.Order("issued_at DESC, id DESC").Limit(limit).Offset(offset).
+        Find(&advances).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "advances": advances,
+        "total":    total,
+        "limit":    limit,
+        "offset":   offset,
+    })
+}
+
+// GetEmployeeAdvances returns the outstanding advances of an employee
+// @Summary Get employee advance balance
+// @Description Open advances and loans of an employee with the total outstanding balance and what the next regular salary payment will recover
+// @Tags Advances
+// @Accept json
+// @Produce json
+// @Param employee_id path int true "Employee ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /advances/employee/{employee_id} [get]
+func (h *AdvanceHandler) GetEmployeeAdvances(c *gin.Context) {
+    employeeID, err := strconv.ParseUint(c.Param("employee_id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid employee_id"})
+        return
+    }
+
+    var advances []models.SalaryAdvance
+    if err := h.DB.Where("employee_id = ? AND status = ?", employeeID, models.AdvanceOpen).
+        Order("issued_at, id").Find(&advances).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    var outstanding, next float64
+    for _, a := range advances {
+        outstanding += a.Balance
+        next += math.Min(a.Installment, a.Balance)
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "employee_id":       employeeID,
+        "advances":          advances,
+        "outstanding":       roundMoney(outstanding),
+        "next_installments": roundMoney(next),
+    })
+}
@@ -20,6 +20,7 @@ func NewAttendanceHandler(db *gorm.DB) *AttendanceHandler {
 
 type clockInRequest struct {
     EmployeeID uint `json:"employee_id"`
+    ShopID     uint `json:"shop_id"` // магазин, в котором сотрудник на смене
 }
 // ClockIn marks the employee's clock-in time
 // @Summary Clock-in for an employee
@@ -42,6 +43,7 @@ func (h *AttendanceHandler) ClockIn(c *gin.Context) {
 
     record := models.EmployeeAttendance{
         EmployeeID: req.EmployeeID,
+        ShopID:     req.ShopID,
         ClockIn:    time.Now(),
     }
 
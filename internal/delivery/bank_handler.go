@@ -0,0 +1,598 @@
+package delivery
+
+import (
+    "bytes"
+    "errors"
+    "fmt"
+    "log"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+    "unicode/utf8"
+
+    "github.com/dibsnvas/golang-2025/internal/banking"
+    "github.com/dibsnvas/golang-2025/internal/models"
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+)
+
+const defaultCurrency = "EUR"
+
+type BankHandler struct {
+    DB *gorm.DB
+    // Cipher шифрует номера счетов; nil — BANK_DATA_KEY не задан, банковские данные недоступны
+    Cipher *banking.Cipher
+}
+
+func NewBankHandler(db *gorm.DB) *BankHandler {
+    cipher, err := banking.CipherFromEnv()
+    if err != nil {
+        log.Printf("Bank details are disabled: %v", err)
+    }
+    return &BankHandler{DB: db, Cipher: cipher}
+}
+
+type bankAccountRequest struct {
+    HolderName string `json:"holder_name"`
+    IBAN       string `json:"iban"`
+    BIC        string `json:"bic"`
+}
+
+// bankAccountAD binds an encrypted IBAN to its employee.
+func bankAccountAD(employeeID uint) string {
+    return fmt.Sprintf("employee:%d", employeeID)
+}
+
+func validBIC(bic string) bool {
+    if len(bic) != 8 && len(bic) != 11 {
+        return false
+    }
+    for i, r := range bic {
+        letter := r >= 'A' && r <= 'Z'
+        digit := r >= '0' && r <= '9'
+        if (i < 6 && !letter) || (!letter && !digit) {
+            return false
+        }
+    }
+    return true
+}
+
+// SetBankAccount stores the bank account of an employee
+// @Summary Set employee bank account
+// @Description Store the account salary is transferred to. The IBAN is validated (country length and mod-97 check digits) and stored encrypted with BANK_DATA_KEY; responses only show it masked. 503 when no key is configured
+// @Tags Employees
+// @Accept json
+// @Produce json
+// @Param id path int true "Employee ID"
+// @Param bankAccountRequest body bankAccountRequest true "Bank account"
+// @Success 200 {object} models.EmployeeBankAccount
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Failure 503 {object} map[string]interface{}
+// @Router /employees/{id}/bank-account [put]
+func (h *BankHandler) SetBankAccount(c *gin.Context) {
+    if h.Cipher == nil {
+        c.JSON(http.StatusServiceUnavailable, gin.H{"error": "bank data encryption is not configured"})
+        return
+    }
+    id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+        return
+    }
+
+    var req bankAccountRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    iban := banking.NormalizeIBAN(req.IBAN)
+    if err := banking.ValidateIBAN(iban); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    bic := strings.ToUpper(strings.TrimSpace(req.BIC))
+    if bic != "" && !validBIC(bic) {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid bic"})
+        return
+    }
+
+    var employee models.Employee
+    if err := h.DB.First(&employee, id).Error; err != nil {
+        if err == gorm.ErrRecordNotFound {
+            c.JSON(http.StatusNotFound, gin.H{"error": "employee not found"})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
+        return
+    }
+    if req.HolderName == "" {
+        req.HolderName = employee.Name
+    }
+
+    encrypted, err := h.Cipher.Encrypt(iban, bankAccountAD(employee.ID))
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    var account models.EmployeeBankAccount
+    if err := h.DB.Where("employee_id = ?", employee.ID).Limit(1).Find(&account).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    account.EmployeeID = employee.ID
+    account.HolderName = req.HolderName
+    account.IBANEncrypted = encrypted
+    account.IBANMasked = banking.MaskIBAN(iban)
+    account.BIC = bic
+    if err := h.DB.Save(&account).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, account)
+}
+
+// GetBankAccount returns the bank account of an employee
+// @Summary Get employee bank account
+// @Description Bank account of an employee with the IBAN masked
+// @Tags Employees
+// @Accept json
+// @Produce json
+// @Param id path int true "Employee ID"
+// @Success 200 {object} models.EmployeeBankAccount
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /employees/{id}/bank-account [get]
+func (h *BankHandler) GetBankAccount(c *gin.Context) {
+    id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+        return
+    }
+
+    var account models.EmployeeBankAccount
+    if err := h.DB.Where("employee_id = ?", id).First(&account).Error; err != nil {
+        if err == gorm.ErrRecordNotFound {
+            c.JSON(http.StatusNotFound, gin.H{"error": "bank account not found"})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
+        return
+    }
+
+    c.JSON(http.StatusOK, account)
+}
+
+type bankExportProblem struct {
+    EmployeeID uint   `json:"employee_id"`
+    Error      string `json:"error"`
+}
+
+// bankTransfer is net pay of a payroll line or salary payment to transfer.
+type bankTransfer struct {
+    EmployeeID uint
+    EndToEndID string
+    Amount     float64
+    Reference  string
+}
+
+func payPeriodReference(start, end time.Time) string {
+    return fmt.Sprintf("Salary %s - %s", start.Format("2006-01-02"), end.Format("2006-01-02"))
+}
+
+// runTransfers returns the net pay of the lines of a run. Lines whose salary
+// payment was reversed are left out.
+func runTransfers(db *gorm.DB, run *models.PayrollRun) ([]bankTransfer, error) {
+    lines, _, err := splitReversedLines(db, run)
+    if err != nil {
+        return nil, err
+    }
+    var transfers []bankTransfer
+    for _, l := range lines {
+        if l.NetAmount <= 0 {
+            continue
+        }
+        transfers = append(transfers, bankTransfer{
+            EmployeeID: l.EmployeeID,
+            EndToEndID: fmt.Sprintf("PR%d-L%d", run.ID, l.ID),
+            Amount:     l.NetAmount,
+            Reference:  payPeriodReference(run.PeriodStart, run.PeriodEnd),
+        })
+    }
+    return transfers, nil
+}
+
+// buildBankBatch turns net pay into transfers to the employees' accounts.
+// Every employee needs a bank account with a valid IBAN; otherwise the
+// problems are returned and no batch.
+func (h *BankHandler) buildBankBatch(messageID string, transfers []bankTransfer, company *models.CompanySettings, executionDate time.Time) (*banking.Batch, []bankExportProblem, error) {
+    batch := &banking.Batch{
+        MessageID:     messageID,
+        Created:       time.Now(),
+        ExecutionDate: executionDate,
+        DebtorName:    company.Name,
+        DebtorIBAN:    banking.NormalizeIBAN(company.BankIBAN),
+        DebtorBIC:     company.BankBIC,
+        Currency:      company.Currency,
+    }
+    if batch.Currency == "" {
+        batch.Currency = defaultCurrency
+    }
+
+    var problems []bankExportProblem
+    if err := banking.ValidateIBAN(batch.DebtorIBAN); err != nil {
+        problems = append(problems, bankExportProblem{Error: "company bank_iban: " + err.Error()})
+    }
+
+    var ids []uint
+    for _, t := range transfers {
+        ids = append(ids, t.EmployeeID)
+    }
+    var accounts []models.EmployeeBankAccount
+    if err := h.DB.Where("employee_id IN ?", ids).Find(&accounts).Error; err != nil {
+        return nil, nil, err
+    }
+    byEmployee := make(map[uint]models.EmployeeBankAccount, len(accounts))
+    for _, a := range accounts {
+        byEmployee[a.EmployeeID] = a
+    }
+
+    for _, t := range transfers {
+        account, ok := byEmployee[t.EmployeeID]
+        if !ok {
+            problems = append(problems, bankExportProblem{EmployeeID: t.EmployeeID, Error: "no bank account"})
+            continue
+        }
+        iban, err := h.Cipher.Decrypt(account.IBANEncrypted, bankAccountAD(account.EmployeeID))
+        if err != nil {
+            problems = append(problems, bankExportProblem{EmployeeID: t.EmployeeID, Error: "bank account cannot be decrypted"})
+            continue
+        }
+        // Проверяем ещё раз: счёт мог быть записан до появления проверки или с другим ключом
+        if err := banking.ValidateIBAN(iban); err != nil {
+            problems = append(problems, bankExportProblem{EmployeeID: t.EmployeeID, Error: err.Error()})
+            continue
+        }
+        batch.Transfers = append(batch.Transfers, banking.Transfer{
+            EndToEndID: t.EndToEndID,
+            Name:       account.HolderName,
+            IBAN:       iban,
+            BIC:        account.BIC,
+            Amount:     t.Amount,
+            Reference:  t.Reference,
+        })
+    }
+    if len(problems) > 0 {
+        return nil, problems, nil
+    }
+    return batch, nil, nil
+}
+
+// parseBankExportOptions checks the file format and the requested execution
+// date (default: today) of a bank export.
+func parseBankExportOptions(format, executionDate string) (string, time.Time, error) {
+    if format == "" {
+        format = "pain001"
+    }
+    if format != "pain001" && format != "csv" {
+        return "", time.Time{}, errors.New("format must be pain001 or csv")
+    }
+    date := time.Now()
+    if executionDate != "" {
+        var err error
+        if date, err = time.Parse("2006-01-02", executionDate); err != nil {
+            return "", time.Time{}, errors.New("invalid execution_date, use YYYY-MM-DD")
+        }
+    }
+    return format, date, nil
+}
+
+// writeBankFile encodes a batch as pain.001 XML or as CSV in the company
+// layout and returns the content type and file extension.
+func writeBankFile(buf *bytes.Buffer, batch *banking.Batch, company *models.CompanySettings, format string) (string, string, error) {
+    if format != "csv" {
+        return "application/xml", "xml", banking.WritePain001(buf, batch)
+    }
+    columns, err := banking.ParseCSVLayout(company.BankCSVLayout)
+    if err != nil {
+        return "", "", err
+    }
+    delimiter := ','
+    if company.BankCSVDelimiter != "" {
+        delimiter, _ = utf8.DecodeRuneInString(company.BankCSVDelimiter)
+    }
+    return "text/csv; charset=utf-8", "csv", banking.WriteCSV(buf, batch, columns, delimiter)
+}
+
+func sendBankFile(c *gin.Context, batch *banking.Batch, buf *bytes.Buffer, contentType, filename string) {
+    c.Header("Cache-Control", "no-store")
+    c.Header("X-Message-Id", batch.MessageID)
+    c.Header("X-Transaction-Count", strconv.Itoa(len(batch.Transfers)))
+    c.Header("X-Control-Sum", batch.ControlSum())
+    c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+    c.Data(http.StatusOK, contentType, buf.Bytes())
+}
+
+// ExportRun exports the transfers of a payroll run as a bank payment file
+// @Summary Export payroll run bank file
+// @Description Export the net pay transfers of a paid payroll run (409 before it is paid, as paying can still change net amounts), leaving out lines whose salary payment was reversed, as ISO 20022 pain.001.001.03 XML or as CSV in the company layout (bank_csv_layout, bank_csv_delimiter). All IBANs are validated first; if any employee lacks a valid account nothing is exported and 422 lists the problems. A run without transfers is rejected with 422. Every export is recorded with its message ID; exporting a run again, or a run some of whose payments were exported with POST /salary/bank-export, requires reexport=true, otherwise 409 returns the previous export or those salary_ids. The message ID, number of transfers and control sum are returned in the X-Message-Id, X-Transaction-Count and X-Control-Sum headers. Payments made outside the run, such as reissues of its reversed lines, are exported with POST /salary/bank-export
+// @Tags Payroll
+// @Produce xml
+// @Produce text/csv
+// @Param id path int true "Run ID"
+// @Param format query string false "pain001 (default) or csv"
+// @Param execution_date query string false "Requested execution date in YYYY-MM-DD format (default: today)"
+// @Param reexport query bool false "Export a run that was exported before"
+// @Success 200 {string} string
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 422 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Failure 503 {object} map[string]interface{}
+// @Router /payroll/runs/{id}/bank-export [post]
+func (h *BankHandler) ExportRun(c *gin.Context) {
+    if h.Cipher == nil {
+        c.JSON(http.StatusServiceUnavailable, gin.H{"error": "bank data encryption is not configured"})
+        return
+    }
+    id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+        return
+    }
+    format, executionDate, err := parseBankExportOptions(c.Query("format"), c.Query("execution_date"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    var run models.PayrollRun
+    if err := h.DB.Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).First(&run, id).Error; err != nil {
+        if err == gorm.ErrRecordNotFound {
+            c.JSON(http.StatusNotFound, gin.H{"error": "payroll run not found"})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
+        return
+    }
+    // До оплаты суммы строк ещё могут измениться: PayRun ограничивает погашение авансов
+    if run.Status != models.PayrollRunPaid {
+        c.JSON(http.StatusConflict, gin.H{"error": "only paid payroll runs can be exported"})
+        return
+    }
+
+    company, err := loadCompanySettings(h.DB)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    transfers, err := runTransfers(h.DB, &run)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    messageID := fmt.Sprintf("PAYROLL-%d-%s", run.ID, time.Now().Format("20060102150405"))
+    batch, problems, err := h.buildBankBatch(messageID, transfers, company, executionDate)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    if len(problems) > 0 {
+        c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "bank details are missing or invalid", "problems": problems})
+        return
+    }
+    // Файл pain.001 без CdtTrfTxInf не проходит схему
+    if len(batch.Transfers) == 0 {
+        c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "payroll run has no transfers"})
+        return
+    }
+
+    // Повторная выгрузка только явно: иначе банк может получить те же переводы дважды
+    var previous models.PayrollBankExport
+    if err := h.DB.Where("run_id = ?", run.ID).Order("id DESC").Limit(1).Find(&previous).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    if previous.ID != 0 && c.Query("reexport") != "true" {
+        c.JSON(http.StatusConflict, gin.H{
+            "error":       "payroll run was already exported; pass reexport=true to export it again",
+            "message_id":  previous.MessageID,
+            "exported_at": previous.CreatedAt,
+        })
+        return
+    }
+    if c.Query("reexport") != "true" {
+        var exported []uint
+        if err := h.DB.Model(&models.SalaryBankExportPayment{}).
+            Joins("JOIN salary_payments p ON p.id = salary_bank_export_payments.salary_payment_id").
+            Where("p.payroll_run_id = ?", run.ID).Order("p.id").Pluck("p.id", &exported).Error; err != nil {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+            return
+        }
+        if len(exported) > 0 {
+            c.JSON(http.StatusConflict, gin.H{
+                "error":      "payments of the run were already exported on their own; pass reexport=true to export the run",
+                "salary_ids": exported,
+            })
+            return
+        }
+    }
+
+    var buf bytes.Buffer
+    contentType, ext, err := writeBankFile(&buf, batch, company, format)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    export := models.PayrollBankExport{
+        RunID:      run.ID,
+        MessageID:  batch.MessageID,
+        Format:     format,
+        Transfers:  len(batch.Transfers),
+        ControlSum: batch.ControlSum(),
+    }
+    if err := h.DB.Create(&export).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    sendBankFile(c, batch, &buf, contentType, fmt.Sprintf("payroll-%d.%s", run.ID, ext))
+}
+
+type salaryBankExportRequest struct {
+    SalaryIDs     []uint `json:"salary_ids"`
+    Format        string `json:"format"`         // pain001 (по умолчанию) или csv
+    ExecutionDate string `json:"execution_date"` // YYYY-MM-DD, по умолчанию сегодня
+    Reexport      bool   `json:"reexport"`
+}
+
+// exportedSalaryPayments returns the IDs of the payments that are already in
+// a bank file: exported on their own or with their payroll run.
+func exportedSalaryPayments(db *gorm.DB, ids []uint) ([]uint, error) {
+    var exported []uint
+    err := db.Model(&models.SalaryPayment{}).
+        Where("id IN ?", ids).
+        Where("id IN (?) OR payroll_run_id IN (?)",
+            db.Model(&models.SalaryBankExportPayment{}).Select("salary_payment_id"),
+            db.Model(&models.PayrollBankExport{}).Select("run_id")).
+        Order("id").Pluck("id", &exported).Error
+    return exported, err
+}
+
+// ExportSalaryPayments exports the transfers of salary payments as a bank payment file
+// @Summary Export salary payments bank file
+// @Description Export the net pay of the listed salary payments, such as those made with POST /salary/pay, POST /salary/adjustments/{id}/pay or POST /salary/{id}/reissue, in the same formats as a payroll run export. Reversed payments and reversal entries cannot be exported (409 and 400). Payments already exported on their own or with their payroll run need reexport=true, otherwise 409 lists their salary_ids. All IBANs are validated first; if any employee lacks a valid account nothing is exported and 422 lists the problems. Every export is recorded with its message ID and payments; the message ID, number of transfers and control sum are returned in the X-Message-Id, X-Transaction-Count and X-Control-Sum headers
+// @Tags Salary
+// @Accept json
+// @Produce xml
+// @Produce text/csv
+// @Param salaryBankExportRequest body salaryBankExportRequest true "Payments and export options"
+// @Success 200 {string} string
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 422 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Failure 503 {object} map[string]interface{}
+// @Router /salary/bank-export [post]
+func (h *BankHandler) ExportSalaryPayments(c *gin.Context) {
+    if h.Cipher == nil {
+        c.JSON(http.StatusServiceUnavailable, gin.H{"error": "bank data encryption is not configured"})
+        return
+    }
+    var req salaryBankExportRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    if len(req.SalaryIDs) == 0 {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "salary_ids is required"})
+        return
+    }
+    format, executionDate, err := parseBankExportOptions(req.Format, req.ExecutionDate)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    var payments []models.SalaryPayment
+    if err := h.DB.Where("id IN ?", req.SalaryIDs).Order("id").Find(&payments).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    found := make(map[uint]bool, len(payments))
+    for _, p := range payments {
+        found[p.ID] = true
+    }
+    for _, id := range req.SalaryIDs {
+        if !found[id] {
+            c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("salary payment %d not found", id)})
+            return
+        }
+    }
+
+    var transfers []bankTransfer
+    ids := make([]uint, 0, len(payments))
+    for _, p := range payments {
+        switch {
+        case p.PaymentType == models.SalaryPaymentReversal:
+            c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("salary payment %d is a reversal entry", p.ID)})
+            return
+        case p.ReversedAt != nil:
+            c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("salary payment %d was reversed", p.ID)})
+            return
+        case p.NetAmount <= 0:
+            c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("salary payment %d has no net pay to transfer", p.ID)})
+            return
+        }
+        ids = append(ids, p.ID)
+        transfers = append(transfers, bankTransfer{
+            EmployeeID: p.EmployeeID,
+            EndToEndID: fmt.Sprintf("SP%d", p.ID),
+            Amount:     p.NetAmount,
+            Reference:  payPeriodReference(p.PayPeriodStart, p.PayPeriodEnd),
+        })
+    }
+
+    // Повторная выгрузка только явно: иначе банк может получить те же переводы дважды
+    if !req.Reexport {
+        exported, err := exportedSalaryPayments(h.DB, ids)
+        if err != nil {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+            return
+        }
+        if len(exported) > 0 {
+            c.JSON(http.StatusConflict, gin.H{
+                "error":      "salary payments were already exported; pass reexport=true to export them again",
+                "salary_ids": exported,
+            })
+            return
+        }
+    }
+
+    company, err := loadCompanySettings(h.DB)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    messageID := fmt.Sprintf("SALARY-%d-%s", ids[0], time.Now().Format("20060102150405"))
+    batch, problems, err := h.buildBankBatch(messageID, transfers, company, executionDate)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    if len(problems) > 0 {
+        c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "bank details are missing or invalid", "problems": problems})
+        return
+    }
+
+    var buf bytes.Buffer
+    contentType, ext, err := writeBankFile(&buf, batch, company, format)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    export := models.SalaryBankExport{
+        MessageID:  batch.MessageID,
+        Format:     format,
+        Transfers:  len(batch.Transfers),
+        ControlSum: batch.ControlSum(),
+    }
+    for _, id := range ids {
+        export.Payments = append(export.Payments, models.SalaryBankExportPayment{SalaryPaymentID: id})
+    }
+    if err := h.DB.Create(&export).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    sendBankFile(c, batch, &buf, contentType, fmt.Sprintf("salary-%d.%s", export.ID, ext))
+}
@@ -0,0 +1,31 @@
+package delivery
+
+import (
+    "net/http"
+
+    "github.com/dibsnvas/golang-2025/internal/catalog"
+    "github.com/gin-gonic/gin"
+)
+
+type CatalogHandler struct {
+    Client *catalog.Client
+}
+
+func NewCatalogHandler(client *catalog.Client) *CatalogHandler {
+    return &CatalogHandler{Client: client}
+}
+
+// GetMetrics returns catalog client metrics
+// @Summary Catalog client metrics
+// @Description Per-operation counters of calls to the catalog service (calls, successes, failures, retries, calls rejected by the open circuit breaker) and the current circuit breaker state
+// @Tags Catalog
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /catalog/metrics [get]
+func (h *CatalogHandler) GetMetrics(c *gin.Context) {
+    c.JSON(http.StatusOK, gin.H{
+        "base_url":   h.Client.Config.BaseURL,
+        "breaker":    h.Client.BreakerState(),
+        "operations": h.Client.Metrics.Snapshot(),
+    })
+}
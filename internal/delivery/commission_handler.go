@@ -0,0 +1,423 @@
+package delivery
+
+import (
+    "errors"
+    "fmt"
+    "math"
+    "net/http"
+    "sort"
+    "strconv"
+    "time"
+
+    "github.com/dibsnvas/golang-2025/internal/models"
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+)
+
+var errNoCommissionPlan = errors.New("no commission plan assigned for this period")
+
+type CommissionHandler struct {
+    DB *gorm.DB
+}
+
+func NewCommissionHandler(db *gorm.DB) *CommissionHandler {
+    return &CommissionHandler{DB: db}
+}
+
+// toCents converts an amount to cents, not yet rounded. v*100 is not exact in
+// binary (0.29*100 = 28.999999999999996, 1.005*100 = 100.49999999999999);
+// snapping to a millionth of a cent lets rounding see the decimal value.
+func toCents(v float64) float64 {
+    return math.Round(v*1e8) / 1e6
+}
+
+// roundMoney rounds an amount to cents, halves away from zero.
+func roundMoney(v float64) float64 {
+    return math.Round(toCents(v)) / 100
+}
+
+type createCommissionPlanRequest struct {
+    Name     string  `json:"name"`
+    PlanType string  `json:"plan_type"` // flat, tiered или category
+    Rate     float64 `json:"rate"`      // проценты: 2.5 = 2.5%
+    Tiers    []struct {
+        MinRevenue float64 `json:"min_revenue"`
+        Rate       float64 `json:"rate"`
+    } `json:"tiers"`
+    CategoryRates []struct {
+        Category string  `json:"category"`
+        Rate     float64 `json:"rate"`
+    } `json:"category_rates"`
+}
+
+// CreatePlan registers a new commission plan
+// @Summary Create a commission plan
+// @Description Create a flat, tiered (by monthly revenue) or per-category commission plan. Rates are percentages
+// @Tags Commission
+// @Accept json
+// @Produce json
+// @Param createCommissionPlanRequest body createCommissionPlanRequest true "Plan definition"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /commission/plans [post]
+func (h *CommissionHandler) CreatePlan(c *gin.Context) {
+    var req createCommissionPlanRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    if req.Name == "" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+        return
+    }
+    if req.Rate < 0 {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "rate must not be negative"})
+        return
+    }
+
+    plan := models.CommissionPlan{
+        Name:     req.Name,
+        PlanType: req.PlanType,
+        Rate:     req.Rate,
+    }
+
+    switch req.PlanType {
+    case models.CommissionPlanFlat:
+    case models.CommissionPlanTiered:
+        if len(req.Tiers) == 0 {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "tiered plan needs at least one tier"})
+            return
+        }
+        seen := map[float64]bool{}
+        for _, t := range req.Tiers {
+            if t.MinRevenue < 0 || t.Rate < 0 || seen[t.MinRevenue] {
+                c.JSON(http.StatusBadRequest, gin.H{"error": "tiers must have unique non-negative min_revenue and non-negative rate"})
+                return
+            }
+            seen[t.MinRevenue] = true
+            plan.Tiers = append(plan.Tiers, models.CommissionTier{MinRevenue: t.MinRevenue, Rate: t.Rate})
+        }
+    case models.CommissionPlanCategory:
+        if len(req.CategoryRates) == 0 {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "category plan needs at least one category rate"})
+            return
+        }
+        for _, cr := range req.CategoryRates {
+            if cr.Category == "" || cr.Rate < 0 {
+                c.JSON(http.StatusBadRequest, gin.H{"error": "category rates need a category and a non-negative rate"})
+                return
+            }
+            plan.CategoryRates = append(plan.CategoryRates, models.CommissionCategoryRate{Category: cr.Category, Rate: cr.Rate})
+        }
+    default:
+        c.JSON(http.StatusBadRequest, gin.H{"error": "plan_type must be flat, tiered or category"})
+        return
+    }
+
+    if err := h.DB.Create(&plan).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusCreated, gin.H{"plan_id": plan.ID})
+}
+
+// GetPlan returns a commission plan with its tiers and category rates
+// @Summary Get commission plan by ID
+// @Description Retrieve a commission plan with its tiers and category rates
+// @Tags Commission
+// @Accept json
+// @Produce json
+// @Param id path int true "Plan ID"
+// @Success 200 {object} models.CommissionPlan
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /commission/plans/{id} [get]
+func (h *CommissionHandler) GetPlan(c *gin.Context) {
+    id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+        return
+    }
+
+    var plan models.CommissionPlan
+    if err := h.DB.Preload("Tiers").Preload("CategoryRates").First(&plan, id).Error; err != nil {
+        if err == gorm.ErrRecordNotFound {
+            c.JSON(http.StatusNotFound, gin.H{"error": "plan not found"})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
+        return
+    }
+
+    c.JSON(http.StatusOK, plan)
+}
+
+type assignCommissionPlanRequest struct {
+    EmployeeID    uint   `json:"employee_id"`
+    PlanID        uint   `json:"plan_id"`
+    EffectiveFrom string `json:"effective_from"` // "YYYY-MM-DD"
+    EffectiveTo   string `json:"effective_to"`   // можно не указывать — бессрочно
+}
+
+// AssignPlan assigns a commission plan to an employee
+// @Summary Assign a commission plan to an employee
+// @Description Assign a commission plan to an employee starting from a date, optionally until a date
+// @Tags Commission
+// @Accept json
+// @Produce json
+// @Param assignCommissionPlanRequest body assignCommissionPlanRequest true "Assignment data"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /commission/assignments [post]
+func (h *CommissionHandler) AssignPlan(c *gin.Context) {
+    var req assignCommissionPlanRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    from, err := time.Parse("2006-01-02", req.EffectiveFrom)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid effective_from"})
+        return
+    }
+
+    assignment := models.CommissionAssignment{
+        EmployeeID:    req.EmployeeID,
+        PlanID:        req.PlanID,
+        EffectiveFrom: from,
+    }
+    if req.EffectiveTo != "" {
+        to, err := time.Parse("2006-01-02", req.EffectiveTo)
+        if err != nil || to.Before(from) {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "invalid effective_to"})
+            return
+        }
+        assignment.EffectiveTo = &to
+    }
+
+    var plan models.CommissionPlan
+    if err := h.DB.First(&plan, req.PlanID).Error; err != nil {
+        if err == gorm.ErrRecordNotFound {
+            c.JSON(http.StatusNotFound, gin.H{"error": "plan not found"})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
+        return
+    }
+
+    if err := h.DB.Create(&assignment).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusCreated, gin.H{"assignment_id": assignment.ID})
+}
+
+type commissionLine struct {
+    Basis      string  `json:"basis"`
+    Revenue    float64 `json:"revenue"`
+    Rate       float64 `json:"rate"`
+    Commission float64 `json:"commission"`
+}
+
+type commissionStatement struct {
+    EmployeeID  uint             `json:"employee_id"`
+    PlanID      uint             `json:"plan_id"`
+    PlanName    string           `json:"plan_name"`
+    PlanType    string           `json:"plan_type"`
+    PeriodStart string           `json:"period_start"`
+    PeriodEnd   string           `json:"period_end"`
+    GrossSales  float64          `json:"gross_sales"`
+    Returns     float64          `json:"returns"`
+    NetRevenue  float64          `json:"net_revenue"`
+    Lines       []commissionLine `json:"lines"`
+    Commission  float64          `json:"commission"`
+}
+
+// lineRevenue is the amount a sale line contributes to revenue after
+// discounts; negative for returned items.
+func lineRevenue(item models.SaleItem) float64 {
+    // Строки, созданные до появления скидок, не имеют original_price
+    if item.OriginalPrice == 0 {
+        return float64(item.Quantity) * item.PriceAtSale
+    }
+    return float64(item.Quantity)*item.OriginalPrice - item.DiscountAmount
+}
+
+// computeCommission builds the commission statement of an employee for the
+// half-open period [from, to) using the plan assigned at the end of the period.
+func computeCommission(db *gorm.DB, employeeID uint, from, to time.Time) (*commissionStatement, error) {
+    var assignment models.CommissionAssignment
+    err := db.Preload("Plan.Tiers").Preload("Plan.CategoryRates").
+        Where("employee_id = ? AND effective_from < ? AND (effective_to IS NULL OR effective_to >= ?)", employeeID, to, from).
+        Order("effective_from desc").
+        First(&assignment).Error
+    if err == gorm.ErrRecordNotFound {
+        return nil, errNoCommissionPlan
+    }
+    if err != nil {
+        return nil, err
+    }
+    plan := assignment.Plan
+
+    var sales []models.SalesTransaction
+    if err := db.Preload("SaleItems").Scopes(completedSales).Where(
+        "employee_id = ? AND transaction_time >= ? AND transaction_time < ?",
+        employeeID, from, to,
+    ).Order("transaction_time").Find(&sales).Error; err != nil {
+        return nil, err
+    }
+
+    st := &commissionStatement{
+        EmployeeID:  employeeID,
+        PlanID:      plan.ID,
+        PlanName:    plan.Name,
+        PlanType:    plan.PlanType,
+        PeriodStart: from.Format("2006-01-02"),
+        PeriodEnd:   to.AddDate(0, 0, -1).Format("2006-01-02"),
+    }
+
+    byCategory := map[string]float64{}
+    var months []string
+    byMonth := map[string]float64{}
+    for _, s := range sales {
+        var revenue float64
+        for _, item := range s.SaleItems {
+            // Проданная подарочная карта — это предоплата, а не выручка
+            if item.GiftCardID != nil {
+                continue
+            }
+            revenue += lineRevenue(item)
+            byCategory[item.Category] += lineRevenue(item)
+        }
+        if revenue >= 0 {
+            st.GrossSales += revenue
+        } else {
+            st.Returns -= revenue
+        }
+
+        month := s.TransactionTime.Format("2006-01")
+        if _, ok := byMonth[month]; !ok {
+            months = append(months, month)
+        }
+        byMonth[month] += revenue
+    }
+    st.NetRevenue = st.GrossSales - st.Returns
+
+    switch plan.PlanType {
+    case models.CommissionPlanFlat:
+        st.Lines = append(st.Lines, commissionLine{
+            Basis:      "all sales",
+            Revenue:    st.NetRevenue,
+            Rate:       plan.Rate,
+            Commission: st.NetRevenue * plan.Rate / 100,
+        })
+    case models.CommissionPlanTiered:
+        // Ступени применяются к выручке каждого календарного месяца отдельно
+        tiers := append([]models.CommissionTier(nil), plan.Tiers...)
+        sort.Slice(tiers, func(i, j int) bool { return tiers[i].MinRevenue < tiers[j].MinRevenue })
+        for _, month := range months {
+            revenue := byMonth[month]
+            for i, t := range tiers {
+                upper := math.Inf(1)
+                if i+1 < len(tiers) {
+                    upper = tiers[i+1].MinRevenue
+                }
+                portion := math.Min(revenue, upper) - t.MinRevenue
+                if portion <= 0 {
+                    continue
+                }
+                st.Lines = append(st.Lines, commissionLine{
+                    Basis:      fmt.Sprintf("%s tier from %.2f", month, t.MinRevenue),
+                    Revenue:    portion,
+                    Rate:       t.Rate,
+                    Commission: portion * t.Rate / 100,
+                })
+            }
+        }
+    case models.CommissionPlanCategory:
+        rates := map[string]float64{}
+        for _, cr := range plan.CategoryRates {
+            rates[cr.Category] = cr.Rate
+        }
+        categories := make([]string, 0, len(byCategory))
+        for category := range byCategory {
+            categories = append(categories, category)
+        }
+        sort.Strings(categories)
+        for _, category := range categories {
+            rate, ok := rates[category]
+            if !ok {
+                rate = plan.Rate
+            }
+            basis := category
+            if basis == "" {
+                basis = "uncategorized"
+            }
+            st.Lines = append(st.Lines, commissionLine{
+                Basis:      basis,
+                Revenue:    byCategory[category],
+                Rate:       rate,
+                Commission: byCategory[category] * rate / 100,
+            })
+        }
+    }
+
+    for i := range st.Lines {
+        st.Lines[i].Revenue = roundMoney(st.Lines[i].Revenue)
+        st.Lines[i].Commission = roundMoney(st.Lines[i].Commission)
+        st.Commission += st.Lines[i].Commission
+    }
+    st.GrossSales = roundMoney(st.GrossSales)
+    st.Returns = roundMoney(st.Returns)
+    st.NetRevenue = roundMoney(st.NetRevenue)
+    st.Commission = roundMoney(st.Commission)
+
+    return st, nil
+}
+
+// GetStatement returns the commission statement of an employee for a pay period
+// @Summary Get commission statement
+// @Description Compute an employee's commission for a pay period from their sales, net of returns, using the assigned plan
+// @Tags Commission
+// @Accept json
+// @Produce json
+// @Param employee_id path int true "Employee ID"
+// @Param from query string true "Pay period start in YYYY-MM-DD format"
+// @Param to query string true "Pay period end (inclusive) in YYYY-MM-DD format"
+// @Success 200 {object} commissionStatement
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /commission/statement/{employee_id} [get]
+func (h *CommissionHandler) GetStatement(c *gin.Context) {
+    employeeID, err := strconv.ParseUint(c.Param("employee_id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid employee_id"})
+        return
+    }
+
+    from, to, ok := parseDateRange(c)
+    if !ok {
+        return
+    }
+
+    st, err := computeCommission(h.DB, uint(employeeID), from, to)
+    if err != nil {
+        if err == errNoCommissionPlan {
+            c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
+        return
+    }
+
+    c.JSON(http.StatusOK, st)
+}
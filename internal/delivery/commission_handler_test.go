@@ -0,0 +1,27 @@
+package delivery
+
+import "testing"
+
+func TestRoundMoney(t *testing.T) {
+    tests := []struct {
+        v, want float64
+    }{
+        {0.29, 0.29},
+        {1.005, 1.01},
+        {1.015, 1.02},
+        {2.675, 2.68},
+        {0.1 + 0.2, 0.3},
+        {19.999, 20},
+        {1234567.125, 1234567.13},
+        {-0.29, -0.29},
+        {-1.005, -1.01},
+        {-2.675, -2.68},
+        {-0.004, 0},
+        {0, 0},
+    }
+    for _, tt := range tests {
+        if got := roundMoney(tt.v); got != tt.want {
+            t.Errorf("roundMoney(%v) = %v, want %v", tt.v, got, tt.want)
+        }
+    }
+}
@@ -0,0 +1,177 @@
+package delivery
+
+import (
+    htmltemplate "html/template"
+    "net/http"
+    "strings"
+    "text/template"
+    "unicode/utf8"
+
+    "github.com/dibsnvas/golang-2025/internal/banking"
+    "github.com/dibsnvas/golang-2025/internal/models"
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+    "gorm.io/gorm/clause"
+)
+
+const (
+    companySettingsID          = 1
+    defaultOvertimeWeeklyHours = 40
+    defaultOvertimeMultiplier  = 1.5
+)
+
+type CompanyHandler struct {
+    DB *gorm.DB
+}
+
+func NewCompanyHandler(db *gorm.DB) *CompanyHandler {
+    return &CompanyHandler{DB: db}
+}
+
+// loadCompanySettings returns the company settings with defaults filled in.
+// A missing row is not an error.
+func loadCompanySettings(db *gorm.DB) (*models.CompanySettings, error) {
+    var settings models.CompanySettings
+    if err := db.Where("id = ?", companySettingsID).Limit(1).Find(&settings).Error; err != nil {
+        return nil, err
+    }
+    if settings.OvertimeWeeklyHours == 0 {
+        settings.OvertimeWeeklyHours = defaultOvertimeWeeklyHours
+    }
+    if settings.OvertimeMultiplier == 0 {
+        settings.OvertimeMultiplier = defaultOvertimeMultiplier
+    }
+    return &settings, nil
+}
+
+func validCurrency(code string) bool {
+    if len(code) != 3 {
+        return false
+    }
+    for _, r := range code {
+        if r < 'A' || r > 'Z' {
+            return false
+        }
+    }
+    return true
+}
+
+type companySettingsRequest struct {
+    Name                string  `json:"name"`
+    Address             string  `json:"address"`
+    TaxNumber           string  `json:"tax_number"`
+    PayslipFooter       string  `json:"payslip_footer"`
+    PayslipTextTemplate string  `json:"payslip_text_template"` // шаблон для PDF
+    PayslipHTMLTemplate string  `json:"payslip_html_template"`
+    OvertimeWeeklyHours float64 `json:"overtime_weekly_hours"` // 0 — 40
+    OvertimeMultiplier  float64 `json:"overtime_multiplier"`   // 0 — 1.5
+    BankIBAN            string  `json:"bank_iban"`
+    BankBIC             string  `json:"bank_bic"`
+    Currency            string  `json:"currency"`           // пусто — EUR
+    BankCSVLayout       string  `json:"bank_csv_layout"`    // например "name,iban,amount,reference"
+    BankCSVDelimiter    string  `json:"bank_csv_delimiter"` // один символ, например ";"
+}
+
+// GetCompany returns the company settings
+// @Summary Get company settings
+// @Description Company details printed on payslips, payslip templates and overtime rules, with defaults filled in
+// @Tags Company
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.CompanySettings
+// @Failure 500 {object} map[string]interface{}
+// @Router /company [get]
+func (h *CompanyHandler) GetCompany(c *gin.Context) {
+    settings, err := loadCompanySettings(h.DB)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    c.JSON(http.StatusOK, settings)
+}
+
+// UpdateCompany replaces the company settings
+// @Summary Update company settings
+// @Description Set company details, payslip templates (text/template for PDF, html/template for HTML; empty for the defaults) and overtime rules: hours above overtime_weekly_hours in a week are paid at overtime_multiplier times the hourly rate. The bank fields (validated company IBAN and BIC, currency, CSV column layout and delimiter) are used for salary bank file exports
+// @Tags Company
+// @Accept json
+// @Produce json
+// @Param companySettingsRequest body companySettingsRequest true "Company settings"
+// @Success 200 {object} models.CompanySettings
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /company [put]
+func (h *CompanyHandler) UpdateCompany(c *gin.Context) {
+    var req companySettingsRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    if req.OvertimeWeeklyHours < 0 || req.OvertimeMultiplier < 0 {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "overtime_weekly_hours and overtime_multiplier must not be negative"})
+        return
+    }
+    // Ошибку в шаблоне лучше показать сейчас, а не при печати расчётного листка
+    if req.PayslipTextTemplate != "" {
+        if _, err := template.New("payslip").Funcs(payslipTextFuncs).Parse(req.PayslipTextTemplate); err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payslip_text_template: " + err.Error()})
+            return
+        }
+    }
+    if req.PayslipHTMLTemplate != "" {
+        if _, err := htmltemplate.New("payslip").Funcs(payslipHTMLFuncs).Parse(req.PayslipHTMLTemplate); err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payslip_html_template: " + err.Error()})
+            return
+        }
+    }
+
+    // Счёт компании проверяем сразу, иначе ошибка всплывёт только при выгрузке платежей
+    req.BankIBAN = banking.NormalizeIBAN(req.BankIBAN)
+    if req.BankIBAN != "" {
+        if err := banking.ValidateIBAN(req.BankIBAN); err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "invalid bank_iban: " + err.Error()})
+            return
+        }
+    }
+    req.BankBIC = strings.ToUpper(strings.TrimSpace(req.BankBIC))
+    if req.BankBIC != "" && !validBIC(req.BankBIC) {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid bank_bic"})
+        return
+    }
+    req.Currency = strings.ToUpper(strings.TrimSpace(req.Currency))
+    if req.Currency != "" && !validCurrency(req.Currency) {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "currency must be a 3-letter ISO 4217 code"})
+        return
+    }
+    if _, err := banking.ParseCSVLayout(req.BankCSVLayout); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid bank_csv_layout: " + err.Error()})
+        return
+    }
+    if req.BankCSVDelimiter != "" && (utf8.RuneCountInString(req.BankCSVDelimiter) != 1 || strings.ContainsAny(req.BankCSVDelimiter, "\"\r\n")) {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "bank_csv_delimiter must be a single character other than a quote or newline"})
+        return
+    }
+
+    settings := models.CompanySettings{
+        ID:                  companySettingsID,
+        Name:                req.Name,
+        Address:             req.Address,
+        TaxNumber:           req.TaxNumber,
+        PayslipFooter:       req.PayslipFooter,
+        PayslipTextTemplate: req.PayslipTextTemplate,
+        PayslipHTMLTemplate: req.PayslipHTMLTemplate,
+        OvertimeWeeklyHours: req.OvertimeWeeklyHours,
+        OvertimeMultiplier:  req.OvertimeMultiplier,
+        BankIBAN:            req.BankIBAN,
+        BankBIC:             req.BankBIC,
+        Currency:            req.Currency,
+        BankCSVLayout:       req.BankCSVLayout,
+        BankCSVDelimiter:    req.BankCSVDelimiter,
+    }
+    if err := h.DB.Clauses(clause.OnConflict{UpdateAll: true}).Create(&settings).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, settings)
+}
@@ -0,0 +1,284 @@
+package delivery
+
+import (
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/dibsnvas/golang-2025/internal/models"
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+)
+
+const (
+    defaultPageSize = 50
+    maxPageSize     = 500
+)
+
+// parsePagination reads limit and offset from the query string. On invalid
+// values it writes a 400 response and returns ok == false.
+func parsePagination(c *gin.Context) (limit, offset int, ok bool) {
+    limit = defaultPageSize
+    if v := c.Query("limit"); v != "" {
+        n, err := strconv.Atoi(v)
+        if err != nil || n <= 0 || n > maxPageSize {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 500"})
+            return 0, 0, false
+        }
+        limit = n
+    }
+    if v := c.Query("offset"); v != "" {
+        n, err := strconv.Atoi(v)
+        if err != nil || n < 0 {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "invalid offset"})
+            return 0, 0, false
+        }
+        offset = n
+    }
+    return limit, offset, true
+}
+
+type CustomerHandler struct {
+    DB *gorm.DB
+    // PointValue — стоимость одного балла при списании
+    PointValue float64
+}
+
+func NewCustomerHandler(db *gorm.DB) *CustomerHandler {
+    return &CustomerHandler{DB: db, PointValue: loyaltyPointValueFromEnv()}
+}
+
+type createCustomerRequest struct {
+    Name        string `json:"name"`
+    Phone       string `json:"phone"`
+    Email       string `json:"email"`
+    LoyaltyCard string `json:"loyalty_card"` // номер карты лояльности, необязательно
+}
+
+// findCustomer resolves the customer of a sale from its ID or loyalty card
+// number. It returns nil when neither is given.
+func findCustomer(db *gorm.DB, id *uint, card string) (*models.Customer, error) {
+    if id == nil && card == "" {
+        return nil, nil
+    }
+
+    query := db.Model(&models.Customer{})
+    if card != "" {
+        query = query.Where("loyalty_card = ?", card)
+    }
+    if id != nil {
+        query = query.Where("id = ?", *id)
+    }
+    var customer models.Customer
+    if err := query.Limit(1).Find(&customer).Error; err != nil {
+        return nil, err
+    }
+    if customer.ID == 0 {
+        return nil, &saleError{Status: http.StatusBadRequest, Message: "customer not found"}
+    }
+    return &customer, nil
+}
+
+// CreateCustomer registers a customer
+// @Summary Create a customer
+// @Description Register a customer, optionally with a loyalty card number
+// @Tags Customers
+// @Accept json
+// @Produce json
+// @Param createCustomerRequest body createCustomerRequest true "Customer data"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /customers [post]
+func (h *CustomerHandler) CreateCustomer(c *gin.Context) {
+    var req createCustomerRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    if req.Name == "" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+        return
+    }
+
+    customer := models.Customer{Name: req.Name, Phone: req.Phone, Email: req.Email}
+    if card := strings.TrimSpace(req.LoyaltyCard); card != "" {
+        var count int64
+        if err := h.DB.Model(&models.Customer{}).Where("loyalty_card = ?", card).Count(&count).Error; err != nil {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+            return
+        }
+        if count > 0 {
+            c.JSON(http.StatusConflict, gin.H{"error": "loyalty card is already assigned"})
+            return
+        }
+        customer.LoyaltyCard = &card
+    }
+    if err := h.DB.Create(&customer).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusCreated, gin.H{"customer_id": customer.ID})
+}
+
+// loadCustomer writes 400/404/500 itself and returns nil on failure.
+func (h *CustomerHandler) loadCustomer(c *gin.Context) *models.Customer {
+    id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+        return nil
+    }
+
+    var customer models.Customer
+    if err := h.DB.First(&customer, id).Error; err != nil {
+        if err == gorm.ErrRecordNotFound {
+            c.JSON(http.StatusNotFound, gin.H{"error": "customer not found"})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
+        return nil
+    }
+    return &customer
+}
+
+// GetCustomer returns a customer by ID
+// @Summary Get customer by ID
+// @Description Retrieve customer details and points balance
+// @Tags Customers
+// @Accept json
+// @Produce json
+// @Param id path int true "Customer ID"
+// @Success 200 {object} models.Customer
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /customers/{id} [get]
+func (h *CustomerHandler) GetCustomer(c *gin.Context) {
+    customer := h.loadCustomer(c)
+    if customer == nil {
+        return
+    }
+    c.JSON(http.StatusOK, customer)
+}
+
+// GetLoyalty returns the points balance and history of a customer
+// @Summary Get customer loyalty points
+// @Description Points balance, its money value and the points history (newest first)
+// @Tags Customers
+// @Accept json
+// @Produce json
+// @Param id path int true "Customer ID"
+// @Param limit query int false "Page size (default 50, max 500)"
+// @Param offset query int false "Entries to skip"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /customers/{id}/loyalty [get]
+func (h *CustomerHandler) GetLoyalty(c *gin.Context) {
+    limit, offset, ok := parsePagination(c)
+    if !ok {
+        return
+    }
+    customer := h.loadCustomer(c)
+    if customer == nil {
+        return
+    }
+
+    var history []models.LoyaltyTransaction
+    if err := h.DB.Where("customer_id = ?", customer.ID).
+        Order("id DESC").Limit(limit).Offset(offset).
+        Find(&history).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "customer_id":    customer.ID,
+        "points_balance": customer.PointsBalance,
+        "points_value":   roundMoney(float64(customer.PointsBalance) * h.PointValue),
+        "history":        history,
+    })
+}
+
+type customerPurchase struct {
+    TransactionID   uint      `json:"transaction_id"`
+    ReceiptNumber   string    `json:"receipt_number"`
+    ShopID          uint      `json:"shop_id"`
+    TransactionTime time.Time `json:"transaction_time"`
+    TransactionType string    `json:"transaction_type"`
+    Status          string    `json:"status"`
+    TotalAmount     float64   `json:"total_amount"`
+    PointsEarned    int       `json:"points_earned"`
+    PointsRedeemed  int       `json:"points_redeemed"`
+}
+
+// GetPurchases returns the purchase history of a customer
+// @Summary Get customer purchases
+// @Description Sales and returns of a customer, newest first, including voided ones
+// @Tags Customers
+// @Accept json
+// @Produce json
+// @Param id path int true "Customer ID"
+// @Param limit query int false "Page size (default 50, max 500)"
+// @Param offset query int false "Entries to skip"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /customers/{id}/purchases [get]
+func (h *CustomerHandler) GetPurchases(c *gin.Context) {
+    limit, offset, ok := parsePagination(c)
+    if !ok {
+        return
+    }
+    customer := h.loadCustomer(c)
+    if customer == nil {
+        return
+    }
+
+    var sales []models.SalesTransaction
+    if err := h.DB.Where("customer_id = ?", customer.ID).
+        Order("transaction_time DESC, id DESC").Limit(limit).Offset(offset).
+        Find(&sales).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    var total struct {
+        Count  int64
+        Amount float64
+    }
+    if err := h.DB.Model(&models.SalesTransaction{}).Scopes(completedSales).
+        Select("COUNT(*) AS count, COALESCE(SUM(total_amount), 0) AS amount").
+        Where("customer_id = ?", customer.ID).
+        Scan(&total).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    purchases := make([]customerPurchase, 0, len(sales))
+    for _, s := range sales {
+        purchases = append(purchases, customerPurchase{
+            TransactionID:   s.ID,
+            ReceiptNumber:   s.ReceiptNumber,
+            ShopID:          s.ShopID,
+            TransactionTime: s.TransactionTime,
+            TransactionType: s.TransactionType,
+            Status:          s.Status,
+            TotalAmount:     s.TotalAmount,
+            PointsEarned:    s.PointsEarned,
+            PointsRedeemed:  s.PointsRedeemed,
+        })
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "customer_id":     customer.ID,
+        "purchases":       purchases,
+        "completed_count": total.Count,
+        "total_spent":     roundMoney(total.Amount),
+    })
+}
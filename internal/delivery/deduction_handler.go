@@ -0,0 +1,479 @@
+package delivery
+
+import (
+    "errors"
+    "math"
+    "net/http"
+    "sort"
+    "strconv"
+    "time"
+
+    "github.com/dibsnvas/golang-2025/internal/models"
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+    "gorm.io/gorm/clause"
+)
+
+// Порядок расчёта: взносы уменьшают облагаемую базу, затем налог, затем остальное из остатка
+var deductionOrder = map[string]int{
+    models.DeductionPension:     0,
+    models.DeductionSocial:      0,
+    models.DeductionIncomeTax:   1,
+    models.DeductionAdvance:     2,
+    models.DeductionFixed:       3,
+    models.DeductionGarnishment: 4,
+}
+
+var errAdvanceBalanceChanged = errors.New("advance balance changed since the deductions were computed")
+
+type DeductionHandler struct {
+    DB *gorm.DB
+}
+
+func NewDeductionHandler(db *gorm.DB) *DeductionHandler {
+    return &DeductionHandler{DB: db}
+}
+
+// withholding is the result of applying the deduction rules to gross pay.
+type withholding struct {
+    Lines                 []models.SalaryDeduction
+    Deductions            float64
+    Net                   float64
+    EmployerContributions float64
+}
+
+// add withholds d, capped so that no more than limit is taken. Lines with
+// nothing withheld and no employer part are dropped.
+func (w *withholding) add(d models.SalaryDeduction, limit float64) {
+    d.Amount = roundMoney(d.Amount)
+    if d.Amount > limit {
+        d.Amount = roundMoney(limit)
+    }
+    if d.Amount < 0 {
+        d.Amount = 0
+    }
+    if d.Amount == 0 && d.EmployerAmount == 0 {
+        return
+    }
+    w.Net = roundMoney(w.Net - d.Amount)
+    w.Deductions = roundMoney(w.Deductions + d.Amount)
+    w.EmployerContributions = roundMoney(w.EmployerContributions + d.EmployerAmount)
+    w.Lines = append(w.Lines, d)
+}
+
+// loadDeductionRules returns the active rules of an employee, including the
+// rules for everyone, in calculation order.
+func loadDeductionRules(db *gorm.DB, employeeID uint) ([]models.DeductionRule, error) {
+    var rules []models.DeductionRule
+    if err := db.Preload("Brackets", func(db *gorm.DB) *gorm.DB { return db.Order("threshold") }).
+        Where("active AND (employee_id IS NULL OR employee_id = ?)", employeeID).
+        Order("id").Find(&rules).Error; err != nil {
+        return nil, err
+    }
+    sort.SliceStable(rules, func(i, j int) bool {
+        return deductionOrder[rules[i].Kind] < deductionOrder[rules[j].Kind]
+    })
+    return rules, nil
+}
+
+// progressiveTax applies each bracket's rate to the part of taxable pay
+// between its threshold and the next one. Brackets are sorted by threshold.
+func progressiveTax(brackets []models.TaxBracket, taxable float64) float64 {
+    var tax float64
+    for i, b := range brackets {
+        top := taxable
+        if i+1 < len(brackets) && brackets[i+1].Threshold < top {
+            top = brackets[i+1].Threshold
+        }
+        if top > b.Threshold {
+            tax += (top - b.Threshold) * b.Rate / 100
+        }
+    }
+    return tax
+}
+
+// calculateDeductions applies rules (in calculation order) to gross pay and
+// recovers an installment of each advance after income tax. No deduction
+// takes more than is left to pay, so net pay never goes negative.
+//
+// prior holds, per rule, the base already withheld in the same pay period
+// (see periodToDate): contribution ceilings and tax brackets apply to the
+// period's total pay, so a bonus is taxed at the rate of the income it is
+// added to. Fixed deductions and garnishments are taken from regular pay
+// only, as are advance installments.
+func calculateDeductions(rules []models.DeductionRule, advances []models.SalaryAdvance, gross float64, regular bool, prior map[uint]float64) withholding {
+    w := withholding{Net: roundMoney(gross)}
+    if gross <= 0 {
+        return w
+    }
+
+    recovered := false
+    recoverAdvances := func() {
+        recovered = true
+        for i := range advances {
+            a := &advances[i]
+            d := models.SalaryDeduction{
+                AdvanceID:   &a.ID,
+                Kind:        models.DeductionAdvance,
+                Description: advanceDescription(a),
+                Base:        a.Balance,
+                Amount:      a.Installment,
+            }
+            limit := w.Net
+            if a.Balance < limit {
+                limit = a.Balance
+            }
+            w.add(d, limit)
+        }
+    }
+
+    taxable := gross
+    for i := range rules {
+        r := &rules[i]
+        if !recovered && deductionOrder[r.Kind] > deductionOrder[models.DeductionAdvance] {
+            recoverAdvances()
+        }
+        d := models.SalaryDeduction{RuleID: &r.ID, Kind: r.Kind, Description: r.Name}
+        limit := w.Net
+        switch r.Kind {
+        case models.DeductionPension, models.DeductionSocial:
+            d.Base = gross
+            // Потолок базы — на весь период, часть уже могла быть взята с других выплат
+            if ceiling := r.BaseCeiling - prior[r.ID]; r.BaseCeiling > 0 && d.Base > ceiling {
+                d.Base = math.Max(ceiling, 0)
+            }
+            d.Amount = d.Base * r.EmployeeRate / 100
+            d.EmployerAmount = roundMoney(d.Base * r.EmployerRate / 100)
+        case models.DeductionIncomeTax:
+            d.Base = roundMoney(taxable)
+            if len(r.Brackets) > 0 {
+                paid := prior[r.ID]
+                d.Amount = progressiveTax(r.Brackets, paid+taxable) - progressiveTax(r.Brackets, paid)
+            } else {
+                d.Amount = taxable * r.EmployeeRate / 100
+            }
+        case models.DeductionFixed:
+            if !regular {
+                continue
+            }
+            d.Base = w.Net
+            d.Amount = r.Amount
+        case models.DeductionGarnishment:
+            if !regular {
+                continue
+            }
+            d.Base = w.Net
+            d.Amount = r.Amount
+            if d.Amount == 0 {
+                d.Amount = w.Net * r.EmployeeRate / 100
+            }
+            limit = w.Net - r.ProtectedNet
+        default:
+            continue
+        }
+
+        before := w.Deductions
+        w.add(d, limit)
+        if r.Kind == models.DeductionPension || r.Kind == models.DeductionSocial {
+            taxable -= w.Deductions - before
+        }
+    }
+    if !recovered {
+        recoverAdvances()
+    }
+    return w
+}
+
+// periodToDate sums, per rule, the base of the deductions withheld from the
+// employee's payments for pay periods overlapping [start, end]. Reversed
+// payments and reversal entries do not count.
+func periodToDate(db *gorm.DB, employeeID uint, start, end time.Time) (map[uint]float64, error) {
+    var rows []struct {
+        RuleID uint
+        Base   float64
+    }
+    err := db.Table("salary_deductions AS d").
+        Select("d.rule_id, SUM(d.base) AS base").
+        Joins("JOIN salary_payments p ON p.id = d.salary_payment_id").
+        Where("p.employee_id = ? AND p.payment_type <> ? AND p.reversed_at IS NULL AND d.rule_id IS NOT NULL",
+            employeeID, models.SalaryPaymentReversal).
+        Where("p.pay_period_start <= ? AND p.pay_period_end >= ?", end, start).
+        Group("d.rule_id").Scan(&rows).Error
+    if err != nil {
+        return nil, err
+    }
+    prior := make(map[uint]float64, len(rows))
+    for _, r := range rows {
+        prior[r.RuleID] = r.Base
+    }
+    return prior, nil
+}
+
+// computeWithholding applies the employee's deduction rules to gross pay for
+// the pay period [periodStart, periodEnd], counting what was already paid for
+// it. Regular pay also recovers the advances due by periodEnd.
+func computeWithholding(db *gorm.DB, employeeID uint, gross float64, regular bool, periodStart, periodEnd time.Time) (withholding, error) {
+    rules, err := loadDeductionRules(db, employeeID)
+    if err != nil {
+        return withholding{}, err
+    }
+    prior, err := periodToDate(db, employeeID, periodStart, periodEnd)
+    if err != nil {
+        return withholding{}, err
+    }
+    var advances []models.SalaryAdvance
+    if regular {
+        if advances, err = dueAdvances(db, employeeID, periodEnd); err != nil {
+            return withholding{}, err
+        }
+    }
+    return calculateDeductions(rules, advances, gross, regular, prior), nil
+}
+
+// recordAdvanceRecoveries reduces the balances of the advances recovered by
+// the deduction lines of a payment. It runs inside the payment's database
+// transaction; if another payment has recovered the same installment since
+// the lines were computed, it fails with errAdvanceBalanceChanged.
+func recordAdvanceRecoveries(db *gorm.DB, lines []models.SalaryDeduction) error {
+    for _, d := range lines {
+        if d.AdvanceID == nil || d.Amount == 0 {
+            continue
+        }
+        res := db.Model(&models.SalaryAdvance{}).
+            Where("id = ? AND status = ? AND balance >= ?", *d.AdvanceID, models.AdvanceOpen, d.Amount).
+            Updates(map[string]interface{}{
+                "balance": gorm.Expr("balance - ?", d.Amount),
+                "status":  gorm.Expr("CASE WHEN balance - ? <= 0 THEN ? ELSE status END", d.Amount, models.AdvanceRepaid),
+            })
+        if res.Error != nil {
+            return res.Error
+        }
+        if res.RowsAffected == 0 {
+            return errAdvanceBalanceChanged
+        }
+    }
+    return nil
+}
+
+// capAdvanceRecoveries limits the advance installments of deduction lines
+// computed earlier, such as those of a payroll draft, to what the advances
+// still owe: another payment may have recovered the same installment since.
+// Changed lines are saved. Returns how much less is withheld in total.
+func capAdvanceRecoveries(db *gorm.DB, lines []models.SalaryDeduction) (float64, error) {
+    var released float64
+    for i := range lines {
+        d := &lines[i]
+        if d.AdvanceID == nil || d.Amount <= 0 {
+            continue
+        }
+        var advance models.SalaryAdvance
+        if err := db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", *d.AdvanceID).
+            Limit(1).Find(&advance).Error; err != nil {
+            return 0, err
+        }
+        owed := 0.0
+        if advance.Status == models.AdvanceOpen && advance.Balance > 0 {
+            owed = advance.Balance
+        }
+        if d.Amount <= owed {
+            continue
+        }
+        released += d.Amount - owed
+        d.Amount = roundMoney(owed)
+        if err := db.Model(&models.SalaryDeduction{}).Where("id = ?", d.ID).Update("amount", d.Amount).Error; err != nil {
+            return 0, err
+        }
+    }
+    return roundMoney(released), nil
+}
+
+type deductionRuleRequest struct {
+    Name         string  `json:"name"`
+    Kind         string  `json:"kind"`          // income_tax, pension, social, fixed или garnishment
+    EmployeeID   *uint   `json:"employee_id"`   // не указан — для всех сотрудников
+    EmployeeRate float64 `json:"employee_rate"` // проценты: 2.5 = 2.5%
+    EmployerRate float64 `json:"employer_rate"`
+    Amount       float64 `json:"amount"`
+    BaseCeiling  float64 `json:"base_ceiling"`
+    ProtectedNet float64 `json:"protected_net"`
+    Brackets     []struct {
+        Threshold float64 `json:"threshold"`
+        Rate      float64 `json:"rate"`
+    } `json:"brackets"`
+}
+
+// validate checks the fields that matter for the rule's kind.
+func (req *deductionRuleRequest) validate() string {
+    if req.Name == "" {
+        return "name is required"
+    }
+    if req.EmployeeRate < 0 || req.EmployeeRate > 100 || req.EmployerRate < 0 || req.EmployerRate > 100 {
+        return "employee_rate and employer_rate must be between 0 and 100"
+    }
+    if req.Amount < 0 || req.BaseCeiling < 0 || req.ProtectedNet < 0 {
+        return "amount, base_ceiling and protected_net must not be negative"
+    }
+
+    switch req.Kind {
+    case models.DeductionPension, models.DeductionSocial:
+        if req.EmployeeRate == 0 && req.EmployerRate == 0 {
+            return "pension and social rules need employee_rate or employer_rate"
+        }
+    case models.DeductionIncomeTax:
+        if len(req.Brackets) == 0 && req.EmployeeRate == 0 {
+            return "income_tax rules need brackets or employee_rate"
+        }
+        seen := map[float64]bool{}
+        for _, b := range req.Brackets {
+            if b.Threshold < 0 || b.Rate < 0 || b.Rate > 100 || seen[b.Threshold] {
+                return "brackets must have unique non-negative thresholds and rates between 0 and 100"
+            }
+            seen[b.Threshold] = true
+        }
+    case models.DeductionFixed:
+        if req.Amount == 0 {
+            return "fixed rules need amount"
+        }
+    case models.DeductionGarnishment:
+        if req.EmployeeID == nil {
+            return "garnishment rules need employee_id"
+        }
+        if req.Amount == 0 && req.EmployeeRate == 0 {
+            return "garnishment rules need amount or employee_rate"
+        }
+    default:
+        return "kind must be income_tax, pension, social, fixed or garnishment"
+    }
+    return ""
+}
+
+// CreateRule adds a deduction rule
+// @Summary Create a deduction rule
+// @Description Create a rule withheld from the gross pay of every new salary payment and payroll line, for one employee or for everyone. pension and social take employee_rate (and the employer's employer_rate) percent of gross up to base_ceiling and reduce taxable pay; income_tax is progressive by brackets (rate above each threshold) or a flat employee_rate on taxable pay; fixed takes amount; garnishment takes amount or employee_rate percent of what is left, keeping at least protected_net. base_ceiling and brackets apply to all pay of a pay period, counting earlier payments for it; fixed and garnishment rules apply to regular payments only. Deductions never make net pay negative
+// @Tags Deductions
+// @Accept json
+// @Produce json
+// @Param deductionRuleRequest body deductionRuleRequest true "Rule data"
+// @Success 201 {object} models.DeductionRule
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /deductions/rules [post]
+func (h *DeductionHandler) CreateRule(c *gin.Context) {
+    var req deductionRuleRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    if msg := req.validate(); msg != "" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+        return
+    }
+
+    if req.EmployeeID != nil {
+        var employee models.Employee
+        if err := h.DB.Where("id = ?", *req.EmployeeID).Limit(1).Find(&employee).Error; err != nil {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+            return
+        }
+        if employee.ID == 0 {
+            c.JSON(http.StatusNotFound, gin.H{"error": "employee not found"})
+            return
+        }
+    }
+
+    rule := models.DeductionRule{
+        Name:         req.Name,
+        Kind:         req.Kind,
+        EmployeeID:   req.EmployeeID,
+        EmployeeRate: req.EmployeeRate,
+        EmployerRate: req.EmployerRate,
+        Amount:       roundMoney(req.Amount),
+        BaseCeiling:  req.BaseCeiling,
+        ProtectedNet: roundMoney(req.ProtectedNet),
+        Active:       true,
+    }
+    if req.Kind == models.DeductionIncomeTax {
+        for _, b := range req.Brackets {
+            rule.Brackets = append(rule.Brackets, models.TaxBracket{Threshold: b.Threshold, Rate: b.Rate})
+        }
+    }
+    if err := h.DB.Create(&rule).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusCreated, rule)
+}
+
+// ListRules returns active deduction rules
+// @Summary List deduction rules
+// @Description List active deduction rules in calculation order, optionally only those that apply to an employee
+// @Tags Deductions
+// @Accept json
+// @Produce json
+// @Param employee_id query int false "Employee ID"
+// @Success 200 {array} models.DeductionRule
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /deductions/rules [get]
+func (h *DeductionHandler) ListRules(c *gin.Context) {
+    if v := c.Query("employee_id"); v != "" {
+        employeeID, err := strconv.ParseUint(v, 10, 64)
+        if err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "invalid employee_id"})
+            return
+        }
+        rules, err := loadDeductionRules(h.DB, uint(employeeID))
+        if err != nil {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+            return
+        }
+        c.JSON(http.StatusOK, rules)
+        return
+    }
+
+    var rules []models.DeductionRule
+    if err := h.DB.Preload("Brackets", func(db *gorm.DB) *gorm.DB { return db.Order("threshold") }).
+        Where("active").Order("id").Find(&rules).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    sort.SliceStable(rules, func(i, j int) bool {
+        return deductionOrder[rules[i].Kind] < deductionOrder[rules[j].Kind]
+    })
+
+    c.JSON(http.StatusOK, rules)
+}
+
+// DeleteRule deactivates a deduction rule
+// @Summary Deactivate a deduction rule
+// @Description Stop applying a deduction rule to new salary payments and payroll lines. Recorded deductions are kept
+// @Tags Deductions
+// @Accept json
+// @Produce json
+// @Param id path int true "Rule ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /deductions/rules/{id} [delete]
+func (h *DeductionHandler) DeleteRule(c *gin.Context) {
+    id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+        return
+    }
+
+    res := h.DB.Model(&models.DeductionRule{}).Where("id = ?", id).Update("active", false)
+    if res.Error != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": res.Error.Error()})
+        return
+    }
+    if res.RowsAffected == 0 {
+        c.JSON(http.StatusNotFound, gin.H{"error": "rule not found"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"rule_id": id, "active": false})
+}
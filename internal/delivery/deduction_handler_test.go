@@ -0,0 +1,210 @@
+package delivery
+
+import (
+    "math"
+    "testing"
+
+    "github.com/dibsnvas/golang-2025/internal/models"
+)
+
+func TestProgressiveTax(t *testing.T) {
+    brackets := []models.TaxBracket{
+        {Threshold: 0, Rate: 0},
+        {Threshold: 1000, Rate: 10},
+        {Threshold: 3000, Rate: 20},
+    }
+    tests := []struct {
+        name    string
+        taxable float64
+        want    float64
+    }{
+        {"nothing taxable", 0, 0},
+        {"inside the zero bracket", 999.99, 0},
+        {"at the first threshold", 1000, 0},
+        {"just above the first threshold", 1000.01, 0.001},
+        {"inside the second bracket", 2000, 100},
+        {"at the second threshold", 3000, 200},
+        {"just above the second threshold", 3000.01, 200.002},
+        {"inside the top bracket", 5000, 600},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := progressiveTax(brackets, tt.taxable); math.Abs(got-tt.want) > 1e-9 {
+                t.Errorf("progressiveTax(%v) = %v, want %v", tt.taxable, got, tt.want)
+            }
+        })
+    }
+}
+
+func TestProgressiveTaxFirstThresholdAboveZero(t *testing.T) {
+    // Часть ниже первого порога не облагается
+    brackets := []models.TaxBracket{{Threshold: 500, Rate: 10}}
+    if got := progressiveTax(brackets, 1500); math.Abs(got-100) > 1e-9 {
+        t.Errorf("progressiveTax(1500) = %v, want 100", got)
+    }
+}
+
+func TestCalculateDeductions(t *testing.T) {
+    pension := models.DeductionRule{ID: 1, Kind: models.DeductionPension, EmployeeRate: 10, EmployerRate: 5, BaseCeiling: 2000}
+    flatTax := models.DeductionRule{ID: 2, Kind: models.DeductionIncomeTax, EmployeeRate: 20}
+    bracketTax := models.DeductionRule{ID: 3, Kind: models.DeductionIncomeTax, Brackets: []models.TaxBracket{
+        {Threshold: 0, Rate: 0},
+        {Threshold: 1000, Rate: 10},
+    }}
+    fixed := models.DeductionRule{ID: 4, Kind: models.DeductionFixed, Amount: 50}
+    garnishment := models.DeductionRule{ID: 5, Kind: models.DeductionGarnishment, EmployeeRate: 50, ProtectedNet: 1500}
+
+    // Правила в тестах идут в порядке расчёта, как их возвращает loadDeductionRules
+    type line struct {
+        kind   string
+        amount float64
+    }
+    tests := []struct {
+        name           string
+        rules          []models.DeductionRule
+        advances       []models.SalaryAdvance
+        gross          float64
+        supplemental   bool
+        prior          map[uint]float64
+        wantLines      []line
+        wantDeductions float64
+        wantNet        float64
+        wantEmployer   float64
+    }{
+        {
+            name:    "no rules",
+            gross:   1000,
+            wantNet: 1000,
+        },
+        {
+            name:    "nothing to pay",
+            rules:   []models.DeductionRule{flatTax},
+            gross:   0,
+            wantNet: 0,
+        },
+        {
+            name:           "pension base capped at the ceiling and deducted before tax",
+            rules:          []models.DeductionRule{pension, flatTax},
+            gross:          3000,
+            wantLines:      []line{{models.DeductionPension, 200}, {models.DeductionIncomeTax, 560}},
+            wantDeductions: 760,
+            wantNet:        2240,
+            wantEmployer:   100,
+        },
+        {
+            name:           "tax brackets on pay reduced by pension",
+            rules:          []models.DeductionRule{pension, bracketTax},
+            gross:          1500,
+            wantLines:      []line{{models.DeductionPension, 150}, {models.DeductionIncomeTax, 35}},
+            wantDeductions: 185,
+            wantNet:        1315,
+            wantEmployer:   75,
+        },
+        {
+            name:           "garnishment leaves the protected net",
+            rules:          []models.DeductionRule{garnishment},
+            gross:          2000,
+            wantLines:      []line{{models.DeductionGarnishment, 500}},
+            wantDeductions: 500,
+            wantNet:        1500,
+        },
+        {
+            name:           "garnishment below the protected net takes nothing",
+            rules:          []models.DeductionRule{fixed, garnishment},
+            gross:          1500,
+            wantLines:      []line{{models.DeductionFixed, 50}},
+            wantDeductions: 50,
+            wantNet:        1450,
+        },
+        {
+            name:           "fixed deduction capped at what is left",
+            rules:          []models.DeductionRule{{ID: 6, Kind: models.DeductionFixed, Amount: 500}},
+            gross:          300,
+            wantLines:      []line{{models.DeductionFixed, 300}},
+            wantDeductions: 300,
+            wantNet:        0,
+        },
+        {
+            name:           "advance installment capped at the balance",
+            advances:       []models.SalaryAdvance{{ID: 1, Installment: 500, Balance: 300}},
+            gross:          1000,
+            wantLines:      []line{{models.DeductionAdvance, 300}},
+            wantDeductions: 300,
+            wantNet:        700,
+        },
+        {
+            name:           "advance installment capped at net pay",
+            advances:       []models.SalaryAdvance{{ID: 1, Installment: 500, Balance: 1000}},
+            gross:          400,
+            wantLines:      []line{{models.DeductionAdvance, 400}},
+            wantDeductions: 400,
+            wantNet:        0,
+        },
+        {
+            name:           "advances after tax and before garnishment",
+            rules:          []models.DeductionRule{flatTax, {ID: 7, Kind: models.DeductionGarnishment, EmployeeRate: 50}},
+            advances:       []models.SalaryAdvance{{ID: 1, Installment: 200, Balance: 1000}},
+            gross:          1000,
+            wantLines:      []line{{models.DeductionIncomeTax, 200}, {models.DeductionAdvance, 200}, {models.DeductionGarnishment, 300}},
+            wantDeductions: 700,
+            wantNet:        300,
+        },
+        {
+            name:           "supplemental pay skips fixed deductions and garnishments",
+            rules:          []models.DeductionRule{flatTax, fixed, garnishment},
+            gross:          1000,
+            supplemental:   true,
+            wantLines:      []line{{models.DeductionIncomeTax, 200}},
+            wantDeductions: 200,
+            wantNet:        800,
+        },
+        {
+            name:           "supplemental pay taxed at the brackets of the period's income",
+            rules:          []models.DeductionRule{bracketTax},
+            gross:          500,
+            supplemental:   true,
+            prior:          map[uint]float64{3: 800},
+            wantLines:      []line{{models.DeductionIncomeTax, 30}},
+            wantDeductions: 30,
+            wantNet:        470,
+        },
+        {
+            name:           "pension ceiling partly used by earlier pay of the period",
+            rules:          []models.DeductionRule{pension},
+            gross:          1000,
+            supplemental:   true,
+            prior:          map[uint]float64{1: 1500},
+            wantLines:      []line{{models.DeductionPension, 50}},
+            wantDeductions: 50,
+            wantNet:        950,
+            wantEmployer:   25,
+        },
+        {
+            name:           "pension ceiling used up by earlier pay of the period",
+            rules:          []models.DeductionRule{pension, flatTax},
+            gross:          1000,
+            supplemental:   true,
+            prior:          map[uint]float64{1: 2000, 2: 1800},
+            wantLines:      []line{{models.DeductionIncomeTax, 200}},
+            wantDeductions: 200,
+            wantNet:        800,
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            w := calculateDeductions(tt.rules, tt.advances, tt.gross, !tt.supplemental, tt.prior)
+            if len(w.Lines) != len(tt.wantLines) {
+                t.Fatalf("got %d lines %+v, want %d", len(w.Lines), w.Lines, len(tt.wantLines))
+            }
+            for i, want := range tt.wantLines {
+                if got := w.Lines[i]; got.Kind != want.kind || got.Amount != want.amount {
+                    t.Errorf("line %d = %s %v, want %s %v", i, got.Kind, got.Amount, want.kind, want.amount)
+                }
+            }
+            if w.Deductions != tt.wantDeductions || w.Net != tt.wantNet || w.EmployerContributions != tt.wantEmployer {
+                t.Errorf("deductions, net, employer = %v, %v, %v, want %v, %v, %v",
+                    w.Deductions, w.Net, w.EmployerContributions, tt.wantDeductions, tt.wantNet, tt.wantEmployer)
+            }
+        })
+    }
+}
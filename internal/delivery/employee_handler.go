@@ -0,0 +1,291 @@
+package delivery
+
+import (
+    "errors"
+    "fmt"
+    "net/http"
+    "strconv"
+    "time"
+
+    "github.com/dibsnvas/golang-2025/internal/models"
+    "github.com/gin-gonic/gin"
+    "golang.org/x/crypto/bcrypt"
+    "gorm.io/gorm"
+)
+
+const (
+    minApprovalCodeLength = 6
+    // После стольких неудачных попыток подряд код менеджера блокируется на approvalLockout
+    maxApprovalAttempts = 5
+    approvalLockout     = 15 * time.Minute
+)
+
+// errApprovalLocked is returned by checkManagerApproval while a manager's
+// code is locked after too many failed attempts.
+var errApprovalLocked = errors.New("too many failed approval attempts, try again later")
+
+type EmployeeHandler struct {
+    DB *gorm.DB
+}
+
+func NewEmployeeHandler(db *gorm.DB) *EmployeeHandler {
+    return &EmployeeHandler{DB: db}
+}
+
+type createEmployeeRequest struct {
+    Name    string  `json:"name"`
+    ShopID  uint    `json:"shop_id"`
+    Role    string  `json:"role"`     // cashier (по умолчанию) или manager
+    PayType string  `json:"pay_type"` // monthly, hourly или пусто
+    PayRate float64 `json:"pay_rate"` // оклад в месяц или ставка в час
+}
+
+func validPay(payType string, rate float64) bool {
+    if rate < 0 {
+        return false
+    }
+    return payType == "" || payType == models.PayTypeMonthly || payType == models.PayTypeHourly
+}
+
+// CreateEmployee registers a new employee
+// @Summary Create an employee
+// @Description Register an employee in a shop
+// @Tags Employees
+// @Accept json
+// @Produce json
+// @Param createEmployeeRequest body createEmployeeRequest true "Employee data"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /employees [post]
+func (h *EmployeeHandler) CreateEmployee(c *gin.Context) {
+    var req createEmployeeRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    if req.Name == "" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+        return
+    }
+
+    if req.Role == "" {
+        req.Role = models.EmployeeRoleCashier
+    }
+    if req.Role != models.EmployeeRoleCashier && req.Role != models.EmployeeRoleManager {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "role must be cashier or manager"})
+        return
+    }
+    if !validPay(req.PayType, req.PayRate) {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "pay_type must be monthly or hourly and pay_rate must not be negative"})
+        return
+    }
+
+    employee := models.Employee{
+        Name:    req.Name,
+        ShopID:  req.ShopID,
+        Role:    req.Role,
+        Active:  true,
+        PayType: req.PayType,
+        PayRate: req.PayRate,
+    }
+    if err := h.DB.Create(&employee).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusCreated, gin.H{"employee_id": employee.ID})
+}
+
+// GetEmployee returns an employee by ID
+// @Summary Get employee by ID
+// @Description Retrieve employee details
+// @Tags Employees
+// @Accept json
+// @Produce json
+// @Param id path int true "Employee ID"
+// @Success 200 {object} models.Employee
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /employees/{id} [get]
+func (h *EmployeeHandler) GetEmployee(c *gin.Context) {
+    id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+        return
+    }
+
+    var employee models.Employee
+    if err := h.DB.First(&employee, id).Error; err != nil {
+        if err == gorm.ErrRecordNotFound {
+            c.JSON(http.StatusNotFound, gin.H{"error": "employee not found"})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
+        return
+    }
+
+    c.JSON(http.StatusOK, employee)
+}
+
+// checkManagerApproval reports whether code is the approval code of an
+// active manager. Every attempt is counted before the code is compared, so
+// parallel requests cannot exceed maxApprovalAttempts; a correct code resets
+// the count. At the limit the code is locked for approvalLockout and the
+// function returns errApprovalLocked.
+func checkManagerApproval(db *gorm.DB, managerID uint, code string) (bool, error) {
+    var manager models.Employee
+    if err := db.Where("id = ?", managerID).Limit(1).Find(&manager).Error; err != nil {
+        return false, err
+    }
+    if manager.ID == 0 || !manager.Active || manager.Role != models.EmployeeRoleManager || manager.ApprovalCodeHash == "" {
+        return false, nil
+    }
+    now := time.Now()
+    if manager.ApprovalLockedUntil != nil && now.Before(*manager.ApprovalLockedUntil) {
+        return false, errApprovalLocked
+    }
+
+    res := db.Model(&models.Employee{}).Where("id = ? AND approval_failures < ?", manager.ID, maxApprovalAttempts).
+        Update("approval_failures", gorm.Expr("approval_failures + 1"))
+    if res.Error != nil {
+        return false, res.Error
+    }
+    if res.RowsAffected == 0 {
+        return false, errApprovalLocked
+    }
+
+    if bcrypt.CompareHashAndPassword([]byte(manager.ApprovalCodeHash), []byte(code)) == nil {
+        err := db.Model(&models.Employee{}).Where("id = ?", manager.ID).Update("approval_failures", 0).Error
+        return err == nil, err
+    }
+    err := db.Model(&models.Employee{}).Where("id = ? AND approval_failures >= ?", manager.ID, maxApprovalAttempts).
+        Updates(map[string]interface{}{"approval_failures": 0, "approval_locked_until": now.Add(approvalLockout)}).Error
+    return false, err
+}
+
+// approvalErrorStatus is the response status for an error of
+// checkManagerApproval.
+func approvalErrorStatus(err error) int {
+    if errors.Is(err, errApprovalLocked) {
+        return http.StatusTooManyRequests
+    }
+    return http.StatusInternalServerError
+}
+
+type setApprovalCodeRequest struct {
+    Code string `json:"code"`
+}
+
+// SetApprovalCode sets the approval code of a manager
+// @Summary Set manager approval code
+// @Description Set the code (at least 6 characters) a manager uses to approve overrides such as late sale voids. Only a bcrypt hash is stored. After 5 failed attempts in a row the code is locked for 15 minutes and approvals answer 429; setting a new code lifts the lock
+// @Tags Employees
+// @Accept json
+// @Produce json
+// @Param id path int true "Employee ID"
+// @Param setApprovalCodeRequest body setApprovalCodeRequest true "Approval code"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /employees/{id}/approval-code [put]
+func (h *EmployeeHandler) SetApprovalCode(c *gin.Context) {
+    id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+        return
+    }
+
+    var req setApprovalCodeRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    if len(req.Code) < minApprovalCodeLength {
+        c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("code must be at least %d characters", minApprovalCodeLength)})
+        return
+    }
+    hash, err := bcrypt.GenerateFromPassword([]byte(req.Code), bcrypt.DefaultCost)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    var employee models.Employee
+    if err := h.DB.First(&employee, id).Error; err != nil {
+        if err == gorm.ErrRecordNotFound {
+            c.JSON(http.StatusNotFound, gin.H{"error": "employee not found"})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
+        return
+    }
+    if employee.Role != models.EmployeeRoleManager {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "only managers have approval codes"})
+        return
+    }
+
+    err = h.DB.Model(&employee).Updates(map[string]interface{}{
+        "approval_code_hash":    string(hash),
+        "approval_failures":     0,
+        "approval_locked_until": nil,
+    }).Error
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"employee_id": employee.ID})
+}
+
+type setPayRequest struct {
+    PayType string  `json:"pay_type"`
+    PayRate float64 `json:"pay_rate"`
+}
+
+// SetPay sets how an employee is paid
+// @Summary Set employee pay
+// @Description Set the pay type (monthly salary or hourly) and rate used by payroll runs
+// @Tags Employees
+// @Accept json
+// @Produce json
+// @Param id path int true "Employee ID"
+// @Param setPayRequest body setPayRequest true "Pay type and rate"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /employees/{id}/pay [put]
+func (h *EmployeeHandler) SetPay(c *gin.Context) {
+    id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+        return
+    }
+
+    var req setPayRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    if !validPay(req.PayType, req.PayRate) {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "pay_type must be monthly or hourly and pay_rate must not be negative"})
+        return
+    }
+
+    res := h.DB.Model(&models.Employee{}).Where("id = ?", id).
+        Updates(map[string]interface{}{"pay_type": req.PayType, "pay_rate": req.PayRate})
+    if res.Error != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": res.Error.Error()})
+        return
+    }
+    if res.RowsAffected == 0 {
+        c.JSON(http.StatusNotFound, gin.H{"error": "employee not found"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"employee_id": id, "pay_type": req.PayType, "pay_rate": req.PayRate})
+}
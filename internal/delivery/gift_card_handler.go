@@ -0,0 +1,354 @@
+package delivery
+
+import (
+    "crypto/rand"
+    "errors"
+    "fmt"
+    "math/big"
+    "net/http"
+    "strings"
+
+    "github.com/dibsnvas/golang-2025/internal/models"
+    "github.com/gin-gonic/gin"
+    "github.com/jackc/pgx/v5/pgconn"
+    "gorm.io/gorm"
+    "gorm.io/gorm/clause"
+)
+
+const (
+    giftCardCodeLength = 16
+
+    // SQLSTATE unique_violation
+    pgUniqueViolation = "23505"
+)
+
+func isUniqueViolation(err error) bool {
+    var pgErr *pgconn.PgError
+    return errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation
+}
+
+type GiftCardHandler struct {
+    DB *gorm.DB
+}
+
+func NewGiftCardHandler(db *gorm.DB) *GiftCardHandler {
+    return &GiftCardHandler{DB: db}
+}
+
+type giftCardRequest struct {
+    Code   string  `json:"code"` // номер напечатанной карты; если пусто — генерируется
+    Amount float64 `json:"amount"`
+}
+
+type giftCardView struct {
+    Code    string  `json:"code"`
+    Kind    string  `json:"kind"`
+    Balance float64 `json:"balance"`
+}
+
+func newGiftCardCode() (string, error) {
+    var b strings.Builder
+    for i := 0; i < giftCardCodeLength; i++ {
+        n, err := rand.Int(rand.Reader, big.NewInt(10))
+        if err != nil {
+            return "", err
+        }
+        b.WriteByte(byte('0' + n.Int64()))
+    }
+    return b.String(), nil
+}
+
+// maskGiftCardCode keeps the last four characters; the code itself works as
+// a bearer token and is not stored on payments or printed.
+func maskGiftCardCode(code string) string {
+    if len(code) <= 4 {
+        return code
+    }
+    return strings.Repeat("*", len(code)-4) + code[len(code)-4:]
+}
+
+// isGiftCardLine reports whether a sale line sells a gift card. GiftCardID is
+// only set when the card is issued inside the sale's transaction, so lines of
+// a sale being built are recognised by how giftCardLine makes them.
+func isGiftCardLine(it models.SaleItem) bool {
+    return it.GiftCardID != nil || (it.ItemID == 0 && it.TaxCategory == models.GiftCardTaxCategory)
+}
+
+// giftCardLine is the sale line of a sold gift card. It is not taxed unless a
+// rate is configured for GiftCardTaxCategory and does not touch stock.
+func giftCardLine(gc giftCardRequest) models.SaleItem {
+    return models.SaleItem{
+        Quantity:      1,
+        OriginalPrice: gc.Amount,
+        PriceAtSale:   gc.Amount,
+        Category:      models.GiftCardKindGiftCard,
+        TaxCategory:   models.GiftCardTaxCategory,
+    }
+}
+
+func lockGiftCard(db *gorm.DB, code string) (*models.GiftCard, error) {
+    var card models.GiftCard
+    err := db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("code = ?", code).Limit(1).Find(&card).Error
+    if err != nil {
+        return nil, err
+    }
+    if card.ID == 0 {
+        return nil, nil
+    }
+    return &card, nil
+}
+
+func createGiftCard(db *gorm.DB, code, kind string, shopID uint, amount float64) (*models.GiftCard, error) {
+    if code == "" {
+        var err error
+        if code, err = newGiftCardCode(); err != nil {
+            return nil, err
+        }
+    } else {
+        var count int64
+        if err := db.Model(&models.GiftCard{}).Where("code = ?", code).Count(&count).Error; err != nil {
+            return nil, err
+        }
+        if count > 0 {
+            return nil, &saleError{Status: http.StatusConflict, Message: fmt.Sprintf("gift card %s is already issued", maskGiftCardCode(code))}
+        }
+    }
+
+    card := models.GiftCard{
+        Code:          code,
+        Kind:          kind,
+        ShopID:        shopID,
+        InitialAmount: amount,
+        Balance:       amount,
+        Active:        true,
+    }
+    if err := db.Create(&card).Error; err != nil {
+        // Параллельная продажа успела выпустить карту с тем же кодом после проверки
+        if isUniqueViolation(err) {
+            return nil, &saleError{Status: http.StatusConflict, Message: fmt.Sprintf("gift card %s is already issued", maskGiftCardCode(code))}
+        }
+        return nil, err
+    }
+    return &card, nil
+}
+
+// settleGiftCards runs inside the sale's database transaction, before the
+// sale is created. It issues the sold cards (the last len(sold) sale lines),
+// redeems gift card payments, and turns gift card refunds into store credit:
+// onto the card given as reference, or onto a new store credit card. Payment
+// references are replaced with masked codes. The returned movements still
+// need the transaction ID; see recordGiftCardMovements.
+func settleGiftCards(db *gorm.DB, tx *models.SalesTransaction, sold []giftCardRequest) ([]models.GiftCardMovement, error) {
+    var movements []models.GiftCardMovement
+
+    first := len(tx.SaleItems) - len(sold)
+    for i, gc := range sold {
+        card, err := createGiftCard(db, strings.TrimSpace(gc.Code), models.GiftCardKindGiftCard, tx.ShopID, gc.Amount)
+        if err != nil {
+            return nil, err
+        }
+        tx.SaleItems[first+i].GiftCardID = &card.ID
+        movements = append(movements, models.GiftCardMovement{
+            GiftCardID:   card.ID,
+            Type:         models.GiftCardMovementIssue,
+            Amount:       card.Balance,
+            BalanceAfter: card.Balance,
+        })
+    }
+
+    for i := range tx.Payments {
+        p := &tx.Payments[i]
+        if p.Method != models.PaymentMethodGiftCard {
+            continue
+        }
+        code := strings.TrimSpace(p.Reference)
+
+        // Возврат без указанной карты — выдаём новый возвратный кредит
+        if p.Amount < 0 && code == "" {
+            card, err := createGiftCard(db, "", models.GiftCardKindStoreCredit, tx.ShopID, -p.Amount)
+            if err != nil {
+                return nil, err
+            }
+            p.Reference = maskGiftCardCode(card.Code)
+            movements = append(movements, models.GiftCardMovement{
+                GiftCardID:   card.ID,
+                Type:         models.GiftCardMovementIssue,
+                Amount:       card.Balance,
+                BalanceAfter: card.Balance,
+            })
+            continue
+        }
+
+        if code == "" {
+            return nil, &saleError{Status: http.StatusBadRequest, Message: "gift card payments need the card code as reference"}
+        }
+        card, err := lockGiftCard(db, code)
+        if err != nil {
+            return nil, err
+        }
+        if card == nil || !card.Active {
+            return nil, &saleError{Status: http.StatusBadRequest, Message: fmt.Sprintf("gift card %s is unknown or inactive", maskGiftCardCode(code))}
+        }
+
+        movement := models.GiftCardMovement{GiftCardID: card.ID, Type: models.GiftCardMovementRedeem}
+        if p.Amount < 0 {
+            movement.Type = models.GiftCardMovementRefund
+        }
+        movement.Amount = -p.Amount
+        movement.BalanceAfter = roundMoney(card.Balance + movement.Amount)
+        if movement.BalanceAfter < 0 {
+            return nil, &saleError{Status: http.StatusUnprocessableEntity, Message: fmt.Sprintf("gift card %s balance is %.2f", maskGiftCardCode(code), card.Balance)}
+        }
+        if err := db.Model(card).Update("balance", movement.BalanceAfter).Error; err != nil {
+            return nil, err
+        }
+        p.Reference = maskGiftCardCode(code)
+        movements = append(movements, movement)
+    }
+
+    return movements, nil
+}
+
+// recordGiftCardMovements writes the ledger entries of a stored sale and
+// links the cards it issued.
+func recordGiftCardMovements(db *gorm.DB, txID uint, movements []models.GiftCardMovement) error {
+    if len(movements) == 0 {
+        return nil
+    }
+    var issued []uint
+    for i := range movements {
+        movements[i].TransactionID = &txID
+        if movements[i].Type == models.GiftCardMovementIssue {
+            issued = append(issued, movements[i].GiftCardID)
+        }
+    }
+    if err := db.Create(&movements).Error; err != nil {
+        return err
+    }
+    if len(issued) > 0 {
+        return db.Model(&models.GiftCard{}).Where("id IN ?", issued).Update("issued_transaction_id", txID).Error
+    }
+    return nil
+}
+
+// reverseGiftCards undoes the gift card movements of a voided sale: redeemed
+// amounts go back to the cards, cards issued by the sale are emptied and
+// deactivated. It fails with 409 if an issued card has already been spent.
+func reverseGiftCards(db *gorm.DB, txID uint) error {
+    var movements []models.GiftCardMovement
+    if err := db.Where("transaction_id = ? AND type <> ?", txID, models.GiftCardMovementVoid).
+        Order("id").Find(&movements).Error; err != nil {
+        return err
+    }
+
+    for _, m := range movements {
+        var card models.GiftCard
+        if err := db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&card, m.GiftCardID).Error; err != nil {
+            return err
+        }
+        balance := roundMoney(card.Balance - m.Amount)
+        if balance < 0 {
+            return &saleError{Status: http.StatusConflict, Message: fmt.Sprintf("gift card %s issued by this sale has already been used", maskGiftCardCode(card.Code))}
+        }
+
+        updates := map[string]interface{}{"balance": balance}
+        if m.Type == models.GiftCardMovementIssue {
+            updates["active"] = false
+        }
+        if err := db.Model(&card).Updates(updates).Error; err != nil {
+            return err
+        }
+        if err := db.Create(&models.GiftCardMovement{
+            GiftCardID:    card.ID,
+            TransactionID: &txID,
+            Type:          models.GiftCardMovementVoid,
+            Amount:        -m.Amount,
+            BalanceAfter:  balance,
+        }).Error; err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// issuedGiftCards returns the cards a sale issued, with their codes, so the
+// cashier can hand them over. Resent requests get the same cards.
+func issuedGiftCards(db *gorm.DB, txID uint) ([]giftCardView, error) {
+    var cards []models.GiftCard
+    if err := db.Where("issued_transaction_id = ?", txID).Order("id").Find(&cards).Error; err != nil {
+        return nil, err
+    }
+    views := make([]giftCardView, 0, len(cards))
+    for _, c := range cards {
+        views = append(views, giftCardView{Code: c.Code, Kind: c.Kind, Balance: c.Balance})
+    }
+    return views, nil
+}
+
+// GetGiftCard returns the balance of a gift card
+// @Summary Get gift card balance
+// @Description Look up a gift card or store credit by its code
+// @Tags Gift cards
+// @Accept json
+// @Produce json
+// @Param code path string true "Card code"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /gift-cards/{code} [get]
+func (h *GiftCardHandler) GetGiftCard(c *gin.Context) {
+    var card models.GiftCard
+    if err := h.DB.Where("code = ?", c.Param("code")).First(&card).Error; err != nil {
+        if err == gorm.ErrRecordNotFound {
+            c.JSON(http.StatusNotFound, gin.H{"error": "gift card not found"})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "code":           card.Code,
+        "kind":           card.Kind,
+        "balance":        card.Balance,
+        "initial_amount": card.InitialAmount,
+        "active":         card.Active,
+        "shop_id":        card.ShopID,
+        "issued_at":      card.CreatedAt,
+    })
+}
+
+// GetGiftCardMovements returns the ledger of a gift card
+// @Summary Get gift card movements
+// @Description List all balance changes of a gift card with the sales that caused them
+// @Tags Gift cards
+// @Accept json
+// @Produce json
+// @Param code path string true "Card code"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /gift-cards/{code}/movements [get]
+func (h *GiftCardHandler) GetGiftCardMovements(c *gin.Context) {
+    var card models.GiftCard
+    if err := h.DB.Where("code = ?", c.Param("code")).First(&card).Error; err != nil {
+        if err == gorm.ErrRecordNotFound {
+            c.JSON(http.StatusNotFound, gin.H{"error": "gift card not found"})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
+        return
+    }
+
+    var movements []models.GiftCardMovement
+    if err := h.DB.Where("gift_card_id = ?", card.ID).Order("id").Find(&movements).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "code":      card.Code,
+        "balance":   card.Balance,
+        "movements": movements,
+    })
+}
@@ -0,0 +1,79 @@
+package delivery
+
+import (
+    "io"
+    "net/http"
+    "strconv"
+    "strings"
+
+    "github.com/dibsnvas/golang-2025/internal/importer"
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+)
+
+type ImportHandler struct {
+    DB *gorm.DB
+}
+
+func NewImportHandler(db *gorm.DB) *ImportHandler {
+    return &ImportHandler{DB: db}
+}
+
+// ImportSales loads historical sales from CSV
+// @Summary Import historical sales from CSV
+// @Description Load past sales of a shop from its previous system. The CSV has one row per sale item; rows of a sale share external_id and must be consecutive. Required columns: external_id, transaction_time, employee_id, item_id, quantity, price; optional: type, payment_method, discount, category, tax_category, tax_rate. Send the file as multipart field "file" or as the raw request body. Sales with invalid or split rows are rejected with an error on each affected row; a row that is not valid CSV is reported on its own line and only fails the sale whose rows surround it; sales imported earlier are skipped. With dry_run=true nothing is written. Imported sales get no receipt numbers
+// @Tags Sales
+// @Accept text/csv
+// @Accept multipart/form-data
+// @Produce json
+// @Param shop_id query int true "Shop ID"
+// @Param dry_run query bool false "Only validate the file"
+// @Param chunk_size query int false "Sales per database transaction (default 1000)"
+// @Param file formData file false "CSV file"
+// @Success 200 {object} importer.Report
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /sales/import [post]
+func (h *ImportHandler) ImportSales(c *gin.Context) {
+    shopID, err := strconv.ParseUint(c.Query("shop_id"), 10, 64)
+    if err != nil || shopID == 0 {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid shop_id"})
+        return
+    }
+    opts := importer.Options{ShopID: uint(shopID), DryRun: c.Query("dry_run") == "true"}
+    if v := c.Query("chunk_size"); v != "" {
+        if opts.ChunkSize, err = strconv.Atoi(v); err != nil || opts.ChunkSize <= 0 {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "invalid chunk_size"})
+            return
+        }
+    }
+
+    var body io.Reader = c.Request.Body
+    if strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+        fh, err := c.FormFile("file")
+        if err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+            return
+        }
+        f, err := fh.Open()
+        if err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+            return
+        }
+        defer f.Close()
+        body = f
+    }
+
+    report, err := importer.ImportSales(c.Request.Context(), h.DB, body, opts)
+    if err != nil {
+        // Без отчёта — файл не удалось даже начать читать
+        if report == nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "report": report})
+        }
+        return
+    }
+
+    c.JSON(http.StatusOK, report)
+}
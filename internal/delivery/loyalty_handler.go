@@ -0,0 +1,331 @@
+package delivery
+
+import (
+    "fmt"
+    "log"
+    "math"
+    "net/http"
+    "os"
+    "strconv"
+
+    "github.com/dibsnvas/golang-2025/internal/models"
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+    "gorm.io/gorm/clause"
+)
+
+const defaultLoyaltyPointValue = 0.01
+
+// loyaltyPointValueFromEnv reads LOYALTY_POINT_VALUE, the money value of one
+// point when it is redeemed.
+func loyaltyPointValueFromEnv() float64 {
+    value := defaultLoyaltyPointValue
+    if v := os.Getenv("LOYALTY_POINT_VALUE"); v != "" {
+        if f, err := strconv.ParseFloat(v, 64); err == nil && f > 0 {
+            value = f
+        } else {
+            log.Printf("Invalid LOYALTY_POINT_VALUE %q, using %g", v, value)
+        }
+    }
+    return value
+}
+
+type LoyaltyHandler struct {
+    DB *gorm.DB
+}
+
+func NewLoyaltyHandler(db *gorm.DB) *LoyaltyHandler {
+    return &LoyaltyHandler{DB: db}
+}
+
+// bestLoyaltyRule returns the most specific active rule for a sale line:
+// item beats category beats any item, and a shop rule beats a global one.
+func bestLoyaltyRule(rules []models.LoyaltyRule, item models.SaleItem) *models.LoyaltyRule {
+    var best *models.LoyaltyRule
+    bestScore := -1
+    for i := range rules {
+        r := &rules[i]
+        if (r.ItemID != 0 && r.ItemID != item.ItemID) || (r.Category != "" && r.Category != item.Category) {
+            continue
+        }
+        score := 0
+        if r.ItemID != 0 {
+            score += 4
+        }
+        if r.Category != "" {
+            score += 2
+        }
+        if r.ShopID != 0 {
+            score++
+        }
+        if score > bestScore {
+            best, bestScore = r, score
+        }
+    }
+    return best
+}
+
+// earnedPoints applies the loyalty rules of the shop to the lines of a sale.
+// Returns give back the points their lines earned, so the result is negative
+// for them. Gift card lines earn nothing.
+func earnedPoints(db *gorm.DB, tx *models.SalesTransaction) (int, error) {
+    var rules []models.LoyaltyRule
+    if err := db.Where("active AND shop_id IN ?", []uint{0, tx.ShopID}).Find(&rules).Error; err != nil {
+        return 0, err
+    }
+
+    var points float64
+    for _, item := range tx.SaleItems {
+        if isGiftCardLine(item) {
+            continue
+        }
+        if r := bestLoyaltyRule(rules, item); r != nil {
+            points += lineRevenue(item) * r.PointsPerUnit
+        }
+    }
+    // Дробные баллы не начисляем, округляем к нулю
+    return int(math.Trunc(points + math.Copysign(1e-9, points))), nil
+}
+
+// applyPointsDiscount spreads a check discount paid with points over the
+// lines in proportion to their amounts, like a check promotion.
+func applyPointsDiscount(tx *models.SalesTransaction, discount float64) {
+    amounts := make([]float64, len(tx.SaleItems))
+    var total float64
+    for i, item := range tx.SaleItems {
+        amounts[i] = lineRevenue(item)
+        total += amounts[i]
+    }
+
+    remaining := discount
+    for i := range tx.SaleItems {
+        item := &tx.SaleItems[i]
+        share := remaining
+        if i < len(tx.SaleItems)-1 && total > 0 {
+            share = roundMoney(discount * amounts[i] / total)
+        }
+        remaining -= share
+        item.DiscountAmount = roundMoney(item.DiscountAmount + share)
+        if item.Quantity != 0 {
+            item.PriceAtSale = roundMoney((float64(item.Quantity)*item.OriginalPrice - item.DiscountAmount) / float64(item.Quantity))
+        }
+    }
+    tx.DiscountAmount = roundMoney(tx.DiscountAmount + discount)
+}
+
+// paymentPoints converts a loyalty_points payment to points. The amount must
+// be a whole number of points.
+func paymentPoints(amount, pointValue float64) (int, error) {
+    points := math.Round(amount / pointValue)
+    if math.Abs(points*pointValue-amount) >= 0.005 {
+        return 0, fmt.Errorf("loyalty_points payment must be a multiple of %.2f", pointValue)
+    }
+    return int(points), nil
+}
+
+// settleLoyalty runs inside the sale's database transaction. It locks the
+// customer, checks that the balance covers the redeemed points and books the
+// points redeemed (discount and loyalty_points payments) and earned. The
+// returned entries still need the transaction ID.
+func settleLoyalty(db *gorm.DB, tx *models.SalesTransaction, pointValue float64) ([]models.LoyaltyTransaction, error) {
+    if tx.CustomerID == nil {
+        return nil, nil
+    }
+
+    redeemed := tx.PointsRedeemed
+    for _, p := range tx.Payments {
+        if p.Method != models.PaymentMethodLoyaltyPoints {
+            continue
+        }
+        points, err := paymentPoints(p.Amount, pointValue)
+        if err != nil {
+            return nil, &saleError{Status: http.StatusBadRequest, Message: err.Error()}
+        }
+        redeemed += points
+    }
+    if redeemed == 0 && tx.PointsEarned == 0 {
+        return nil, nil
+    }
+
+    var customer models.Customer
+    if err := db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&customer, *tx.CustomerID).Error; err != nil {
+        return nil, err
+    }
+    if redeemed > 0 && customer.PointsBalance < redeemed {
+        return nil, &saleError{Status: http.StatusUnprocessableEntity, Message: fmt.Sprintf("customer has %d points, %d requested", customer.PointsBalance, redeemed)}
+    }
+
+    balance := customer.PointsBalance
+    var entries []models.LoyaltyTransaction
+    if redeemed != 0 {
+        balance -= redeemed
+        entries = append(entries, models.LoyaltyTransaction{
+            CustomerID:   customer.ID,
+            Type:         models.LoyaltyRedeem,
+            Points:       -redeemed,
+            BalanceAfter: balance,
+        })
+    }
+    if tx.PointsEarned != 0 {
+        balance += tx.PointsEarned
+        entries = append(entries, models.LoyaltyTransaction{
+            CustomerID:   customer.ID,
+            Type:         models.LoyaltyEarn,
+            Points:       tx.PointsEarned,
+            BalanceAfter: balance,
+        })
+    }
+
+    if err := db.Model(&customer).Update("points_balance", balance).Error; err != nil {
+        return nil, err
+    }
+    return entries, nil
+}
+
+func recordLoyaltyTransactions(db *gorm.DB, txID uint, entries []models.LoyaltyTransaction) error {
+    if len(entries) == 0 {
+        return nil
+    }
+    for i := range entries {
+        entries[i].TransactionID = &txID
+    }
+    return db.Create(&entries).Error
+}
+
+// reverseLoyalty books the opposite of every points entry of a voided sale.
+// The balance may go negative if earned points have been spent since.
+func reverseLoyalty(db *gorm.DB, txID uint) error {
+    var entries []models.LoyaltyTransaction
+    if err := db.Where("transaction_id = ? AND type <> ?", txID, models.LoyaltyVoid).
+        Order("id").Find(&entries).Error; err != nil {
+        return err
+    }
+
+    for _, e := range entries {
+        var customer models.Customer
+        if err := db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&customer, e.CustomerID).Error; err != nil {
+            return err
+        }
+        balance := customer.PointsBalance - e.Points
+        if err := db.Model(&customer).Update("points_balance", balance).Error; err != nil {
+            return err
+        }
+        if err := db.Create(&models.LoyaltyTransaction{
+            CustomerID:    customer.ID,
+            TransactionID: &txID,
+            Type:          models.LoyaltyVoid,
+            Points:        -e.Points,
+            BalanceAfter:  balance,
+        }).Error; err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+type loyaltyRuleRequest struct {
+    ShopID        uint    `json:"shop_id"`  // 0 — во всех магазинах
+    ItemID        uint    `json:"item_id"`  // 0 — любой товар
+    Category      string  `json:"category"` // пусто — любая категория
+    PointsPerUnit float64 `json:"points_per_unit"`
+}
+
+// CreateRule adds a loyalty accrual rule
+// @Summary Create a loyalty rule
+// @Description Award points_per_unit points per currency unit of line revenue. Rules can be limited to a shop, an item or a category; the most specific matching rule applies to each line
+// @Tags Loyalty
+// @Accept json
+// @Produce json
+// @Param loyaltyRuleRequest body loyaltyRuleRequest true "Rule data"
+// @Success 201 {object} models.LoyaltyRule
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /loyalty/rules [post]
+func (h *LoyaltyHandler) CreateRule(c *gin.Context) {
+    var req loyaltyRuleRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    if req.PointsPerUnit < 0 {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "points_per_unit must not be negative"})
+        return
+    }
+
+    rule := models.LoyaltyRule{
+        ShopID:        req.ShopID,
+        ItemID:        req.ItemID,
+        Category:      req.Category,
+        PointsPerUnit: req.PointsPerUnit,
+        Active:        true,
+    }
+    if err := h.DB.Create(&rule).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusCreated, rule)
+}
+
+// ListRules returns active loyalty rules
+// @Summary List loyalty rules
+// @Description List active loyalty rules, optionally only those that apply in a shop
+// @Tags Loyalty
+// @Accept json
+// @Produce json
+// @Param shop_id query int false "Shop ID"
+// @Success 200 {array} models.LoyaltyRule
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /loyalty/rules [get]
+func (h *LoyaltyHandler) ListRules(c *gin.Context) {
+    query := h.DB.Where("active")
+    if v := c.Query("shop_id"); v != "" {
+        shopID, err := strconv.ParseUint(v, 10, 64)
+        if err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "invalid shop_id"})
+            return
+        }
+        query = query.Where("shop_id IN ?", []uint64{0, shopID})
+    }
+
+    var rules []models.LoyaltyRule
+    if err := query.Order("id").Find(&rules).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, rules)
+}
+
+// DeleteRule deactivates a loyalty rule
+// @Summary Deactivate a loyalty rule
+// @Description Stop applying a loyalty rule to new sales
+// @Tags Loyalty
+// @Accept json
+// @Produce json
+// @Param id path int true "Rule ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /loyalty/rules/{id} [delete]
+func (h *LoyaltyHandler) DeleteRule(c *gin.Context) {
+    id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+        return
+    }
+
+    res := h.DB.Model(&models.LoyaltyRule{}).Where("id = ?", id).Update("active", false)
+    if res.Error != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": res.Error.Error()})
+        return
+    }
+    if res.RowsAffected == 0 {
+        c.JSON(http.StatusNotFound, gin.H{"error": "rule not found"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"rule_id": id, "active": false})
+}
@@ -0,0 +1,194 @@
+package delivery
+
+import (
+    "errors"
+    "fmt"
+    "math"
+    "net/http"
+    "strconv"
+
+    "github.com/dibsnvas/golang-2025/internal/models"
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+)
+
+type PaymentHandler struct {
+    DB *gorm.DB
+}
+
+func NewPaymentHandler(db *gorm.DB) *PaymentHandler {
+    return &PaymentHandler{DB: db}
+}
+
+type paymentRequest struct {
+    Method    string  `json:"method"`
+    Amount    float64 `json:"amount"`
+    Reference string  `json:"reference"` // номер авторизации карты и т.п.
+}
+
+// allowedPaymentMethods returns the payment methods configured for a shop,
+// or DefaultPaymentMethods if the shop has none.
+func allowedPaymentMethods(db *gorm.DB, shopID uint) ([]string, error) {
+    var rows []models.ShopPaymentMethod
+    if err := db.Where("shop_id = ?", shopID).Order("id").Find(&rows).Error; err != nil {
+        return nil, err
+    }
+    if len(rows) == 0 {
+        return models.DefaultPaymentMethods, nil
+    }
+
+    methods := make([]string, 0, len(rows))
+    for _, r := range rows {
+        methods = append(methods, r.Method)
+    }
+    return methods, nil
+}
+
+// buildPayments validates the tenders of a check against its total and the
+// allowed methods. Overpayment is returned as change and must be covered by
+// cash. It returns the payments and the value for
+// SalesTransaction.PaymentMethod. Errors are client errors.
+func buildPayments(allowed []string, reqs []paymentRequest, total float64) ([]models.SalePayment, string, error) {
+    isAllowed := map[string]bool{}
+    for _, m := range allowed {
+        isAllowed[m] = true
+    }
+
+    if len(reqs) == 0 {
+        return nil, "", errors.New("at least one payment is required")
+    }
+
+    var payments []models.SalePayment
+    var paid, cash float64
+    methods := map[string]bool{}
+    for _, p := range reqs {
+        if !models.IsPaymentMethod(p.Method) {
+            return nil, "", fmt.Errorf("unknown payment method %q", p.Method)
+        }
+        if !isAllowed[p.Method] {
+            return nil, "", fmt.Errorf("payment method %q is not accepted in this shop", p.Method)
+        }
+        // Для возврата суммы оплат отрицательные, знак должен совпадать с итогом
+        if (p.Amount < 0) != (total < 0) || (p.Amount == 0 && total != 0) {
+            return nil, "", errors.New("payment amounts must be non-zero and have the same sign as the total")
+        }
+        paid += p.Amount
+        if p.Method == models.PaymentMethodCash {
+            cash += p.Amount
+        }
+        methods[p.Method] = true
+        payments = append(payments, models.SalePayment{
+            Method:    p.Method,
+            Amount:    roundMoney(p.Amount),
+            Reference: p.Reference,
+        })
+    }
+
+    change := roundMoney(paid - total)
+    switch {
+    case math.Abs(change) < 0.005:
+    case total < 0 || change < 0:
+        return nil, "", fmt.Errorf("payments sum to %.2f, expected %.2f", roundMoney(paid), total)
+    case change > roundMoney(cash):
+        return nil, "", errors.New("change can only be given from cash payments")
+    default:
+        // Сдачу записываем на последнюю наличную оплату
+        for i := len(payments) - 1; i >= 0; i-- {
+            if payments[i].Method == models.PaymentMethodCash {
+                payments[i].ChangeGiven = change
+                break
+            }
+        }
+    }
+
+    method := models.PaymentMethodSplit
+    if len(methods) == 1 {
+        method = payments[0].Method
+    }
+    return payments, method, nil
+}
+
+// GetShopPaymentMethods returns payment methods accepted in a shop
+// @Summary Get shop payment methods
+// @Description List payment methods accepted in a shop; shops without configuration accept cash, card, gift_card and loyalty_points
+// @Tags Payments
+// @Accept json
+// @Produce json
+// @Param id path int true "Shop ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /shops/{id}/payment-methods [get]
+func (h *PaymentHandler) GetShopPaymentMethods(c *gin.Context) {
+    shopID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid shop id"})
+        return
+    }
+
+    methods, err := allowedPaymentMethods(h.DB, uint(shopID))
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"shop_id": shopID, "methods": methods})
+}
+
+type setPaymentMethodsRequest struct {
+    Methods []string `json:"methods"`
+}
+
+// SetShopPaymentMethods replaces the payment methods accepted in a shop
+// @Summary Set shop payment methods
+// @Description Replace the set of payment methods accepted in a shop. Methods must be among cash, card, gift_card and loyalty_points
+// @Tags Payments
+// @Accept json
+// @Produce json
+// @Param id path int true "Shop ID"
+// @Param setPaymentMethodsRequest body setPaymentMethodsRequest true "Payment methods"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /shops/{id}/payment-methods [put]
+func (h *PaymentHandler) SetShopPaymentMethods(c *gin.Context) {
+    shopID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid shop id"})
+        return
+    }
+
+    var req setPaymentMethodsRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    seen := map[string]bool{}
+    var rows []models.ShopPaymentMethod
+    for _, m := range req.Methods {
+        if !models.IsPaymentMethod(m) || seen[m] {
+            c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unknown or duplicate method %q", m)})
+            return
+        }
+        seen[m] = true
+        rows = append(rows, models.ShopPaymentMethod{ShopID: uint(shopID), Method: m})
+    }
+    if len(rows) == 0 {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "at least one method is required"})
+        return
+    }
+
+    err = h.DB.Transaction(func(db *gorm.DB) error {
+        if err := db.Where("shop_id = ?", shopID).Delete(&models.ShopPaymentMethod{}).Error; err != nil {
+            return err
+        }
+        return db.Create(&rows).Error
+    })
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"shop_id": shopID, "methods": req.Methods})
+}
@@ -0,0 +1,971 @@
+package delivery
+
+import (
+    "errors"
+    "fmt"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/dibsnvas/golang-2025/internal/models"
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+    "gorm.io/gorm/clause"
+)
+
+var (
+    errPayrollRunNotDraft    = errors.New("payroll run is not a draft")
+    errPayrollRunNotApproved = errors.New("payroll run is not approved")
+)
+
+// runConflictError lists employees that are already in a payroll run for an
+// overlapping period.
+type runConflictError struct {
+    EmployeeIDs []uint
+}
+
+func (e *runConflictError) Error() string {
+    return "employees are already in a payroll run for an overlapping period"
+}
+
+// checkRunOverlap returns a *runConflictError if any of the employees has a
+// line in a payroll run for a period overlapping start..end (inclusive).
+// Lines whose salary payment was reversed do not count.
+func checkRunOverlap(db *gorm.DB, employeeIDs []uint, start, end time.Time) error {
+    var taken []uint
+    if err := db.Model(&models.PayrollLine{}).
+        Joins("JOIN payroll_runs ON payroll_runs.id = payroll_lines.run_id").
+        Joins("LEFT JOIN salary_payments ON salary_payments.id = payroll_lines.salary_payment_id").
+        Where("payroll_lines.employee_id IN ? AND payroll_runs.period_start <= ? AND payroll_runs.period_end >= ?", employeeIDs, end, start).
+        Where("salary_payments.reversed_at IS NULL").
+        Distinct().Pluck("payroll_lines.employee_id", &taken).Error; err != nil {
+        return err
+    }
+    if len(taken) > 0 {
+        return &runConflictError{EmployeeIDs: taken}
+    }
+    return nil
+}
+
+type PayrollHandler struct {
+    DB *gorm.DB
+}
+
+func NewPayrollHandler(db *gorm.DB) *PayrollHandler {
+    return &PayrollHandler{DB: db}
+}
+
+type createPayrollRunRequest struct {
+    PeriodStart string `json:"period_start"` // YYYY-MM-DD
+    PeriodEnd   string `json:"period_end"`   // YYYY-MM-DD, включительно
+    ShopIDs     []uint `json:"shop_ids"`
+}
+
+type adjustPayrollLineRequest struct {
+    Adjustment float64 `json:"adjustment"` // заменяет прежнюю корректировку
+    Note       string  `json:"note"`
+}
+
+type approvePayrollRunRequest struct {
+    ManagerID    uint   `json:"manager_id"`
+    ApprovalCode string `json:"approval_code"`
+}
+
+type payPayrollRunRequest struct {
+    PaidAt string `json:"paid_at"` // YYYY-MM-DD; можно не указывать и взять time.Now()
+}
+
+type payrollTotals struct {
+    Employees             int     `json:"employees"`
+    BasePay               float64 `json:"base_pay"`
+    Overtime              float64 `json:"overtime"`
+    Commission            float64 `json:"commission"`
+    Adjustments           float64 `json:"adjustments"`
+    Bonuses               float64 `json:"bonuses"`
+    Corrections           float64 `json:"corrections"`
+    Total                 float64 `json:"total"` // брутто
+    Reimbursements        float64 `json:"reimbursements"`
+    Deductions            float64 `json:"deductions"`
+    Net                   float64 `json:"net"`
+    EmployerContributions float64 `json:"employer_contributions"`
+}
+
+func payrollRunTotals(lines []models.PayrollLine) payrollTotals {
+    t := payrollTotals{Employees: len(lines)}
+    for _, l := range lines {
+        t.BasePay += l.BasePay
+        t.Overtime += l.OvertimePay
+        t.Commission += l.Commission
+        t.Adjustments += l.Adjustment
+        t.Bonuses += l.Bonuses
+        t.Corrections += l.Corrections
+        t.Total += l.Amount
+        t.Reimbursements += l.Reimbursements
+        t.Deductions += l.Deductions
+        t.Net += l.NetAmount
+        t.EmployerContributions += l.EmployerContributions
+    }
+    t.BasePay = roundMoney(t.BasePay)
+    t.Overtime = roundMoney(t.Overtime)
+    t.Commission = roundMoney(t.Commission)
+    t.Adjustments = roundMoney(t.Adjustments)
+    t.Bonuses = roundMoney(t.Bonuses)
+    t.Corrections = roundMoney(t.Corrections)
+    t.Total = roundMoney(t.Total)
+    t.Reimbursements = roundMoney(t.Reimbursements)
+    t.Deductions = roundMoney(t.Deductions)
+    t.Net = roundMoney(t.Net)
+    t.EmployerContributions = roundMoney(t.EmployerContributions)
+    return t
+}
+
+// splitReversedLines separates the lines of a run whose salary payment has
+// since been reversed; their pay was cancelled and must not be counted or
+// transferred again.
+func splitReversedLines(db *gorm.DB, run *models.PayrollRun) (active, reversed []models.PayrollLine, err error) {
+    var ids []uint
+    if run.Status == models.PayrollRunPaid {
+        if err := db.Model(&models.SalaryPayment{}).Where("payroll_run_id = ? AND reversed_at IS NOT NULL", run.ID).
+            Pluck("id", &ids).Error; err != nil {
+            return nil, nil, err
+        }
+    }
+    isReversed := make(map[uint]bool, len(ids))
+    for _, id := range ids {
+        isReversed[id] = true
+    }
+    for _, l := range run.Lines {
+        if l.SalaryPaymentID != nil && isReversed[*l.SalaryPaymentID] {
+            reversed = append(reversed, l)
+        } else {
+            active = append(active, l)
+        }
+    }
+    return active, reversed, nil
+}
+
+// monthlyPay prorates a monthly salary over [from, to) by the share of days
+// covered in each calendar month.
+func monthlyPay(rate float64, from, to time.Time) float64 {
+    var pay float64
+    for month := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC); month.Before(to); month = month.AddDate(0, 1, 0) {
+        next := month.AddDate(0, 1, 0)
+        start, end := month, next
+        if start.Before(from) {
+            start = from
+        }
+        if end.After(to) {
+            end = to
+        }
+        days := end.Sub(start).Hours() / 24
+        pay += rate * days / (next.Sub(month).Hours() / 24)
+    }
+    return roundMoney(pay)
+}
+
+// workedHours sums the closed shifts of an employee inside [from, to) and
+// splits them into regular hours and overtime: hours above weeklyLimit in a
+// week (Monday to Sunday, by shift start) are overtime. Weeks cut by the
+// period boundaries only count their part inside the period.
+func workedHours(db *gorm.DB, employeeID uint, from, to time.Time, weeklyLimit float64) (regular, overtime float64, err error) {
+    var shifts []models.EmployeeAttendance
+    if err := db.Where("employee_id = ? AND clock_out IS NOT NULL AND clock_in < ? AND clock_out > ?", employeeID, to, from).
+        Find(&shifts).Error; err != nil {
+        return 0, 0, err
+    }
+
+    byWeek := map[string]float64{}
+    for _, a := range shifts {
+        start, end := workedInterval(a, from, to)
+        if end.After(start) {
+            year, week := start.ISOWeek()
+            byWeek[fmt.Sprintf("%d-%02d", year, week)] += end.Sub(start).Hours()
+        }
+    }
+    for _, hours := range byWeek {
+        if hours > weeklyLimit {
+            overtime += hours - weeklyLimit
+            hours = weeklyLimit
+        }
+        regular += hours
+    }
+    return roundMoney(regular), roundMoney(overtime), nil
+}
+
+// payrollLineAmount is the gross pay of a line.
+func payrollLineAmount(l models.PayrollLine) float64 {
+    return roundMoney(l.BasePay + l.OvertimePay + l.Commission + l.Adjustment + l.Bonuses + l.Corrections)
+}
+
+// withholdPayrollLine recomputes the gross amount of a line and the
+// deductions withheld from it for the pay period [periodStart, periodEnd],
+// including advance installments due by periodEnd. Reimbursements are added
+// to net pay untaxed. The new deduction lines are saved with the line; the
+// caller removes the old ones.
+func withholdPayrollLine(db *gorm.DB, line *models.PayrollLine, periodStart, periodEnd time.Time) error {
+    line.Amount = payrollLineAmount(*line)
+    w, err := computeWithholding(db, line.EmployeeID, line.Amount, true, periodStart, periodEnd)
+    if err != nil {
+        return err
+    }
+    line.Deductions = w.Deductions
+    line.NetAmount = roundMoney(w.Net + line.Reimbursements)
+    line.EmployerContributions = w.EmployerContributions
+    line.DeductionLines = w.Lines
+    return nil
+}
+
+// computePayrollLine prices one employee for the half-open period [from, to):
+// prorated monthly salary or worked hours with overtime, plus commission if a
+// plan is assigned and the approved adjustments, less the employee's
+// deductions.
+func computePayrollLine(db *gorm.DB, company *models.CompanySettings, employee models.Employee, from, to time.Time, adjustments []models.SalaryAdjustment) (models.PayrollLine, error) {
+    line := models.PayrollLine{
+        EmployeeID: employee.ID,
+        ShopID:     employee.ShopID,
+        PayType:    employee.PayType,
+        PayRate:    employee.PayRate,
+    }
+
+    switch employee.PayType {
+    case models.PayTypeMonthly:
+        line.BasePay = monthlyPay(employee.PayRate, from, to)
+    case models.PayTypeHourly:
+        regular, overtime, err := workedHours(db, employee.ID, from, to, company.OvertimeWeeklyHours)
+        if err != nil {
+            return line, err
+        }
+        line.Hours = regular
+        line.OvertimeHours = overtime
+        line.BasePay = roundMoney(regular * employee.PayRate)
+        line.OvertimePay = roundMoney(overtime * employee.PayRate * company.OvertimeMultiplier)
+    }
+
+    st, err := computeCommission(db, employee.ID, from, to)
+    switch {
+    case err == nil:
+        line.Commission = st.Commission
+    case err != errNoCommissionPlan:
+        return line, err
+    }
+
+    sums := sumAdjustments(adjustments)
+    line.Bonuses = sums.Bonuses
+    line.Corrections = sums.Corrections
+    line.Reimbursements = sums.Reimbursements
+
+    if err := withholdPayrollLine(db, &line, from, to.AddDate(0, 0, -1)); err != nil {
+        return line, err
+    }
+    return line, nil
+}
+
+func formatShopIDs(ids []uint) string {
+    parts := make([]string, len(ids))
+    for i, id := range ids {
+        parts[i] = strconv.FormatUint(uint64(id), 10)
+    }
+    return strings.Join(parts, ",")
+}
+
+// CreateRun creates a draft payroll run
+// @Summary Create a payroll run
+// @Description Create a draft payroll run for a pay period and a set of shops. A line is computed for every active employee of the shops: monthly salary prorated by days, or worked hours times the hourly rate with weekly overtime at the company overtime multiplier, plus commission for the period and the employee's approved salary adjustments effective by period_end that are not paid yet, less the deductions of the employee's deduction rules. Employees already in a run for an overlapping period are rejected with 409, unless their salary payment from that run was reversed; employees who already have a regular salary payment (not reversed) for an overlapping period are skipped and listed in skipped_employee_ids
+// @Tags Payroll
+// @Accept json
+// @Produce json
+// @Param createPayrollRunRequest body createPayrollRunRequest true "Run data"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /payroll/runs [post]
+func (h *PayrollHandler) CreateRun(c *gin.Context) {
+    var req createPayrollRunRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    start, err := time.Parse("2006-01-02", req.PeriodStart)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid period_start"})
+        return
+    }
+    end, err := time.Parse("2006-01-02", req.PeriodEnd)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid period_end"})
+        return
+    }
+    if end.Before(start) {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "period_end must not be before period_start"})
+        return
+    }
+    if len(req.ShopIDs) == 0 {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "shop_ids is required"})
+        return
+    }
+
+    var employees []models.Employee
+    if err := h.DB.Where("active AND shop_id IN ?", req.ShopIDs).Order("id").Find(&employees).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    if len(employees) == 0 {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "no active employees in these shops"})
+        return
+    }
+    ids := make([]uint, len(employees))
+    for i, e := range employees {
+        ids[i] = e.ID
+    }
+
+    // Один сотрудник не может попасть в две ведомости за пересекающиеся периоды;
+    // окончательно это проверяется в транзакции под блокировкой сотрудников
+    var conflict *runConflictError
+    if err := checkRunOverlap(h.DB, ids, start, end); err != nil {
+        if errors.As(err, &conflict) {
+            c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "employee_ids": conflict.EmployeeIDs})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
+        return
+    }
+
+    // Уже получившие регулярную выплату за пересекающийся период в ведомость не попадают
+    var paid []uint
+    if err := h.DB.Model(&models.SalaryPayment{}).
+        Where("employee_id IN ? AND payment_type = ? AND reversed_at IS NULL AND pay_period_start <= ? AND pay_period_end >= ?",
+            ids, models.SalaryPaymentRegular, end, start).
+        Distinct().Pluck("employee_id", &paid).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    if len(paid) > 0 {
+        skip := map[uint]bool{}
+        for _, id := range paid {
+            skip[id] = true
+        }
+        kept := employees[:0]
+        ids = ids[:0]
+        for _, e := range employees {
+            if !skip[e.ID] {
+                kept = append(kept, e)
+                ids = append(ids, e.ID)
+            }
+        }
+        employees = kept
+        if len(employees) == 0 {
+            c.JSON(http.StatusConflict, gin.H{"error": "all employees already have a regular payment for an overlapping period", "employee_ids": paid})
+            return
+        }
+    }
+
+    company, err := loadCompanySettings(h.DB)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    payable, err := payableAdjustments(h.DB, ids, end)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    adjustments := map[uint][]models.SalaryAdjustment{}
+    for _, a := range payable {
+        adjustments[a.EmployeeID] = append(adjustments[a.EmployeeID], a)
+    }
+
+    run := models.PayrollRun{
+        PeriodStart: start,
+        PeriodEnd:   end,
+        ShopIDs:     formatShopIDs(req.ShopIDs),
+        Status:      models.PayrollRunDraft,
+    }
+    for _, e := range employees {
+        line, err := computePayrollLine(h.DB, company, e, start, end.AddDate(0, 0, 1), adjustments[e.ID])
+        if err != nil {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+            return
+        }
+        run.Lines = append(run.Lines, line)
+    }
+    run.TotalAmount = payrollRunTotals(run.Lines).Total
+
+    // Корректировки закрепляются за строками, чтобы их не взяла другая выплата.
+    // Блокировка сотрудников не даёт параллельному запросу включить их в другую ведомость
+    err = h.DB.Transaction(func(db *gorm.DB) error {
+        var locked []models.Employee
+        if err := db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id IN ?", ids).Order("id").Find(&locked).Error; err != nil {
+            return err
+        }
+        if err := checkRunOverlap(db, ids, start, end); err != nil {
+            return err
+        }
+        if err := db.Create(&run).Error; err != nil {
+            return err
+        }
+        for _, l := range run.Lines {
+            if err := attachAdjustments(db, adjustments[l.EmployeeID], map[string]interface{}{"payroll_line_id": l.ID}); err != nil {
+                return err
+            }
+        }
+        return nil
+    })
+    if errors.As(err, &conflict) {
+        c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "employee_ids": conflict.EmployeeIDs})
+        return
+    }
+    if err != nil {
+        c.JSON(payrollErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusCreated, gin.H{
+        "run_id":               run.ID,
+        "status":               run.Status,
+        "totals":               payrollRunTotals(run.Lines),
+        "skipped_employee_ids": paid,
+    })
+}
+
+// loadRun writes 400/404/500 itself and returns nil on failure.
+func (h *PayrollHandler) loadRun(c *gin.Context) *models.PayrollRun {
+    id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+        return nil
+    }
+
+    var run models.PayrollRun
+    if err := h.DB.Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+        Preload("Lines.DeductionLines", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+        Preload("Lines.AdjustmentLines", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+        First(&run, id).Error; err != nil {
+        if err == gorm.ErrRecordNotFound {
+            c.JSON(http.StatusNotFound, gin.H{"error": "payroll run not found"})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
+        return nil
+    }
+    return &run
+}
+
+// GetRun returns a payroll run with its lines
+// @Summary Get payroll run
+// @Description Retrieve a payroll run, its lines with their deductions and totals. Lines whose salary payment was reversed are left out of totals and summed in reversed
+// @Tags Payroll
+// @Accept json
+// @Produce json
+// @Param id path int true "Run ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /payroll/runs/{id} [get]
+func (h *PayrollHandler) GetRun(c *gin.Context) {
+    run := h.loadRun(c)
+    if run == nil {
+        return
+    }
+    active, reversed, err := splitReversedLines(h.DB, run)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{
+        "run":      run,
+        "totals":   payrollRunTotals(active),
+        "reversed": payrollRunTotals(reversed),
+    })
+}
+
+// ListRuns returns payroll runs
+// @Summary List payroll runs
+// @Description List payroll runs, newest period first, optionally by status
+// @Tags Payroll
+// @Accept json
+// @Produce json
+// @Param status query string false "draft, approved or paid"
+// @Success 200 {array} models.PayrollRun
+// @Failure 500 {object} map[string]interface{}
+// @Router /payroll/runs [get]
+func (h *PayrollHandler) ListRuns(c *gin.Context) {
+    query := h.DB.Order("period_start DESC, id DESC")
+    if status := c.Query("status"); status != "" {
+        query = query.Where("status = ?", status)
+    }
+
+    var runs []models.PayrollRun
+    if err := query.Find(&runs).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, runs)
+}
+
+// lockDraftRun locks a run for a change of its lines.
+func lockDraftRun(db *gorm.DB, runID uint) (*models.PayrollRun, error) {
+    var run models.PayrollRun
+    if err := db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&run, runID).Error; err != nil {
+        return nil, err
+    }
+    if run.Status != models.PayrollRunDraft {
+        return nil, errPayrollRunNotDraft
+    }
+    return &run, nil
+}
+
+func updateRunTotal(db *gorm.DB, runID uint) error {
+    var total float64
+    if err := db.Model(&models.PayrollLine{}).Where("run_id = ?", runID).
+        Select("COALESCE(SUM(amount), 0)").Scan(&total).Error; err != nil {
+        return err
+    }
+    return db.Model(&models.PayrollRun{}).Where("id = ?", runID).Update("total_amount", roundMoney(total)).Error
+}
+
+func payrollErrorStatus(err error) int {
+    switch {
+    case err == gorm.ErrRecordNotFound:
+        return http.StatusNotFound
+    case err == errPayrollRunNotDraft, err == errPayrollRunNotApproved, err == errAdvanceBalanceChanged,
+        err == errAdjustmentsUnavailable, isExclusionViolation(err):
+        return http.StatusConflict
+    }
+    return http.StatusInternalServerError
+}
+
+// AdjustLine sets the manual adjustment of a payroll line
+// @Summary Adjust a payroll line
+// @Description Set a manual adjustment (bonus or correction, may be negative) on a line of a draft run. The line amount, its deductions and the run total are recomputed
+// @Tags Payroll
+// @Accept json
+// @Produce json
+// @Param id path int true "Run ID"
+// @Param line_id path int true "Line ID"
+// @Param adjustPayrollLineRequest body adjustPayrollLineRequest true "Adjustment"
+// @Success 200 {object} models.PayrollLine
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /payroll/runs/{id}/lines/{line_id} [put]
+func (h *PayrollHandler) AdjustLine(c *gin.Context) {
+    runID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+        return
+    }
+    lineID, err := strconv.ParseUint(c.Param("line_id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid line_id"})
+        return
+    }
+
+    var req adjustPayrollLineRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    var line models.PayrollLine
+    err = h.DB.Transaction(func(db *gorm.DB) error {
+        run, err := lockDraftRun(db, uint(runID))
+        if err != nil {
+            return err
+        }
+        if err := db.Where("id = ? AND run_id = ?", lineID, runID).First(&line).Error; err != nil {
+            return err
+        }
+        line.Adjustment = roundMoney(req.Adjustment)
+        line.AdjustmentNote = req.Note
+        if err := db.Where("payroll_line_id = ?", line.ID).Delete(&models.SalaryDeduction{}).Error; err != nil {
+            return err
+        }
+        if err := withholdPayrollLine(db, &line, run.PeriodStart, run.PeriodEnd); err != nil {
+            return err
+        }
+        if err := db.Save(&line).Error; err != nil {
+            return err
+        }
+        return updateRunTotal(db, uint(runID))
+    })
+    if err != nil {
+        c.JSON(payrollErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, line)
+}
+
+// RemoveLine removes an employee from a draft payroll run
+// @Summary Remove a payroll line
+// @Description Remove an employee from a draft run, e.g. to pay them separately. The line's salary adjustments go back to be paid later
+// @Tags Payroll
+// @Accept json
+// @Produce json
+// @Param id path int true "Run ID"
+// @Param line_id path int true "Line ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /payroll/runs/{id}/lines/{line_id} [delete]
+func (h *PayrollHandler) RemoveLine(c *gin.Context) {
+    runID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+        return
+    }
+    lineID, err := strconv.ParseUint(c.Param("line_id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid line_id"})
+        return
+    }
+
+    err = h.DB.Transaction(func(db *gorm.DB) error {
+        if _, err := lockDraftRun(db, uint(runID)); err != nil {
+            return err
+        }
+        lines := db.Model(&models.PayrollLine{}).Select("id").Where("id = ? AND run_id = ?", lineID, runID)
+        if err := db.Where("payroll_line_id IN (?)", lines).Delete(&models.SalaryDeduction{}).Error; err != nil {
+            return err
+        }
+        if err := detachAdjustments(db, lines); err != nil {
+            return err
+        }
+        res := db.Where("id = ? AND run_id = ?", lineID, runID).Delete(&models.PayrollLine{})
+        if res.Error != nil {
+            return res.Error
+        }
+        if res.RowsAffected == 0 {
+            return gorm.ErrRecordNotFound
+        }
+        return updateRunTotal(db, uint(runID))
+    })
+    if err != nil {
+        c.JSON(payrollErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"run_id": runID, "line_id": lineID})
+}
+
+// DeleteRun deletes a draft payroll run
+// @Summary Delete a draft payroll run
+// @Description Discard a draft run and its lines. Their salary adjustments go back to be paid later
+// @Tags Payroll
+// @Accept json
+// @Produce json
+// @Param id path int true "Run ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /payroll/runs/{id} [delete]
+func (h *PayrollHandler) DeleteRun(c *gin.Context) {
+    runID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+        return
+    }
+
+    err = h.DB.Transaction(func(db *gorm.DB) error {
+        run, err := lockDraftRun(db, uint(runID))
+        if err != nil {
+            return err
+        }
+        lines := db.Model(&models.PayrollLine{}).Select("id").Where("run_id = ?", run.ID)
+        if err := db.Where("payroll_line_id IN (?)", lines).Delete(&models.SalaryDeduction{}).Error; err != nil {
+            return err
+        }
+        if err := detachAdjustments(db, lines); err != nil {
+            return err
+        }
+        if err := db.Where("run_id = ?", run.ID).Delete(&models.PayrollLine{}).Error; err != nil {
+            return err
+        }
+        return db.Delete(run).Error
+    })
+    if err != nil {
+        c.JSON(payrollErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"run_id": runID})
+}
+
+// ApproveRun approves a draft payroll run
+// @Summary Approve a payroll run
+// @Description Approve a draft run with a manager approval code. Lines can no longer be changed. Runs with a negative line are rejected with 422
+// @Tags Payroll
+// @Accept json
+// @Produce json
+// @Param id path int true "Run ID"
+// @Param approvePayrollRunRequest body approvePayrollRunRequest true "Manager approval"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 422 {object} map[string]interface{}
+// @Failure 429 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /payroll/runs/{id}/approve [post]
+func (h *PayrollHandler) ApproveRun(c *gin.Context) {
+    var req approvePayrollRunRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    run := h.loadRun(c)
+    if run == nil {
+        return
+    }
+    if run.Status != models.PayrollRunDraft {
+        c.JSON(http.StatusConflict, gin.H{"error": errPayrollRunNotDraft.Error()})
+        return
+    }
+    if len(run.Lines) == 0 {
+        c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "payroll run has no lines"})
+        return
+    }
+    for _, l := range run.Lines {
+        if l.Amount < 0 {
+            c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "payroll run has negative lines", "line_id": l.ID})
+            return
+        }
+    }
+
+    ok, err := checkManagerApproval(h.DB, req.ManagerID, req.ApprovalCode)
+    if err != nil {
+        c.JSON(approvalErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+    if !ok {
+        c.JSON(http.StatusForbidden, gin.H{"error": "invalid manager approval"})
+        return
+    }
+
+    // Условие на статус защищает от одновременного изменения строк
+    now := time.Now()
+    res := h.DB.Model(&models.PayrollRun{}).
+        Where("id = ? AND status = ?", run.ID, models.PayrollRunDraft).
+        Updates(map[string]interface{}{
+            "status":      models.PayrollRunApproved,
+            "approved_by": req.ManagerID,
+            "approved_at": now,
+        })
+    if res.Error != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": res.Error.Error()})
+        return
+    }
+    if res.RowsAffected == 0 {
+        c.JSON(http.StatusConflict, gin.H{"error": errPayrollRunNotDraft.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "run_id":      run.ID,
+        "status":      models.PayrollRunApproved,
+        "approved_at": now,
+    })
+}
+
+// ReopenRun returns an approved payroll run to draft
+// @Summary Reopen a payroll run
+// @Description Return an approved run that is not paid yet to draft with a manager approval code, e.g. when paying it fails. Its lines can then be adjusted or removed, or the run deleted, and it must be approved again
+// @Tags Payroll
+// @Accept json
+// @Produce json
+// @Param id path int true "Run ID"
+// @Param approvePayrollRunRequest body approvePayrollRunRequest true "Manager approval"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 429 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /payroll/runs/{id}/reopen [post]
+func (h *PayrollHandler) ReopenRun(c *gin.Context) {
+    var req approvePayrollRunRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    run := h.loadRun(c)
+    if run == nil {
+        return
+    }
+    if run.Status != models.PayrollRunApproved {
+        c.JSON(http.StatusConflict, gin.H{"error": errPayrollRunNotApproved.Error()})
+        return
+    }
+
+    ok, err := checkManagerApproval(h.DB, req.ManagerID, req.ApprovalCode)
+    if err != nil {
+        c.JSON(approvalErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+    if !ok {
+        c.JSON(http.StatusForbidden, gin.H{"error": "invalid manager approval"})
+        return
+    }
+
+    // Условие на статус: ведомость могли успеть провести
+    res := h.DB.Model(&models.PayrollRun{}).
+        Where("id = ? AND status = ?", run.ID, models.PayrollRunApproved).
+        Updates(map[string]interface{}{
+            "status":      models.PayrollRunDraft,
+            "approved_by": nil,
+            "approved_at": nil,
+        })
+    if res.Error != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": res.Error.Error()})
+        return
+    }
+    if res.RowsAffected == 0 {
+        c.JSON(http.StatusConflict, gin.H{"error": errPayrollRunNotApproved.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "run_id": run.ID,
+        "status": models.PayrollRunDraft,
+    })
+}
+
+// PayRun marks an approved payroll run as paid
+// @Summary Pay a payroll run
+// @Description Mark an approved run as paid and record a regular salary payment for every line with a positive amount or reimbursements, with the line's gross, deductions, reimbursements, net pay and employer contributions; the line's salary adjustments are marked paid and advance installments withheld by the lines reduce the advance balances (an installment already recovered by another payment since the draft was computed is withheld only up to what is still owed). Adjustments of lines without pay go back to be paid later. The payments and the status change are written in one database transaction; if an employee already has a regular payment for an overlapping period nothing is written and 409 is returned
+// @Tags Payroll
+// @Accept json
+// @Produce json
+// @Param id path int true "Run ID"
+// @Param payPayrollRunRequest body payPayrollRunRequest false "Payment date"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /payroll/runs/{id}/pay [post]
+func (h *PayrollHandler) PayRun(c *gin.Context) {
+    var req payPayrollRunRequest
+    if c.Request.ContentLength != 0 {
+        if err := c.ShouldBindJSON(&req); err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+            return
+        }
+    }
+    paidAt := time.Now()
+    if req.PaidAt != "" {
+        var err error
+        paidAt, err = time.Parse("2006-01-02", req.PaidAt)
+        if err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "invalid paid_at"})
+            return
+        }
+    }
+
+    run := h.loadRun(c)
+    if run == nil {
+        return
+    }
+    if run.Status != models.PayrollRunApproved {
+        c.JSON(http.StatusConflict, gin.H{"error": errPayrollRunNotApproved.Error()})
+        return
+    }
+
+    var payments int
+    err := h.DB.Transaction(func(db *gorm.DB) error {
+        res := db.Model(&models.PayrollRun{}).
+            Where("id = ? AND status = ?", run.ID, models.PayrollRunApproved).
+            Updates(map[string]interface{}{"status": models.PayrollRunPaid, "paid_at": paidAt})
+        if res.Error != nil {
+            return res.Error
+        }
+        if res.RowsAffected == 0 {
+            return errPayrollRunNotApproved
+        }
+
+        for i := range run.Lines {
+            l := &run.Lines[i]
+            if l.Amount <= 0 && l.Reimbursements <= 0 {
+                if err := detachAdjustments(db, db.Model(&models.PayrollLine{}).Select("id").Where("id = ?", l.ID)); err != nil {
+                    return err
+                }
+                continue
+            }
+            // Взнос по авансу мог быть удержан другой выплатой после расчёта черновика
+            released, err := capAdvanceRecoveries(db, l.DeductionLines)
+            if err != nil {
+                return err
+            }
+            if released > 0 {
+                l.Deductions = roundMoney(l.Deductions - released)
+                l.NetAmount = roundMoney(l.NetAmount + released)
+                if err := db.Model(&models.PayrollLine{}).Where("id = ?", l.ID).
+                    Updates(map[string]interface{}{"deductions": l.Deductions, "net_amount": l.NetAmount}).Error; err != nil {
+                    return err
+                }
+            }
+            salary := models.SalaryPayment{
+                EmployeeID:            l.EmployeeID,
+                PayPeriodStart:        run.PeriodStart,
+                PayPeriodEnd:          run.PeriodEnd,
+                Amount:                l.Amount,
+                Deductions:            l.Deductions,
+                Reimbursements:        l.Reimbursements,
+                NetAmount:             l.NetAmount,
+                EmployerContributions: l.EmployerContributions,
+                PaidAt:                paidAt,
+                PayrollRunID:          &run.ID,
+                PaymentType:           models.SalaryPaymentRegular,
+            }
+            if err := db.Create(&salary).Error; err != nil {
+                return err
+            }
+            // Удержания посчитаны ещё в черновике, привязываем их к выплате
+            if err := db.Model(&models.SalaryDeduction{}).Where("payroll_line_id = ?", l.ID).
+                Update("salary_payment_id", salary.ID).Error; err != nil {
+                return err
+            }
+            if err := db.Model(&models.SalaryAdjustment{}).Where("payroll_line_id = ?", l.ID).
+                Updates(map[string]interface{}{"salary_payment_id": salary.ID, "status": models.AdjustmentPaid}).Error; err != nil {
+                return err
+            }
+            if err := recordAdvanceRecoveries(db, l.DeductionLines); err != nil {
+                return err
+            }
+            if err := db.Model(&models.PayrollLine{}).Where("id = ?", l.ID).
+                Update("salary_payment_id", salary.ID).Error; err != nil {
+                return err
+            }
+            payments++
+        }
+        return nil
+    })
+    if err != nil {
+        c.JSON(payrollErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "run_id":   run.ID,
+        "status":   models.PayrollRunPaid,
+        "paid_at":  paidAt,
+        "payments": payments,
+        "total":    run.TotalAmount,
+        "net":      payrollRunTotals(run.Lines).Net,
+    })
+}
@@ -0,0 +1,34 @@
+package delivery
+
+import (
+    "testing"
+    "time"
+)
+
+func TestMonthlyPay(t *testing.T) {
+    date := func(year int, month time.Month, day int) time.Time {
+        return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+    }
+    tests := []struct {
+        name     string
+        from, to time.Time
+        want     float64
+    }{
+        {"whole month", date(2025, time.January, 1), date(2025, time.February, 1), 3000},
+        {"whole february", date(2025, time.February, 1), date(2025, time.March, 1), 3000},
+        {"two whole months", date(2025, time.February, 1), date(2025, time.April, 1), 6000},
+        {"first half of a 30-day month", date(2025, time.April, 1), date(2025, time.April, 16), 1500},
+        {"single day", date(2025, time.April, 30), date(2025, time.May, 1), 100},
+        {"across two months", date(2025, time.January, 16), date(2025, time.February, 16), 3155.53},
+        {"leap february day", date(2024, time.February, 29), date(2024, time.March, 1), 103.45},
+        {"empty period", date(2025, time.April, 1), date(2025, time.April, 1), 0},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := monthlyPay(3000, tt.from, tt.to); got != tt.want {
+                t.Errorf("monthlyPay(3000, %s, %s) = %v, want %v",
+                    tt.from.Format("2006-01-02"), tt.to.Format("2006-01-02"), got, tt.want)
+            }
+        })
+    }
+}
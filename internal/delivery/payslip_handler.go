@@ -0,0 +1,401 @@
+package delivery
+
+import (
+    "bytes"
+    "fmt"
+    htmltemplate "html/template"
+    "net/http"
+    "strconv"
+    "strings"
+    "text/template"
+    "time"
+
+    "github.com/dibsnvas/golang-2025/internal/models"
+    "github.com/dibsnvas/golang-2025/internal/pdf"
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+)
+
+// Ширина расчётного листка в символах для PDF на листе A4
+const (
+    payslipWidth       = 80
+    payslipPDFFontSize = 10
+
+    payslipPasswordHeader = "X-Payslip-Password"
+)
+
+var (
+    payslipTextFuncs = receiptTextFuncs(payslipWidth)
+    payslipHTMLFuncs = receiptHTMLFuncs
+)
+
+type payslipLine struct {
+    Description string
+    Quantity    float64 // часы; 0 — без количества
+    Rate        float64
+    Amount      float64
+}
+
+// Detail is the description with quantity and rate, if any.
+func (l payslipLine) Detail() string {
+    if l.Quantity == 0 {
+        return l.Description
+    }
+    return fmt.Sprintf("%s  %.2f h x %s", l.Description, l.Quantity, money(l.Rate))
+}
+
+// payslipData is what payslip templates are executed with.
+type payslipData struct {
+    Company         models.CompanySettings
+    EmployeeID      uint
+    EmployeeName    string
+    PaymentID       uint
+    PaymentType     string
+    PeriodStart     time.Time
+    PeriodEnd       time.Time
+    PaidAt          time.Time
+    ReversedAt      *time.Time // выплата сторнирована
+    PayType         string
+    PayRate         float64
+    Earnings        []payslipLine
+    Gross           float64
+    Deductions      []payslipLine
+    TotalDeductions float64
+    // Возмещения расходов не облагаются и прибавляются к чистой сумме
+    Reimbursements      []payslipLine
+    TotalReimbursements float64
+    Net                 float64
+    // Взносы работодателя не удерживаются, показываются для справки
+    EmployerContributions float64
+    Year                  int
+    YTD                   payTotals
+}
+
+const defaultPayslipTextTemplate = `{{center .Company.Name}}
+{{if .Company.Address}}{{center .Company.Address}}
+{{end}}{{if .Company.TaxNumber}}{{center (print "Tax No: " .Company.TaxNumber)}}
+{{end}}{{sep}}
+{{center "PAYSLIP"}}
+{{line "Employee" .EmployeeName}}
+{{line "Employee ID" (print .EmployeeID)}}
+{{line "Pay period" (print (.PeriodStart.Format "2006-01-02") " - " (.PeriodEnd.Format "2006-01-02"))}}
+{{line "Paid on" (.PaidAt.Format "2006-01-02")}}
+{{line "Payment" (print "#" .PaymentID " (" .PaymentType ")")}}
+{{if .ReversedAt}}{{center (print "REVERSED ON " (.ReversedAt.Format "2006-01-02"))}}
+{{end}}{{sep}}
+EARNINGS
+{{range .Earnings}}{{line .Detail (money .Amount)}}
+{{end}}{{line "Gross pay" (money .Gross)}}
+{{sep}}
+DEDUCTIONS
+{{range .Deductions}}{{line .Detail (money .Amount)}}
+{{else}}{{line "None" "0.00"}}
+{{end}}{{line "Total deductions" (money .TotalDeductions)}}
+{{if .Reimbursements}}{{sep}}
+REIMBURSEMENTS
+{{range .Reimbursements}}{{line .Detail (money .Amount)}}
+{{end}}{{line "Total reimbursements" (money .TotalReimbursements)}}
+{{end}}{{sep}}
+{{line "NET PAY" (money .Net)}}
+{{if .EmployerContributions}}{{line "Employer contributions" (money .EmployerContributions)}}
+{{end}}{{sep}}
+{{line (print "Year to date " .Year) ""}}
+{{line "  Gross" (money .YTD.Gross)}}
+{{line "  Deductions" (money .YTD.Deductions)}}
+{{line "  Net" (money .YTD.Net)}}
+{{if .Company.PayslipFooter}}{{sep}}
+{{center .Company.PayslipFooter}}
+{{end}}`
+
+const defaultPayslipHTMLTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Payslip #{{.PaymentID}}</title>
+<style>
+body { font-family: sans-serif; max-width: 640px; margin: 0 auto; }
+.center { text-align: center; }
+table { width: 100%; border-collapse: collapse; margin-bottom: 1em; }
+th { text-align: left; border-bottom: 1px solid #000; }
+td.amount, th.amount { text-align: right; }
+.total td { font-weight: bold; border-top: 1px solid #000; }
+</style>
+</head>
+<body>
+<div class="center">
+<h2>{{.Company.Name}}</h2>
+{{if .Company.Address}}<div>{{.Company.Address}}</div>{{end}}
+{{if .Company.TaxNumber}}<div>Tax No: {{.Company.TaxNumber}}</div>{{end}}
+<h3>Payslip</h3>
+</div>
+<p>Employee: {{.EmployeeName}} (#{{.EmployeeID}})<br>
+Pay period: {{.PeriodStart.Format "2006-01-02"}} &ndash; {{.PeriodEnd.Format "2006-01-02"}}<br>
+Paid on: {{.PaidAt.Format "2006-01-02"}}<br>
+Payment #{{.PaymentID}} ({{.PaymentType}}){{if .ReversedAt}}<br>
+<strong>Reversed on {{.ReversedAt.Format "2006-01-02"}}</strong>{{end}}</p>
+<table>
+<tr><th>Earnings</th><th class="amount">Hours</th><th class="amount">Rate</th><th class="amount">Amount</th></tr>
+{{range .Earnings}}<tr><td>{{.Description}}</td><td class="amount">{{if .Quantity}}{{printf "%.2f" .Quantity}}{{end}}</td><td class="amount">{{if .Quantity}}{{money .Rate}}{{end}}</td><td class="amount">{{money .Amount}}</td></tr>
+{{end}}<tr class="total"><td colspan="3">Gross pay</td><td class="amount">{{money .Gross}}</td></tr>
+</table>
+<table>
+<tr><th>Deductions</th><th class="amount">Amount</th></tr>
+{{range .Deductions}}<tr><td>{{.Description}}</td><td class="amount">{{money .Amount}}</td></tr>
+{{else}}<tr><td>None</td><td class="amount">0.00</td></tr>
+{{end}}<tr class="total"><td>Total deductions</td><td class="amount">{{money .TotalDeductions}}</td></tr>
+</table>
+{{if .Reimbursements}}<table>
+<tr><th>Reimbursements</th><th class="amount">Amount</th></tr>
+{{range .Reimbursements}}<tr><td>{{.Description}}</td><td class="amount">{{money .Amount}}</td></tr>
+{{end}}<tr class="total"><td>Total reimbursements</td><td class="amount">{{money .TotalReimbursements}}</td></tr>
+</table>
+{{end}}<table>
+<tr class="total"><td>Net pay</td><td class="amount">{{money .Net}}</td></tr>
+{{if .EmployerContributions}}<tr><td>Employer contributions</td><td class="amount">{{money .EmployerContributions}}</td></tr>{{end}}
+</table>
+<table>
+<tr><th>Year to date {{.Year}}</th><th class="amount">Amount</th></tr>
+<tr><td>Gross</td><td class="amount">{{money .YTD.Gross}}</td></tr>
+<tr><td>Deductions</td><td class="amount">{{money .YTD.Deductions}}</td></tr>
+<tr><td>Net</td><td class="amount">{{money .YTD.Net}}</td></tr>
+</table>
+{{if .Company.PayslipFooter}}<p class="center">{{.Company.PayslipFooter}}</p>{{end}}
+</body>
+</html>
+`
+
+// loadPayslipData collects the breakdown of a salary payment. Payments made
+// by a payroll run are broken down by their payroll line; others show a
+// single earnings line. Salary adjustments paid with the payment are listed
+// as earnings or reimbursements.
+func loadPayslipData(db *gorm.DB, payment models.SalaryPayment) (*payslipData, error) {
+    company, err := loadCompanySettings(db)
+    if err != nil {
+        return nil, err
+    }
+
+    data := &payslipData{
+        Company:      *company,
+        EmployeeID:   payment.EmployeeID,
+        EmployeeName: fmt.Sprintf("#%d", payment.EmployeeID),
+        PaymentID:    payment.ID,
+        PaymentType:  payment.PaymentType,
+        PeriodStart:  payment.PayPeriodStart,
+        PeriodEnd:    payment.PayPeriodEnd,
+        PaidAt:       payment.PaidAt,
+        ReversedAt:   payment.ReversedAt,
+        Gross:        payment.Amount,
+        Net:          payment.NetAmount,
+        Year:         payment.PaidAt.Year(),
+
+        TotalDeductions:       payment.Deductions,
+        TotalReimbursements:   payment.Reimbursements,
+        EmployerContributions: payment.EmployerContributions,
+    }
+    if data.PaymentType == "" {
+        data.PaymentType = models.SalaryPaymentRegular
+    }
+
+    var employee models.Employee
+    if err := db.Where("id = ?", payment.EmployeeID).Limit(1).Find(&employee).Error; err != nil {
+        return nil, err
+    }
+    if employee.Name != "" {
+        data.EmployeeName = employee.Name
+    }
+    data.PayType, data.PayRate = employee.PayType, employee.PayRate
+
+    var adjustments []models.SalaryAdjustment
+    if err := db.Where("salary_payment_id = ?", payment.ID).Order("id").Find(&adjustments).Error; err != nil {
+        return nil, err
+    }
+    var earned []payslipLine
+    adjusted := 0.0
+    for _, a := range adjustments {
+        l := payslipLine{Description: adjustmentDescription(a), Amount: a.Amount}
+        if a.Type == models.AdjustmentReimbursement {
+            data.Reimbursements = append(data.Reimbursements, l)
+        } else {
+            earned = append(earned, l)
+            adjusted += a.Amount
+        }
+    }
+
+    var line models.PayrollLine
+    if payment.PayrollRunID != nil {
+        if err := db.Where("salary_payment_id = ?", payment.ID).Limit(1).Find(&line).Error; err != nil {
+            return nil, err
+        }
+    }
+    if line.ID != 0 {
+        data.PayType, data.PayRate = line.PayType, line.PayRate
+        switch line.PayType {
+        case models.PayTypeHourly:
+            data.Earnings = append(data.Earnings, payslipLine{Description: "Regular hours", Quantity: line.Hours, Rate: line.PayRate, Amount: line.BasePay})
+            if line.OvertimeHours != 0 {
+                data.Earnings = append(data.Earnings, payslipLine{
+                    Description: "Overtime",
+                    Quantity:    line.OvertimeHours,
+                    Rate:        roundMoney(line.OvertimePay / line.OvertimeHours),
+                    Amount:      line.OvertimePay,
+                })
+            }
+        case models.PayTypeMonthly:
+            data.Earnings = append(data.Earnings, payslipLine{Description: "Salary", Amount: line.BasePay})
+        }
+        if line.Commission != 0 {
+            data.Earnings = append(data.Earnings, payslipLine{Description: "Commission", Amount: line.Commission})
+        }
+        if line.Adjustment != 0 {
+            description := "Adjustment"
+            if line.AdjustmentNote != "" {
+                description += ": " + line.AdjustmentNote
+            }
+            data.Earnings = append(data.Earnings, payslipLine{Description: description, Amount: line.Adjustment})
+        }
+    } else if rest := roundMoney(payment.Amount - adjusted); rest != 0 || len(adjustments) == 0 {
+        description := "Salary"
+        switch {
+        case payment.PaymentType == models.SalaryPaymentSupplemental:
+            description = "Supplemental payment"
+        case payment.ReversalOfID != nil:
+            description = fmt.Sprintf("Reversal of payment #%d", *payment.ReversalOfID)
+        }
+        data.Earnings = append(data.Earnings, payslipLine{Description: description, Amount: rest})
+    }
+    data.Earnings = append(data.Earnings, earned...)
+    // Корректировки сторнированной выплаты освобождены, у сторно их нет: показываем суммы
+    if line.ID != 0 && len(adjustments) == 0 {
+        if line.Bonuses != 0 {
+            data.Earnings = append(data.Earnings, payslipLine{Description: "Bonuses", Amount: line.Bonuses})
+        }
+        if line.Corrections != 0 {
+            data.Earnings = append(data.Earnings, payslipLine{Description: "Corrections", Amount: line.Corrections})
+        }
+    }
+    if len(data.Reimbursements) == 0 && payment.Reimbursements != 0 {
+        data.Reimbursements = append(data.Reimbursements, payslipLine{Description: "Reimbursements", Amount: payment.Reimbursements})
+    }
+
+    var deductions []models.SalaryDeduction
+    if err := db.Where("salary_payment_id = ?", payment.ID).Order("id").Find(&deductions).Error; err != nil {
+        return nil, err
+    }
+    for _, d := range deductions {
+        if d.Amount != 0 {
+            data.Deductions = append(data.Deductions, payslipLine{Description: d.Description, Amount: d.Amount})
+        }
+    }
+
+    if data.YTD, err = yearToDate(db, payment.EmployeeID, payment.PaidAt); err != nil {
+        return nil, err
+    }
+    return data, nil
+}
+
+func renderPayslipText(data *payslipData) (string, error) {
+    src := defaultPayslipTextTemplate
+    if data.Company.PayslipTextTemplate != "" {
+        src = data.Company.PayslipTextTemplate
+    }
+    tmpl, err := template.New("payslip").Funcs(payslipTextFuncs).Parse(src)
+    if err != nil {
+        return "", err
+    }
+
+    var buf bytes.Buffer
+    if err := tmpl.Execute(&buf, data); err != nil {
+        return "", err
+    }
+    return buf.String(), nil
+}
+
+func renderPayslipHTML(data *payslipData) ([]byte, error) {
+    src := defaultPayslipHTMLTemplate
+    if data.Company.PayslipHTMLTemplate != "" {
+        src = data.Company.PayslipHTMLTemplate
+    }
+    tmpl, err := htmltemplate.New("payslip").Funcs(payslipHTMLFuncs).Parse(src)
+    if err != nil {
+        return nil, err
+    }
+
+    var buf bytes.Buffer
+    if err := tmpl.Execute(&buf, data); err != nil {
+        return nil, err
+    }
+    return buf.Bytes(), nil
+}
+
+// GetPayslip renders the payslip of a salary payment
+// @Summary Get payslip
+// @Description Render the payslip of a salary payment as HTML or PDF using the company templates: earnings (hours, rates, overtime, commission, adjustments), deductions, net pay and year-to-date totals. A PDF is password protected when the X-Payslip-Password header is set
+// @Tags Salary
+// @Produce html
+// @Produce application/pdf
+// @Param id path int true "Salary ID"
+// @Param format query string false "html (default) or pdf"
+// @Param X-Payslip-Password header string false "Password to open the PDF"
+// @Success 200 {string} string
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /salary/{id}/payslip [get]
+func (h *SalaryHandler) GetPayslip(c *gin.Context) {
+    id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+        return
+    }
+
+    format := c.DefaultQuery("format", "html")
+    if format != "html" && format != "pdf" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "format must be html or pdf"})
+        return
+    }
+
+    var payment models.SalaryPayment
+    if err := h.DB.First(&payment, id).Error; err != nil {
+        if err == gorm.ErrRecordNotFound {
+            c.JSON(http.StatusNotFound, gin.H{"error": "salary not found"})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
+        return
+    }
+
+    data, err := loadPayslipData(h.DB, payment)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    // Расчётные листки содержат персональные данные, не кэшируем
+    c.Header("Cache-Control", "no-store")
+
+    if format == "html" {
+        body, err := renderPayslipHTML(data)
+        if err != nil {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+            return
+        }
+        c.Data(http.StatusOK, "text/html; charset=utf-8", body)
+        return
+    }
+
+    text, err := renderPayslipText(data)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    body, err := pdf.Render(strings.Split(strings.TrimRight(text, "\n"), "\n"), pdf.Options{
+        FontSize:     payslipPDFFontSize,
+        UserPassword: c.GetHeader(payslipPasswordHeader),
+    })
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="payslip-%d.pdf"`, payment.ID))
+    c.Data(http.StatusOK, "application/pdf", body)
+}
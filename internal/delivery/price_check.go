@@ -0,0 +1,144 @@
+package delivery
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "math"
+    "net/http"
+    "os"
+    "strconv"
+    "time"
+
+    "github.com/dibsnvas/golang-2025/internal/catalog"
+    "github.com/dibsnvas/golang-2025/internal/models"
+)
+
+const (
+    priceMismatchReject = "reject"
+    priceMismatchFlag   = "flag"
+
+    defaultPriceTolerance = 0.01 // 1% от цены каталога
+    defaultPriceCacheTTL  = time.Minute
+)
+
+// PriceCheck compares client prices with the catalog.
+type PriceCheck struct {
+    Prices catalog.PriceLookup
+    // Tolerance — допустимое относительное отклонение от цены каталога
+    Tolerance float64
+    // Mode — reject (отклонить продажу) или flag (провести и пометить)
+    Mode string
+}
+
+// NewPriceCheckFromEnv caches prices from client and reads PRICE_TOLERANCE,
+// PRICE_CACHE_TTL and PRICE_MISMATCH_MODE.
+func NewPriceCheckFromEnv(client catalog.PriceLookup) *PriceCheck {
+    tolerance := defaultPriceTolerance
+    if v := os.Getenv("PRICE_TOLERANCE"); v != "" {
+        if f, err := strconv.ParseFloat(v, 64); err == nil && f >= 0 {
+            tolerance = f
+        } else {
+            log.Printf("Invalid PRICE_TOLERANCE %q, using %g", v, tolerance)
+        }
+    }
+
+    ttl := defaultPriceCacheTTL
+    if v := os.Getenv("PRICE_CACHE_TTL"); v != "" {
+        if d, err := time.ParseDuration(v); err == nil {
+            ttl = d
+        } else {
+            log.Printf("Invalid PRICE_CACHE_TTL %q, using %s", v, ttl)
+        }
+    }
+
+    mode := os.Getenv("PRICE_MISMATCH_MODE")
+    if mode == "" {
+        mode = priceMismatchReject
+    }
+    if mode != priceMismatchReject && mode != priceMismatchFlag {
+        log.Printf("Invalid PRICE_MISMATCH_MODE %q, using %s", mode, priceMismatchReject)
+        mode = priceMismatchReject
+    }
+
+    return &PriceCheck{
+        Prices:    catalog.NewCachedPrices(client, ttl),
+        Tolerance: tolerance,
+        Mode:      mode,
+    }
+}
+
+type priceMismatch struct {
+    ItemID       uint    `json:"item_id"`
+    Price        float64 `json:"price"`
+    CatalogPrice float64 `json:"catalog_price"`
+    UnknownItem  bool    `json:"unknown_item,omitempty"` // товара нет в каталоге, catalog_price не заполнена
+}
+
+// Apply looks up catalog prices for items and records them on the lines.
+// Mismatches beyond the tolerance and items the catalog does not know are
+// marked as overridden when overrideBy is set (the caller has already checked
+// the manager approval), flagged in flag mode and returned otherwise. If the
+// catalog is unavailable the lines are flagged in flag mode or with an
+// override, and a *saleError with 503 is returned in reject mode.
+func (p *PriceCheck) Apply(ctx context.Context, items []models.SaleItem, overrideBy *uint) ([]priceMismatch, error) {
+    if p == nil || p.Prices == nil || len(items) == 0 {
+        return nil, nil
+    }
+
+    ids := make([]uint, 0, len(items))
+    for _, it := range items {
+        ids = append(ids, it.ItemID)
+    }
+
+    prices, err := p.Prices.Prices(ctx, ids)
+    if err != nil {
+        if p.Mode != priceMismatchFlag && overrideBy == nil {
+            return nil, &saleError{Status: http.StatusServiceUnavailable, Message: fmt.Sprintf("catalog prices are unavailable (%v); a manager can approve the prices with price_override_by", err)}
+        }
+        log.Printf("Catalog price lookup failed, flagging sale: %v", err)
+        prices = nil
+    }
+
+    var mismatches []priceMismatch
+    for i := range items {
+        it := &items[i]
+        catalogPrice, ok := prices[it.ItemID]
+        if !ok {
+            // Без цены каталога строка всегда остаётся на проверку
+            it.PriceFlagged = true
+            if overrideBy != nil {
+                it.PriceOverrideBy = overrideBy
+            } else if p.Mode != priceMismatchFlag && err == nil {
+                mismatches = append(mismatches, priceMismatch{ItemID: it.ItemID, Price: it.OriginalPrice, UnknownItem: true})
+            }
+            continue
+        }
+        it.CatalogPrice = &catalogPrice
+
+        if p.withinTolerance(it.OriginalPrice, catalogPrice) {
+            continue
+        }
+        switch {
+        case overrideBy != nil:
+            it.PriceOverrideBy = overrideBy
+        case p.Mode == priceMismatchFlag:
+            it.PriceFlagged = true
+        default:
+            mismatches = append(mismatches, priceMismatch{
+                ItemID:       it.ItemID,
+                Price:        it.OriginalPrice,
+                CatalogPrice: catalogPrice,
+            })
+        }
+    }
+    return mismatches, nil
+}
+
+func (p *PriceCheck) withinTolerance(price, catalogPrice float64) bool {
+    diff := math.Abs(price - catalogPrice)
+    if diff < 0.005 {
+        return true
+    }
+    return catalogPrice > 0 && diff/catalogPrice <= p.Tolerance
+}
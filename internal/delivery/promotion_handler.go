@@ -0,0 +1,304 @@
+package delivery
+
+import (
+    "math"
+    "net/http"
+    "strconv"
+    "time"
+
+    "github.com/dibsnvas/golang-2025/internal/models"
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+)
+
+type PromotionHandler struct {
+    DB *gorm.DB
+}
+
+func NewPromotionHandler(db *gorm.DB) *PromotionHandler {
+    return &PromotionHandler{DB: db}
+}
+
+type createPromotionRequest struct {
+    Name          string  `json:"name"`
+    PromotionType string  `json:"promotion_type"` // percentage, fixed_amount, buy_x_get_y, bundle
+    Scope         string  `json:"scope"`          // line или check
+    ShopID        uint    `json:"shop_id"`
+    ItemID        uint    `json:"item_id"`
+    Value         float64 `json:"value"`
+    BuyQuantity   int     `json:"buy_quantity"`
+    FreeQuantity  int     `json:"free_quantity"`
+    MinSubtotal   float64 `json:"min_subtotal"`
+    ValidFrom     string  `json:"valid_from"` // "YYYY-MM-DD"
+    ValidTo       string  `json:"valid_to"`   // включительно
+}
+
+// CreatePromotion registers a new promotion
+// @Summary Create a promotion
+// @Description Create a line-level (percentage, fixed_amount, buy_x_get_y, bundle) or check-level (percentage, fixed_amount) promotion with a validity window and optional shop scope
+// @Tags Promotions
+// @Accept json
+// @Produce json
+// @Param createPromotionRequest body createPromotionRequest true "Promotion definition"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /promotions [post]
+func (h *PromotionHandler) CreatePromotion(c *gin.Context) {
+    var req createPromotionRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    validFrom, err := time.Parse("2006-01-02", req.ValidFrom)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid valid_from"})
+        return
+    }
+    validTo, err := time.Parse("2006-01-02", req.ValidTo)
+    if err != nil || validTo.Before(validFrom) {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid valid_to"})
+        return
+    }
+
+    if req.Scope == "" {
+        req.Scope = models.PromotionScopeLine
+    }
+    if req.Scope != models.PromotionScopeLine && req.Scope != models.PromotionScopeCheck {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "scope must be line or check"})
+        return
+    }
+    if req.Value < 0 || req.MinSubtotal < 0 {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "value and min_subtotal must not be negative"})
+        return
+    }
+
+    switch req.PromotionType {
+    case models.PromotionPercentage:
+        if req.Value > 100 {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "percentage value must be between 0 and 100"})
+            return
+        }
+    case models.PromotionFixedAmount:
+    case models.PromotionBuyXGetY, models.PromotionBundle:
+        if req.Scope != models.PromotionScopeLine {
+            c.JSON(http.StatusBadRequest, gin.H{"error": req.PromotionType + " promotions are line-level only"})
+            return
+        }
+        if req.BuyQuantity <= 0 || (req.PromotionType == models.PromotionBuyXGetY && req.FreeQuantity <= 0) {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "buy_quantity (and free_quantity for buy_x_get_y) must be positive"})
+            return
+        }
+    default:
+        c.JSON(http.StatusBadRequest, gin.H{"error": "promotion_type must be percentage, fixed_amount, buy_x_get_y or bundle"})
+        return
+    }
+
+    promo := models.Promotion{
+        Name:          req.Name,
+        PromotionType: req.PromotionType,
+        Scope:         req.Scope,
+        ShopID:        req.ShopID,
+        ItemID:        req.ItemID,
+        Value:         req.Value,
+        BuyQuantity:   req.BuyQuantity,
+        FreeQuantity:  req.FreeQuantity,
+        MinSubtotal:   req.MinSubtotal,
+        ValidFrom:     validFrom,
+        ValidTo:       validTo,
+        Active:        true,
+    }
+
+    if err := h.DB.Create(&promo).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusCreated, gin.H{"promotion_id": promo.ID})
+}
+
+// ListPromotions returns promotions active at a moment
+// @Summary List promotions
+// @Description List promotions valid at the given date (default: now), optionally only those applicable to a shop
+// @Tags Promotions
+// @Accept json
+// @Produce json
+// @Param shop_id query int false "Shop ID"
+// @Param date query string false "Date in YYYY-MM-DD format"
+// @Success 200 {array} models.Promotion
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /promotions [get]
+func (h *PromotionHandler) ListPromotions(c *gin.Context) {
+    at := time.Now()
+    if v := c.Query("date"); v != "" {
+        var err error
+        at, err = time.Parse("2006-01-02", v)
+        if err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date format, use YYYY-MM-DD"})
+            return
+        }
+    }
+
+    query := h.DB.Where("active AND valid_from <= ? AND ? < valid_to + interval '1 day'", at, at)
+    if v := c.Query("shop_id"); v != "" {
+        shopID, err := strconv.ParseUint(v, 10, 64)
+        if err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "invalid shop_id"})
+            return
+        }
+        query = query.Where("shop_id IN ?", []uint64{0, shopID})
+    }
+
+    var promos []models.Promotion
+    if err := query.Order("id").Find(&promos).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, promos)
+}
+
+// GetPromotion returns a promotion by ID
+// @Summary Get promotion by ID
+// @Description Retrieve a promotion definition
+// @Tags Promotions
+// @Accept json
+// @Produce json
+// @Param id path int true "Promotion ID"
+// @Success 200 {object} models.Promotion
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /promotions/{id} [get]
+func (h *PromotionHandler) GetPromotion(c *gin.Context) {
+    id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+        return
+    }
+
+    var promo models.Promotion
+    if err := h.DB.First(&promo, id).Error; err != nil {
+        if err == gorm.ErrRecordNotFound {
+            c.JSON(http.StatusNotFound, gin.H{"error": "promotion not found"})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
+        return
+    }
+
+    c.JSON(http.StatusOK, promo)
+}
+
+// lineDiscount is the discount a line-level promotion gives on quantity units
+// of price each.
+func lineDiscount(p models.Promotion, quantity int, price float64) float64 {
+    amount := float64(quantity) * price
+    var d float64
+    switch p.PromotionType {
+    case models.PromotionPercentage:
+        d = amount * p.Value / 100
+    case models.PromotionFixedAmount:
+        d = float64(quantity) * p.Value
+    case models.PromotionBuyXGetY:
+        free := quantity / (p.BuyQuantity + p.FreeQuantity) * p.FreeQuantity
+        d = float64(free) * price
+    case models.PromotionBundle:
+        bundles := quantity / p.BuyQuantity
+        d = float64(bundles) * (float64(p.BuyQuantity)*price - p.Value)
+    }
+    return roundMoney(math.Max(0, math.Min(d, amount)))
+}
+
+// applyPromotions picks the best line promotion for each item and then the
+// best check promotion for the remaining subtotal. The check discount is
+// spread over the lines in proportion to their amounts so every SaleItem
+// carries its full discount. Items must have OriginalPrice and Quantity set.
+func applyPromotions(db *gorm.DB, tx *models.SalesTransaction) error {
+    var promos []models.Promotion
+    if err := db.Where(
+        "active AND shop_id IN ? AND valid_from <= ? AND ? < valid_to + interval '1 day'",
+        []uint{0, tx.ShopID}, tx.TransactionTime, tx.TransactionTime,
+    ).Order("id").Find(&promos).Error; err != nil {
+        return err
+    }
+
+    lineAmounts := make([]float64, len(tx.SaleItems))
+    var afterLines float64
+    for i := range tx.SaleItems {
+        item := &tx.SaleItems[i]
+        var best float64
+        for _, p := range promos {
+            if p.Scope != models.PromotionScopeLine || (p.ItemID != 0 && p.ItemID != item.ItemID) {
+                continue
+            }
+            if d := lineDiscount(p, item.Quantity, item.OriginalPrice); d > best {
+                best = d
+                id := p.ID
+                item.PromotionID = &id
+            }
+        }
+        item.DiscountAmount = best
+        lineAmounts[i] = roundMoney(float64(item.Quantity)*item.OriginalPrice - best)
+        afterLines += lineAmounts[i]
+    }
+
+    var checkDiscount float64
+    for _, p := range promos {
+        if p.Scope != models.PromotionScopeCheck || afterLines < p.MinSubtotal {
+            continue
+        }
+        var d float64
+        switch p.PromotionType {
+        case models.PromotionPercentage:
+            d = afterLines * p.Value / 100
+        case models.PromotionFixedAmount:
+            d = p.Value
+        }
+        d = roundMoney(math.Min(d, afterLines))
+        if d > checkDiscount {
+            checkDiscount = d
+            id := p.ID
+            tx.PromotionID = &id
+        }
+    }
+
+    // Распределяем скидку на чек по строкам с положительной суммой, остаток
+    // округления — на самую крупную из них
+    largest := -1
+    var positive float64
+    for i, a := range lineAmounts {
+        if a <= 0 {
+            continue
+        }
+        positive += a
+        if largest < 0 || a > lineAmounts[largest] {
+            largest = i
+        }
+    }
+    shares := make([]float64, len(tx.SaleItems))
+    if checkDiscount > 0 && largest >= 0 {
+        remaining := checkDiscount
+        for i, a := range lineAmounts {
+            if a > 0 && i != largest {
+                shares[i] = roundMoney(checkDiscount * a / positive)
+                remaining -= shares[i]
+            }
+        }
+        shares[largest] = roundMoney(remaining)
+    }
+
+    for i := range tx.SaleItems {
+        item := &tx.SaleItems[i]
+        item.DiscountAmount = roundMoney(item.DiscountAmount + shares[i])
+        if item.Quantity != 0 {
+            item.PriceAtSale = roundMoney((float64(item.Quantity)*item.OriginalPrice - item.DiscountAmount) / float64(item.Quantity))
+        }
+        tx.DiscountAmount += item.DiscountAmount
+    }
+    tx.DiscountAmount = roundMoney(tx.DiscountAmount)
+
+    return nil
+}
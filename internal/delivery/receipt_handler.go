@@ -0,0 +1,468 @@
+package delivery
+
+import (
+    "bytes"
+    "fmt"
+    htmltemplate "html/template"
+    "net/http"
+    "strconv"
+    "strings"
+    "text/template"
+    "time"
+    "unicode/utf8"
+
+    "github.com/dibsnvas/golang-2025/internal/models"
+    "github.com/dibsnvas/golang-2025/internal/pdf"
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+    "gorm.io/gorm/clause"
+)
+
+// Ширина чека в символах: 42 — типовая 80-мм лента ESC/POS, 32 — 58-мм
+const (
+    receiptDefaultWidth = 42
+    receiptMinWidth     = 24
+    receiptMaxWidth     = 80
+    receiptPDFFontSize  = 9
+)
+
+type ReceiptHandler struct {
+    DB *gorm.DB
+}
+
+func NewReceiptHandler(db *gorm.DB) *ReceiptHandler {
+    return &ReceiptHandler{DB: db}
+}
+
+type receiptLine struct {
+    Description string
+    Quantity    int
+    UnitPrice   float64
+    Amount      float64 // до скидки
+    Discount    float64
+    TaxRate     float64
+}
+
+type receiptTax struct {
+    Rate  float64
+    Net   float64
+    Tax   float64
+    Gross float64
+}
+
+type receiptPayment struct {
+    Method    string
+    Amount    float64
+    Reference string
+    Change    float64
+}
+
+// receiptData is what receipt templates are executed with.
+type receiptData struct {
+    Shop          models.Shop
+    Cashier       string
+    ReceiptNumber string
+    Time          time.Time
+    IsReturn      bool
+    IsVoided      bool
+    Lines         []receiptLine
+    Subtotal      float64
+    Discount      float64
+    Taxes         []receiptTax
+    TaxIncluded   bool
+    Total         float64
+    Payments      []receiptPayment
+    Change        float64
+}
+
+func money(v float64) string {
+    return fmt.Sprintf("%.2f", v)
+}
+
+func neg(v float64) float64 {
+    return -v
+}
+
+// receiptTextFuncs are the helpers available in text receipt templates; they
+// lay text out in columns of the given width.
+func receiptTextFuncs(width int) template.FuncMap {
+    return template.FuncMap{
+        "money": money,
+        "neg":   neg,
+        "sep": func() string {
+            return strings.Repeat("-", width)
+        },
+        "center": func(s string) string {
+            n := utf8.RuneCountInString(s)
+            if n >= width {
+                return truncate(s, width)
+            }
+            return strings.Repeat(" ", (width-n)/2) + s
+        },
+        "line": func(left, right string) string {
+            left = truncate(left, width-utf8.RuneCountInString(right)-1)
+            pad := width - utf8.RuneCountInString(left) - utf8.RuneCountInString(right)
+            if pad < 1 {
+                pad = 1
+            }
+            return left + strings.Repeat(" ", pad) + right
+        },
+    }
+}
+
+var receiptHTMLFuncs = htmltemplate.FuncMap{
+    "money": money,
+    "neg":   neg,
+}
+
+func truncate(s string, n int) string {
+    if n <= 0 {
+        return ""
+    }
+    if utf8.RuneCountInString(s) <= n {
+        return s
+    }
+    return string([]rune(s)[:n])
+}
+
+const defaultReceiptTextTemplate = `{{center .Shop.Name}}
+{{if .Shop.Address}}{{center .Shop.Address}}
+{{end}}{{if .Shop.Phone}}{{center .Shop.Phone}}
+{{end}}{{if .Shop.TaxNumber}}{{center (print "Tax No: " .Shop.TaxNumber)}}
+{{end}}{{if .Shop.ReceiptHeader}}{{center .Shop.ReceiptHeader}}
+{{end}}{{sep}}
+{{line (print "Receipt " .ReceiptNumber) (.Time.Format "2006-01-02 15:04")}}
+{{line "Cashier" .Cashier}}
+{{if .IsReturn}}{{center "*** RETURN ***"}}
+{{end}}{{if .IsVoided}}{{center "*** VOIDED ***"}}
+{{end}}{{sep}}
+{{range .Lines}}{{.Description}}
+{{line (printf "  %d x %s" .Quantity (money .UnitPrice)) (money .Amount)}}
+{{if .Discount}}{{line "  Discount" (money (neg .Discount))}}
+{{end}}{{end}}{{sep}}
+{{line "Subtotal" (money .Subtotal)}}
+{{if .Discount}}{{line "Discount" (money (neg .Discount))}}
+{{end}}{{$incl := .TaxIncluded}}{{range .Taxes}}{{line (printf "%s %.2f%%" (or (and $incl "incl. tax") "Tax") .Rate) (money .Tax)}}
+{{end}}{{line "TOTAL" (money .Total)}}
+{{sep}}
+{{range .Payments}}{{line .Method (money .Amount)}}
+{{end}}{{if .Change}}{{line "Change" (money .Change)}}
+{{end}}{{if .Shop.ReceiptFooter}}{{sep}}
+{{center .Shop.ReceiptFooter}}
+{{end}}`
+
+const defaultReceiptHTMLTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Receipt {{.ReceiptNumber}}</title>
+<style>
+body { font-family: monospace; max-width: 360px; margin: 0 auto; }
+.center { text-align: center; }
+table { width: 100%; border-collapse: collapse; }
+td.amount { text-align: right; }
+.total td { font-weight: bold; border-top: 1px dashed #000; }
+</style>
+</head>
+<body>
+<div class="center">
+<h3>{{.Shop.Name}}</h3>
+{{if .Shop.Address}}<div>{{.Shop.Address}}</div>{{end}}
+{{if .Shop.Phone}}<div>{{.Shop.Phone}}</div>{{end}}
+{{if .Shop.TaxNumber}}<div>Tax No: {{.Shop.TaxNumber}}</div>{{end}}
+{{if .Shop.ReceiptHeader}}<div>{{.Shop.ReceiptHeader}}</div>{{end}}
+</div>
+<p>Receipt {{.ReceiptNumber}}<br>{{.Time.Format "2006-01-02 15:04"}}<br>Cashier: {{.Cashier}}</p>
+{{if .IsReturn}}<p class="center"><strong>RETURN</strong></p>{{end}}
+{{if .IsVoided}}<p class="center"><strong>VOIDED</strong></p>{{end}}
+<table>
+{{range .Lines}}<tr><td>{{.Description}}<br>{{.Quantity}} x {{money .UnitPrice}}</td><td class="amount">{{money .Amount}}</td></tr>
+{{if .Discount}}<tr><td>Discount</td><td class="amount">{{money (neg .Discount)}}</td></tr>{{end}}
+{{end}}<tr><td>Subtotal</td><td class="amount">{{money .Subtotal}}</td></tr>
+{{if .Discount}}<tr><td>Discount</td><td class="amount">{{money (neg .Discount)}}</td></tr>{{end}}
+{{$incl := .TaxIncluded}}{{range .Taxes}}<tr><td>{{if $incl}}incl. tax{{else}}Tax{{end}} {{printf "%.2f" .Rate}}%</td><td class="amount">{{money .Tax}}</td></tr>
+{{end}}<tr class="total"><td>TOTAL</td><td class="amount">{{money .Total}}</td></tr>
+{{range .Payments}}<tr><td>{{.Method}}{{if .Reference}} ({{.Reference}}){{end}}</td><td class="amount">{{money .Amount}}</td></tr>
+{{end}}{{if .Change}}<tr><td>Change</td><td class="amount">{{money .Change}}</td></tr>{{end}}
+</table>
+{{if .Shop.ReceiptFooter}}<p class="center">{{.Shop.ReceiptFooter}}</p>{{end}}
+</body>
+</html>
+`
+
+const defaultReceiptPrefix = "{SHOP}-{YYYY}-"
+
+func formatReceiptNumber(prefix string, shopID uint, year, seq int) string {
+    if prefix == "" {
+        prefix = defaultReceiptPrefix
+    }
+    prefix = strings.NewReplacer(
+        "{SHOP}", strconv.FormatUint(uint64(shopID), 10),
+        "{YYYY}", fmt.Sprintf("%04d", year),
+        "{YY}", fmt.Sprintf("%02d", year%100),
+    ).Replace(prefix)
+    return fmt.Sprintf("%s%06d", prefix, seq)
+}
+
+// allocateReceiptNumber assigns the next receipt number of the shop to tx.
+// It must run inside the database transaction that creates the sale: the
+// counter row stays locked until commit, so concurrent sales wait for each
+// other, and a rollback releases the number, which keeps the sequence free of
+// gaps.
+func allocateReceiptNumber(db *gorm.DB, tx *models.SalesTransaction) error {
+    var shop models.Shop
+    if err := db.Where("id = ?", tx.ShopID).Limit(1).Find(&shop).Error; err != nil {
+        return err
+    }
+
+    year := tx.TransactionTime.Year()
+    seqYear := year
+    if shop.ReceiptContinuous {
+        seqYear = 0
+    }
+
+    // Создаём счётчик при первой продаже в году и блокируем его строку
+    if err := db.Clauses(clause.OnConflict{DoNothing: true}).
+        Create(&models.ReceiptSequence{ShopID: tx.ShopID, FiscalYear: seqYear}).Error; err != nil {
+        return err
+    }
+    var seq models.ReceiptSequence
+    if err := db.Clauses(clause.Locking{Strength: "UPDATE"}).
+        Where("shop_id = ? AND fiscal_year = ?", tx.ShopID, seqYear).
+        First(&seq).Error; err != nil {
+        return err
+    }
+
+    seq.LastNumber++
+    if err := db.Model(&models.ReceiptSequence{}).
+        Where("shop_id = ? AND fiscal_year = ?", tx.ShopID, seqYear).
+        Update("last_number", seq.LastNumber).Error; err != nil {
+        return err
+    }
+
+    tx.FiscalYear = seqYear
+    tx.ReceiptSeq = seq.LastNumber
+    tx.ReceiptNumber = formatReceiptNumber(shop.ReceiptPrefix, tx.ShopID, year, seq.LastNumber)
+    return nil
+}
+
+// loadReceiptData collects everything a receipt shows for a transaction.
+func loadReceiptData(db *gorm.DB, tx models.SalesTransaction) (*receiptData, error) {
+    data := &receiptData{
+        Cashier:       fmt.Sprintf("#%d", tx.EmployeeID),
+        ReceiptNumber: fmt.Sprintf("%06d", tx.ID),
+        Time:          tx.TransactionTime,
+        IsReturn:      tx.TransactionType == models.TransactionTypeReturn,
+        IsVoided:      tx.Status == models.SaleStatusVoided,
+        Subtotal:      tx.SubtotalAmount,
+        Discount:      tx.DiscountAmount,
+        Total:         tx.TotalAmount,
+    }
+
+    if err := db.Where("id = ?", tx.ShopID).Limit(1).Find(&data.Shop).Error; err != nil {
+        return nil, err
+    }
+    // У чеков, созданных до нумерации по магазинам, номера нет
+    if tx.ReceiptNumber != "" {
+        data.ReceiptNumber = tx.ReceiptNumber
+    }
+    if data.Shop.Name == "" {
+        data.Shop.Name = fmt.Sprintf("Shop #%d", tx.ShopID)
+    }
+
+    var cashier models.Employee
+    if err := db.Where("id = ?", tx.EmployeeID).Limit(1).Find(&cashier).Error; err != nil {
+        return nil, err
+    }
+    if cashier.Name != "" {
+        data.Cashier = cashier.Name
+    }
+
+    var subtotal float64
+    taxes := map[float64]*receiptTax{}
+    var rates []float64
+    for _, item := range tx.SaleItems {
+        unitPrice := item.OriginalPrice
+        if unitPrice == 0 {
+            unitPrice = item.PriceAtSale
+        }
+        description := fmt.Sprintf("Item #%d", item.ItemID)
+        if item.Category != "" {
+            description += " " + item.Category
+        }
+        if item.GiftCardID != nil {
+            description = "Gift card"
+        }
+        line := receiptLine{
+            Description: description,
+            Quantity:    item.Quantity,
+            UnitPrice:   unitPrice,
+            Amount:      roundMoney(float64(item.Quantity) * unitPrice),
+            Discount:    item.DiscountAmount,
+            TaxRate:     item.TaxRate,
+        }
+        subtotal += line.Amount
+        data.Lines = append(data.Lines, line)
+
+        if item.TaxAmount != 0 {
+            t, ok := taxes[item.TaxRate]
+            if !ok {
+                t = &receiptTax{Rate: item.TaxRate}
+                taxes[item.TaxRate] = t
+                rates = append(rates, item.TaxRate)
+            }
+            t.Net += item.NetAmount
+            t.Tax += item.TaxAmount
+            t.Gross += item.GrossAmount
+        }
+    }
+    for _, r := range rates {
+        t := taxes[r]
+        data.Taxes = append(data.Taxes, receiptTax{Rate: r, Net: roundMoney(t.Net), Tax: roundMoney(t.Tax), Gross: roundMoney(t.Gross)})
+    }
+    // Чеки, созданные до появления скидок, не хранят подытог
+    if data.Subtotal == 0 {
+        data.Subtotal = roundMoney(subtotal)
+    }
+    data.TaxIncluded = tx.PricesIncludeTax
+
+    for _, p := range tx.Payments {
+        data.Payments = append(data.Payments, receiptPayment{
+            Method:    p.Method,
+            Amount:    p.Amount,
+            Reference: p.Reference,
+            Change:    p.ChangeGiven,
+        })
+        data.Change += p.ChangeGiven
+    }
+    if len(data.Payments) == 0 && tx.PaymentMethod != "" {
+        data.Payments = append(data.Payments, receiptPayment{Method: tx.PaymentMethod, Amount: tx.TotalAmount})
+    }
+
+    return data, nil
+}
+
+func renderReceiptText(data *receiptData, width int) (string, error) {
+    src := defaultReceiptTextTemplate
+    if data.Shop.ReceiptTextTemplate != "" {
+        src = data.Shop.ReceiptTextTemplate
+    }
+    tmpl, err := template.New("receipt").Funcs(receiptTextFuncs(width)).Parse(src)
+    if err != nil {
+        return "", err
+    }
+
+    var buf bytes.Buffer
+    if err := tmpl.Execute(&buf, data); err != nil {
+        return "", err
+    }
+    return buf.String(), nil
+}
+
+func renderReceiptHTML(data *receiptData) ([]byte, error) {
+    src := defaultReceiptHTMLTemplate
+    if data.Shop.ReceiptHTMLTemplate != "" {
+        src = data.Shop.ReceiptHTMLTemplate
+    }
+    tmpl, err := htmltemplate.New("receipt").Funcs(receiptHTMLFuncs).Parse(src)
+    if err != nil {
+        return nil, err
+    }
+
+    var buf bytes.Buffer
+    if err := tmpl.Execute(&buf, data); err != nil {
+        return nil, err
+    }
+    return buf.Bytes(), nil
+}
+
+// GetReceipt renders the receipt of a sales transaction
+// @Summary Get sale receipt
+// @Description Render the receipt of a sales transaction as plain text (fixed width, ESC/POS-friendly), HTML or PDF using the shop's templates
+// @Tags Sales
+// @Produce plain
+// @Produce html
+// @Produce application/pdf
+// @Param id path int true "Transaction ID"
+// @Param format query string false "text (default), html or pdf"
+// @Param width query int false "Characters per line for text and PDF (default 42)"
+// @Success 200 {string} string
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /sales/{id}/receipt [get]
+func (h *ReceiptHandler) GetReceipt(c *gin.Context) {
+    id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+        return
+    }
+
+    format := c.DefaultQuery("format", "text")
+    if format != "text" && format != "html" && format != "pdf" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "format must be text, html or pdf"})
+        return
+    }
+
+    width := receiptDefaultWidth
+    if v := c.Query("width"); v != "" {
+        width, err = strconv.Atoi(v)
+        if err != nil || width < receiptMinWidth || width > receiptMaxWidth {
+            c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("width must be between %d and %d", receiptMinWidth, receiptMaxWidth)})
+            return
+        }
+    }
+
+    var tx models.SalesTransaction
+    if err := h.DB.Preload("SaleItems").Preload("Payments").First(&tx, id).Error; err != nil {
+        if err == gorm.ErrRecordNotFound {
+            c.JSON(http.StatusNotFound, gin.H{"error": "transaction not found"})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
+        return
+    }
+
+    data, err := loadReceiptData(h.DB, tx)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    if format == "html" {
+        body, err := renderReceiptHTML(data)
+        if err != nil {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+            return
+        }
+        c.Data(http.StatusOK, "text/html; charset=utf-8", body)
+        return
+    }
+
+    text, err := renderReceiptText(data, width)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    if format == "text" {
+        c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(text))
+        return
+    }
+
+    // PDF — та же текстовая лента на странице по ширине чека
+    lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+    margin := 12.0
+    body, err := pdf.Render(lines, pdf.Options{
+        FontSize:   receiptPDFFontSize,
+        PageWidth:  float64(width)*pdf.CharWidth(receiptPDFFontSize) + 2*margin,
+        PageHeight: float64(len(lines)+1)*pdf.Leading(receiptPDFFontSize) + 2*margin,
+        Margin:     margin,
+    })
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="receipt-%s.pdf"`, data.ReceiptNumber))
+    c.Data(http.StatusOK, "application/pdf", body)
+}
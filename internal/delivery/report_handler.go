@@ -0,0 +1,475 @@
+package delivery
+
+import (
+    "encoding/csv"
+    "fmt"
+    "math"
+    "net/http"
+    "sort"
+    "strconv"
+    "time"
+
+    "github.com/dibsnvas/golang-2025/internal/models"
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+)
+
+type ReportHandler struct {
+    DB *gorm.DB
+}
+
+func NewReportHandler(db *gorm.DB) *ReportHandler {
+    return &ReportHandler{DB: db}
+}
+
+// parseDateRange reads the inclusive ?from=YYYY-MM-DD&to=YYYY-MM-DD range and
+// returns it as a half-open [from, to+1day) interval. On failure it writes a
+// 400 response and returns ok=false.
+func parseDateRange(c *gin.Context) (from, to time.Time, ok bool) {
+    fromStr, toStr := c.Query("from"), c.Query("to")
+    if fromStr == "" || toStr == "" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "from and to query params are required, e.g. ?from=2025-04-01&to=2025-04-30"})
+        return
+    }
+
+    var err error
+    from, err = time.Parse("2006-01-02", fromStr)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from, use YYYY-MM-DD"})
+        return
+    }
+    to, err = time.Parse("2006-01-02", toStr)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to, use YYYY-MM-DD"})
+        return
+    }
+    if to.Before(from) {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "to must not be before from"})
+        return
+    }
+
+    return from, to.AddDate(0, 0, 1), true
+}
+
+// workedInterval clips an attendance record to [from, to). Open shifts are
+// counted up to now.
+func workedInterval(a models.EmployeeAttendance, from, to time.Time) (time.Time, time.Time) {
+    start, end := a.ClockIn, time.Now()
+    if a.ClockOut != nil {
+        end = *a.ClockOut
+    }
+    if start.Before(from) {
+        start = from
+    }
+    if end.After(to) {
+        end = to
+    }
+    return start, end
+}
+
+type heatmapCell struct {
+    DayOfWeek         string  `json:"day_of_week"`
+    Hour              int     `json:"hour"`
+    Checks            int     `json:"checks"`
+    Revenue           float64 `json:"revenue"`
+    StaffHours        float64 `json:"staff_hours"`
+    AvgStaffOnShift   float64 `json:"avg_staff_on_shift"`
+    SalesPerStaffHour float64 `json:"sales_per_staff_hour"`
+    RecommendedStaff  int     `json:"recommended_staff"`
+}
+
+// GetSalesHeatmap returns sales volume by weekday and hour for a shop
+// @Summary Hourly sales heatmap with staffing recommendation
+// @Description Sales by day-of-week and hour-of-day for a shop, joined with attendance to show staff on shift, sales per staff-hour and suggested staffing levels
+// @Tags Reports
+// @Accept json
+// @Produce json
+// @Param shop_id query int true "Shop ID"
+// @Param from query string true "Start date in YYYY-MM-DD format"
+// @Param to query string true "End date (inclusive) in YYYY-MM-DD format"
+// @Param target_sales_per_staff_hour query number false "Revenue one employee should handle per hour; defaults to the shop's average for the period"
+// @Param min_staff query int false "Minimum recommended staff for any hour with sales (default 1)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /reports/sales-heatmap [get]
+func (h *ReportHandler) GetSalesHeatmap(c *gin.Context) {
+    shopID, err := strconv.ParseUint(c.Query("shop_id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid shop_id"})
+        return
+    }
+
+    from, to, ok := parseDateRange(c)
+    if !ok {
+        return
+    }
+
+    minStaff := 1
+    if v := c.Query("min_staff"); v != "" {
+        minStaff, err = strconv.Atoi(v)
+        if err != nil || minStaff < 0 {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "invalid min_staff"})
+            return
+        }
+    }
+
+    var sales []models.SalesTransaction
+    if err := h.DB.Scopes(completedSales).Where(
+        "shop_id = ? AND transaction_time >= ? AND transaction_time < ?",
+        shopID, from, to,
+    ).Find(&sales).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    var shifts []models.EmployeeAttendance
+    if err := h.DB.Where(
+        "shop_id = ? AND clock_in < ? AND (clock_out IS NULL OR clock_out > ?)",
+        shopID, to, from,
+    ).Find(&shifts).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    var cells [7][24]heatmapCell
+    for _, s := range sales {
+        t := s.TransactionTime.In(from.Location())
+        cell := &cells[t.Weekday()][t.Hour()]
+        cell.Checks++
+        cell.Revenue += s.TotalAmount
+    }
+
+    // Раскладываем каждую смену по часовым слотам, учитывая неполные часы
+    for _, a := range shifts {
+        start, end := workedInterval(a, from, to)
+        for cur := start; cur.Before(end); {
+            t := cur.In(from.Location())
+            next := t.Truncate(time.Hour).Add(time.Hour)
+            if next.After(end) {
+                next = end
+            }
+            cells[t.Weekday()][t.Hour()].StaffHours += next.Sub(t).Hours()
+            cur = next
+        }
+    }
+
+    // Сколько раз каждый день недели встречается в периоде — для средних значений
+    var occurrences [7]int
+    for d := from; d.Before(to); d = d.AddDate(0, 0, 1) {
+        occurrences[d.Weekday()]++
+    }
+
+    target, err := strconv.ParseFloat(c.DefaultQuery("target_sales_per_staff_hour", "0"), 64)
+    if err != nil || target < 0 {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid target_sales_per_staff_hour"})
+        return
+    }
+    if target == 0 {
+        var revenue, staffHours float64
+        for d := range cells {
+            for hr := range cells[d] {
+                if cells[d][hr].StaffHours > 0 {
+                    revenue += cells[d][hr].Revenue
+                    staffHours += cells[d][hr].StaffHours
+                }
+            }
+        }
+        if staffHours > 0 {
+            target = revenue / staffHours
+        }
+    }
+
+    result := make([]heatmapCell, 0, 7*24)
+    for d := range cells {
+        for hr := range cells[d] {
+            cell := cells[d][hr]
+            cell.DayOfWeek = time.Weekday(d).String()
+            cell.Hour = hr
+            if occurrences[d] > 0 {
+                cell.AvgStaffOnShift = cell.StaffHours / float64(occurrences[d])
+            }
+            if cell.StaffHours > 0 {
+                cell.SalesPerStaffHour = cell.Revenue / cell.StaffHours
+            }
+            if cell.Checks > 0 {
+                cell.RecommendedStaff = minStaff
+                if target > 0 && occurrences[d] > 0 {
+                    avgRevenue := cell.Revenue / float64(occurrences[d])
+                    if n := int(math.Ceil(avgRevenue / target)); n > cell.RecommendedStaff {
+                        cell.RecommendedStaff = n
+                    }
+                }
+            }
+            result = append(result, cell)
+        }
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "shop_id":                     shopID,
+        "from":                        c.Query("from"),
+        "to":                          c.Query("to"),
+        "target_sales_per_staff_hour": target,
+        "cells":                       result,
+    })
+}
+
+type leaderboardRow struct {
+    EmployeeID     uint    `json:"employee_id"`
+    ShopID         uint    `json:"shop_id"`
+    Revenue        float64 `json:"revenue"`
+    Checks         int     `json:"checks"`
+    AvgBasket      float64 `json:"avg_basket"`
+    ItemsPerCheck  float64 `json:"items_per_check"`
+    HoursWorked    float64 `json:"hours_worked"`
+    RevenuePerHour float64 `json:"revenue_per_hour"`
+    Returns        int     `json:"returns"`
+    ReturnRate     float64 `json:"return_rate"`
+    ShopRank       int     `json:"shop_rank"`
+    ChainRank      int     `json:"chain_rank"`
+
+    grossSales    float64
+    returnedTotal float64
+    itemsSold     int
+    shopRevenue   map[uint]float64
+}
+
+// GetEmployeeLeaderboard ranks employees by sales performance for a period
+// @Summary Employee performance leaderboard
+// @Description Per-employee revenue, check count, average basket, items per check, revenue per hour worked and return rate, ranked within the employee's shop and across the chain. Use format=csv for a CSV export
+// @Tags Reports
+// @Accept json
+// @Produce json
+// @Produce text/csv
+// @Param from query string true "Start date in YYYY-MM-DD format"
+// @Param to query string true "End date (inclusive) in YYYY-MM-DD format"
+// @Param shop_id query int false "Only employees whose main shop in the period is this shop"
+// @Param format query string false "json (default) or csv"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /reports/leaderboard [get]
+func (h *ReportHandler) GetEmployeeLeaderboard(c *gin.Context) {
+    from, to, ok := parseDateRange(c)
+    if !ok {
+        return
+    }
+
+    var shopID uint64
+    if v := c.Query("shop_id"); v != "" {
+        var err error
+        shopID, err = strconv.ParseUint(v, 10, 64)
+        if err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "invalid shop_id"})
+            return
+        }
+    }
+
+    format := c.DefaultQuery("format", "json")
+    if format != "json" && format != "csv" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or csv"})
+        return
+    }
+
+    var sales []models.SalesTransaction
+    if err := h.DB.Preload("SaleItems").Scopes(completedSales).Where(
+        "transaction_time >= ? AND transaction_time < ?", from, to,
+    ).Find(&sales).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    rows := map[uint]*leaderboardRow{}
+    for _, s := range sales {
+        row, exists := rows[s.EmployeeID]
+        if !exists {
+            row = &leaderboardRow{EmployeeID: s.EmployeeID, shopRevenue: map[uint]float64{}}
+            rows[s.EmployeeID] = row
+        }
+
+        row.Revenue += s.TotalAmount
+        row.shopRevenue[s.ShopID] += s.TotalAmount
+        if s.TransactionType == models.TransactionTypeReturn {
+            row.Returns++
+            row.returnedTotal -= s.TotalAmount
+            continue
+        }
+        row.Checks++
+        row.grossSales += s.TotalAmount
+        for _, item := range s.SaleItems {
+            row.itemsSold += item.Quantity
+        }
+    }
+
+    employeeIDs := make([]uint, 0, len(rows))
+    for id := range rows {
+        employeeIDs = append(employeeIDs, id)
+    }
+
+    var shifts []models.EmployeeAttendance
+    if len(employeeIDs) > 0 {
+        if err := h.DB.Where(
+            "employee_id IN ? AND clock_in < ? AND (clock_out IS NULL OR clock_out > ?)",
+            employeeIDs, to, from,
+        ).Find(&shifts).Error; err != nil {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+            return
+        }
+    }
+    for _, a := range shifts {
+        start, end := workedInterval(a, from, to)
+        if end.After(start) {
+            rows[a.EmployeeID].HoursWorked += end.Sub(start).Hours()
+        }
+    }
+
+    result := make([]*leaderboardRow, 0, len(rows))
+    for _, row := range rows {
+        // Основной магазин сотрудника — тот, где у него больше всего выручки
+        best := math.Inf(-1)
+        for shop, revenue := range row.shopRevenue {
+            if revenue > best || (revenue == best && shop < row.ShopID) {
+                row.ShopID, best = shop, revenue
+            }
+        }
+        if row.Checks > 0 {
+            row.AvgBasket = row.grossSales / float64(row.Checks)
+            row.ItemsPerCheck = float64(row.itemsSold) / float64(row.Checks)
+        }
+        if row.HoursWorked > 0 {
+            row.RevenuePerHour = row.Revenue / row.HoursWorked
+        }
+        if row.grossSales > 0 {
+            row.ReturnRate = row.returnedTotal / row.grossSales
+        }
+        result = append(result, row)
+    }
+
+    sort.Slice(result, func(i, j int) bool {
+        if result[i].Revenue != result[j].Revenue {
+            return result[i].Revenue > result[j].Revenue
+        }
+        return result[i].EmployeeID < result[j].EmployeeID
+    })
+
+    shopRanks := map[uint]int{}
+    filtered := result[:0]
+    for i, row := range result {
+        row.ChainRank = i + 1
+        shopRanks[row.ShopID]++
+        row.ShopRank = shopRanks[row.ShopID]
+        if shopID == 0 || uint64(row.ShopID) == shopID {
+            filtered = append(filtered, row)
+        }
+    }
+
+    if format == "csv" {
+        c.Header("Content-Disposition", `attachment; filename="leaderboard.csv"`)
+        c.Header("Content-Type", "text/csv")
+        c.Status(http.StatusOK)
+
+        w := csv.NewWriter(c.Writer)
+        w.Write([]string{
+            "chain_rank", "shop_rank", "employee_id", "shop_id", "revenue", "checks", "avg_basket",
+            "items_per_check", "hours_worked", "revenue_per_hour", "returns", "return_rate",
+        })
+        for _, row := range filtered {
+            w.Write([]string{
+                strconv.Itoa(row.ChainRank),
+                strconv.Itoa(row.ShopRank),
+                strconv.FormatUint(uint64(row.EmployeeID), 10),
+                strconv.FormatUint(uint64(row.ShopID), 10),
+                fmt.Sprintf("%.2f", row.Revenue),
+                strconv.Itoa(row.Checks),
+                fmt.Sprintf("%.2f", row.AvgBasket),
+                fmt.Sprintf("%.2f", row.ItemsPerCheck),
+                fmt.Sprintf("%.2f", row.HoursWorked),
+                fmt.Sprintf("%.2f", row.RevenuePerHour),
+                strconv.Itoa(row.Returns),
+                fmt.Sprintf("%.4f", row.ReturnRate),
+            })
+        }
+        w.Flush()
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "from":      c.Query("from"),
+        "to":        c.Query("to"),
+        "employees": filtered,
+    })
+}
+
+type taxSummaryRow struct {
+    ShopID      uint    `json:"shop_id"`
+    TaxCategory string  `json:"tax_category"`
+    TaxRate     float64 `json:"tax_rate"`
+    NetAmount   float64 `json:"net_amount"`
+    TaxAmount   float64 `json:"tax_amount"`
+    GrossAmount float64 `json:"gross_amount"`
+}
+
+// GetTaxSummary returns net, tax and gross totals per shop and tax rate
+// @Summary Tax summary for a period
+// @Description Net, tax and gross amounts of sold items grouped by shop, tax category and rate; returns are included as negative amounts
+// @Tags Reports
+// @Accept json
+// @Produce json
+// @Param from query string true "Start date in YYYY-MM-DD format"
+// @Param to query string true "End date (inclusive) in YYYY-MM-DD format"
+// @Param shop_id query int false "Shop ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /reports/tax-summary [get]
+func (h *ReportHandler) GetTaxSummary(c *gin.Context) {
+    from, to, ok := parseDateRange(c)
+    if !ok {
+        return
+    }
+
+    query := h.DB.Table("sale_items").
+        Select("sales_transactions.shop_id, sale_items.tax_category, sale_items.tax_rate, " +
+            "SUM(sale_items.net_amount) AS net_amount, SUM(sale_items.tax_amount) AS tax_amount, " +
+            "SUM(sale_items.gross_amount) AS gross_amount").
+        Joins("JOIN sales_transactions ON sales_transactions.id = sale_items.transaction_id").
+        Scopes(completedSales).
+        Where("sales_transactions.transaction_time >= ? AND sales_transactions.transaction_time < ?", from, to)
+
+    if v := c.Query("shop_id"); v != "" {
+        shopID, err := strconv.ParseUint(v, 10, 64)
+        if err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "invalid shop_id"})
+            return
+        }
+        query = query.Where("sales_transactions.shop_id = ?", shopID)
+    }
+
+    var rows []taxSummaryRow
+    if err := query.
+        Group("sales_transactions.shop_id, sale_items.tax_category, sale_items.tax_rate").
+        Order("sales_transactions.shop_id, sale_items.tax_category, sale_items.tax_rate").
+        Scan(&rows).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    var net, tax, gross float64
+    for i := range rows {
+        rows[i].NetAmount = roundMoney(rows[i].NetAmount)
+        rows[i].TaxAmount = roundMoney(rows[i].TaxAmount)
+        rows[i].GrossAmount = roundMoney(rows[i].GrossAmount)
+        net += rows[i].NetAmount
+        tax += rows[i].TaxAmount
+        gross += rows[i].GrossAmount
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "from":         c.Query("from"),
+        "to":           c.Query("to"),
+        "rows":         rows,
+        "net_amount":   roundMoney(net),
+        "tax_amount":   roundMoney(tax),
+        "gross_amount": roundMoney(gross),
+    })
+}
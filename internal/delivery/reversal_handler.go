@@ -0,0 +1,364 @@
+package delivery
+
+import (
+    "errors"
+    "net/http"
+    "strconv"
+    "time"
+
+    "github.com/dibsnvas/golang-2025/internal/models"
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+)
+
+var (
+    errSalaryPaymentReversed = errors.New("salary payment is already reversed")
+    errReissueAmount         = errors.New("negative corrections exceed the amount")
+    errPayPeriodTaken        = errors.New("a regular payment already covers an overlapping pay period")
+)
+
+type reverseSalaryRequest struct {
+    Reason       string `json:"reason"`
+    ManagerID    uint   `json:"manager_id"`
+    ApprovalCode string `json:"approval_code"` // код подтверждения менеджера
+    ReversedAt   string `json:"reversed_at"`   // YYYY-MM-DD; можно не указывать и взять time.Now()
+}
+
+type reissueSalaryRequest struct {
+    Reason         string  `json:"reason"`
+    ManagerID      uint    `json:"manager_id"`
+    ApprovalCode   string  `json:"approval_code"`    // код подтверждения менеджера
+    Amount         float64 `json:"amount"`           // исправленное брутто без корректировок исходной выплаты
+    PayPeriodStart string  `json:"pay_period_start"` // YYYY-MM-DD; по умолчанию как у исходной выплаты
+    PayPeriodEnd   string  `json:"pay_period_end"`
+    PaidAt         string  `json:"paid_at"` // YYYY-MM-DD; можно не указывать и взять time.Now()
+}
+
+// restoreAdvanceRecoveries gives back the advance installments withheld by
+// reversed deduction lines and reopens the advances.
+func restoreAdvanceRecoveries(db *gorm.DB, lines []models.SalaryDeduction) error {
+    for _, d := range lines {
+        if d.AdvanceID == nil || d.Amount == 0 {
+            continue
+        }
+        if err := db.Model(&models.SalaryAdvance{}).Where("id = ?", *d.AdvanceID).
+            Updates(map[string]interface{}{
+                "balance": gorm.Expr("balance + ?", d.Amount),
+                "status":  models.AdvanceOpen,
+            }).Error; err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// reverseSalaryPayment records the reversal entry of a payment: the same
+// employee and pay period with all amounts and deduction lines negated. The
+// original only gets reversed_at set. Recovered advance installments are
+// given back and the adjustments paid by the original are released; they are
+// returned so a reissue can pay them again. Run it in a transaction.
+func reverseSalaryPayment(db *gorm.DB, original *models.SalaryPayment, at time.Time, reason string, managerID uint) (*models.SalaryPayment, []models.SalaryAdjustment, error) {
+    res := db.Model(&models.SalaryPayment{}).
+        Where("id = ? AND reversed_at IS NULL", original.ID).
+        Update("reversed_at", at)
+    if res.Error != nil {
+        return nil, nil, res.Error
+    }
+    if res.RowsAffected == 0 {
+        return nil, nil, errSalaryPaymentReversed
+    }
+
+    reversal := models.SalaryPayment{
+        EmployeeID:            original.EmployeeID,
+        PayPeriodStart:        original.PayPeriodStart,
+        PayPeriodEnd:          original.PayPeriodEnd,
+        Amount:                -original.Amount,
+        Deductions:            -original.Deductions,
+        Reimbursements:        -original.Reimbursements,
+        NetAmount:             -original.NetAmount,
+        EmployerContributions: -original.EmployerContributions,
+        PaidAt:                at,
+        PaymentType:           models.SalaryPaymentReversal,
+        ReversalOfID:          &original.ID,
+        Reason:                reason,
+        ApprovedBy:            &managerID,
+    }
+    for _, d := range original.DeductionLines {
+        reversal.DeductionLines = append(reversal.DeductionLines, models.SalaryDeduction{
+            RuleID:         d.RuleID,
+            AdvanceID:      d.AdvanceID,
+            Kind:           d.Kind,
+            Description:    d.Description,
+            Base:           -d.Base,
+            Amount:         -d.Amount,
+            EmployerAmount: -d.EmployerAmount,
+        })
+    }
+    if err := db.Create(&reversal).Error; err != nil {
+        return nil, nil, err
+    }
+    if err := restoreAdvanceRecoveries(db, original.DeductionLines); err != nil {
+        return nil, nil, err
+    }
+
+    var adjustments []models.SalaryAdjustment
+    if err := db.Where("salary_payment_id = ?", original.ID).Order("id").Find(&adjustments).Error; err != nil {
+        return nil, nil, err
+    }
+    if len(adjustments) > 0 {
+        // Корректировки снова ждут выплаты: с перевыпуском, ведомостью или отдельно
+        if err := db.Model(&models.SalaryAdjustment{}).Where("salary_payment_id = ?", original.ID).
+            Updates(map[string]interface{}{
+                "salary_payment_id": nil,
+                "payroll_line_id":   nil,
+                "status":            models.AdjustmentApproved,
+            }).Error; err != nil {
+            return nil, nil, err
+        }
+        for i := range adjustments {
+            adjustments[i].SalaryPaymentID = nil
+            adjustments[i].PayrollLineID = nil
+            adjustments[i].Status = models.AdjustmentApproved
+        }
+    }
+    return &reversal, adjustments, nil
+}
+
+// loadReversiblePayment checks the manager approval and loads the payment to
+// reverse with its deduction lines. It writes the error response itself and
+// returns nil on failure.
+func (h *SalaryHandler) loadReversiblePayment(c *gin.Context, reason string, managerID uint, approvalCode string) *models.SalaryPayment {
+    id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+        return nil
+    }
+    if reason == "" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "reason is required"})
+        return nil
+    }
+
+    ok, err := checkManagerApproval(h.DB, managerID, approvalCode)
+    if err != nil {
+        c.JSON(approvalErrorStatus(err), gin.H{"error": err.Error()})
+        return nil
+    }
+    if !ok {
+        c.JSON(http.StatusForbidden, gin.H{"error": "invalid manager approval"})
+        return nil
+    }
+
+    var payment models.SalaryPayment
+    if err := h.DB.Preload("DeductionLines", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+        First(&payment, id).Error; err != nil {
+        if err == gorm.ErrRecordNotFound {
+            c.JSON(http.StatusNotFound, gin.H{"error": "salary not found"})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
+        return nil
+    }
+    if payment.PaymentType == models.SalaryPaymentReversal {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "a reversal entry cannot be reversed"})
+        return nil
+    }
+    if payment.ReversedAt != nil {
+        c.JSON(http.StatusConflict, gin.H{"error": errSalaryPaymentReversed.Error()})
+        return nil
+    }
+    return &payment
+}
+
+// ReverseSalary reverses a salary payment
+// @Summary Reverse a salary payment
+// @Description Cancel a wrongly recorded payment with a manager approval code. A reversal entry for the same employee and pay period is recorded with all amounts and deduction lines negated, so reports and year-to-date totals net the payment out; the original payment is kept unchanged except for reversed_at. Advance installments it recovered are given back and its salary adjustments become payable again. A regular payment's pay period is free for a new payment afterwards
+// @Tags Salary
+// @Accept json
+// @Produce json
+// @Param id path int true "Salary ID"
+// @Param reverseSalaryRequest body reverseSalaryRequest true "Reason and manager approval"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 429 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /salary/{id}/reverse [post]
+func (h *SalaryHandler) ReverseSalary(c *gin.Context) {
+    var req reverseSalaryRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    at := time.Now()
+    if req.ReversedAt != "" {
+        var err error
+        if at, err = time.Parse("2006-01-02", req.ReversedAt); err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "invalid reversed_at"})
+            return
+        }
+    }
+
+    original := h.loadReversiblePayment(c, req.Reason, req.ManagerID, req.ApprovalCode)
+    if original == nil {
+        return
+    }
+
+    var reversal *models.SalaryPayment
+    var released []models.SalaryAdjustment
+    err := h.DB.Transaction(func(db *gorm.DB) error {
+        var err error
+        reversal, released, err = reverseSalaryPayment(db, original, at, req.Reason, req.ManagerID)
+        return err
+    })
+    if err != nil {
+        c.JSON(salaryPaymentErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+
+    releasedIDs := make([]uint, len(released))
+    for i, a := range released {
+        releasedIDs[i] = a.ID
+    }
+    c.JSON(http.StatusCreated, gin.H{
+        "salary_id":            original.ID,
+        "reversal_id":          reversal.ID,
+        "gross":                reversal.Amount,
+        "net_amount":           reversal.NetAmount,
+        "released_adjustments": releasedIDs,
+    })
+}
+
+// ReissueSalary reverses a salary payment and records a corrected one
+// @Summary Reissue a salary payment
+// @Description Replace a wrongly recorded payment in one transaction: it is reversed as by POST /salary/{id}/reverse and a corrected payment of the same type is recorded with gross amount plus the original's salary adjustments, for the original pay period unless another is given. Deductions and advance recoveries of the corrected payment are computed anew. The corrected payment has reissue_of_id set to the original
+// @Tags Salary
+// @Accept json
+// @Produce json
+// @Param id path int true "Salary ID"
+// @Param reissueSalaryRequest body reissueSalaryRequest true "Corrected payment and manager approval"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 429 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /salary/{id}/reissue [post]
+func (h *SalaryHandler) ReissueSalary(c *gin.Context) {
+    var req reissueSalaryRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    if req.Amount < 0 {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "amount must not be negative"})
+        return
+    }
+    paidAt := time.Now()
+    if req.PaidAt != "" {
+        var err error
+        if paidAt, err = time.Parse("2006-01-02", req.PaidAt); err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "invalid paid_at"})
+            return
+        }
+    }
+
+    original := h.loadReversiblePayment(c, req.Reason, req.ManagerID, req.ApprovalCode)
+    if original == nil {
+        return
+    }
+
+    start, end := original.PayPeriodStart, original.PayPeriodEnd
+    if req.PayPeriodStart != "" {
+        var err error
+        if start, err = time.Parse("2006-01-02", req.PayPeriodStart); err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pay_period_start"})
+            return
+        }
+    }
+    if req.PayPeriodEnd != "" {
+        var err error
+        if end, err = time.Parse("2006-01-02", req.PayPeriodEnd); err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pay_period_end"})
+            return
+        }
+    }
+    if end.Before(start) {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "pay_period_end must not be before pay_period_start"})
+        return
+    }
+
+    var reversal *models.SalaryPayment
+    var conflict *models.SalaryPayment
+    salary := models.SalaryPayment{
+        EmployeeID:     original.EmployeeID,
+        PayPeriodStart: start,
+        PayPeriodEnd:   end,
+        PaidAt:         paidAt,
+        PaymentType:    original.PaymentType,
+        ReissueOfID:    &original.ID,
+        Reason:         req.Reason,
+    }
+    err := h.DB.Transaction(func(db *gorm.DB) error {
+        var adjustments []models.SalaryAdjustment
+        var err error
+        // Сторно в той же транзакции: период исходной выплаты освобождается, авансы возвращаются
+        if reversal, adjustments, err = reverseSalaryPayment(db, original, paidAt, req.Reason, req.ManagerID); err != nil {
+            return err
+        }
+
+        regular := salary.PaymentType == models.SalaryPaymentRegular
+        if regular {
+            if conflict, err = overlappingPayment(db, salary.EmployeeID, start, end); err != nil {
+                return err
+            }
+            if conflict != nil {
+                return errPayPeriodTaken
+            }
+        }
+
+        sums := sumAdjustments(adjustments)
+        gross := roundMoney(req.Amount + sums.Gross())
+        if gross < 0 || gross+sums.Reimbursements <= 0 {
+            return errReissueAmount
+        }
+        w, err := computeWithholding(db, salary.EmployeeID, gross, regular, start, end)
+        if err != nil {
+            return err
+        }
+        salary.Amount = gross
+        salary.Deductions = w.Deductions
+        salary.Reimbursements = sums.Reimbursements
+        salary.NetAmount = roundMoney(w.Net + sums.Reimbursements)
+        salary.EmployerContributions = w.EmployerContributions
+        salary.DeductionLines = w.Lines
+        return recordSalaryPayment(db, &salary, adjustments)
+    })
+    if err != nil {
+        switch {
+        case err == errReissueAmount:
+            c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        case err == errPayPeriodTaken:
+            c.JSON(http.StatusConflict, overlapConflict(conflict))
+        // Параллельный запрос успел записать выплату за тот же период
+        case isExclusionViolation(err):
+            c.JSON(http.StatusConflict, gin.H{"error": errPayPeriodTaken.Error()})
+        default:
+            c.JSON(salaryPaymentErrorStatus(err), gin.H{"error": err.Error()})
+        }
+        return
+    }
+
+    c.JSON(http.StatusCreated, gin.H{
+        "reversed_salary_id":     original.ID,
+        "reversal_id":            reversal.ID,
+        "salary_id":              salary.ID,
+        "gross":                  salary.Amount,
+        "deductions":             salary.Deductions,
+        "reimbursements":         salary.Reimbursements,
+        "net_amount":             salary.NetAmount,
+        "employer_contributions": salary.EmployerContributions,
+    })
+}
@@ -1,6 +1,7 @@
 package delivery
 
 import (
+    "github.com/dibsnvas/golang-2025/internal/catalog"
     "github.com/gin-gonic/gin"
     "gorm.io/gorm"
 
@@ -12,20 +13,125 @@ import (
 func SetupRouter(db *gorm.DB) *gin.Engine {
     r := gin.Default()
 
-    salesHandler := NewSalesHandler(db)
+    catalogClient := catalog.NewClient(catalog.ConfigFromEnv())
+
+    salesHandler := NewSalesHandler(db, catalogClient)
     attendanceHandler := NewAttendanceHandler(db)
     salaryHandler := NewSalaryHandler(db)
+    reportHandler := NewReportHandler(db)
+    commissionHandler := NewCommissionHandler(db)
+    promotionHandler := NewPromotionHandler(db)
+    taxHandler := NewTaxHandler(db)
+    paymentHandler := NewPaymentHandler(db)
+    shopHandler := NewShopHandler(db)
+    employeeHandler := NewEmployeeHandler(db)
+    receiptHandler := NewReceiptHandler(db)
+    catalogHandler := NewCatalogHandler(catalogClient)
+    importHandler := NewImportHandler(db)
+    giftCardHandler := NewGiftCardHandler(db)
+    customerHandler := NewCustomerHandler(db)
+    loyaltyHandler := NewLoyaltyHandler(db)
+    payrollHandler := NewPayrollHandler(db)
+    companyHandler := NewCompanyHandler(db)
+    bankHandler := NewBankHandler(db)
+    deductionHandler := NewDeductionHandler(db)
+    advanceHandler := NewAdvanceHandler(db)
+    adjustmentHandler := NewAdjustmentHandler(db)
 
     r.POST("/sales", salesHandler.CreateSale)
+    r.POST("/sales/batch", salesHandler.CreateSalesBatch)
+    r.POST("/sales/import", importHandler.ImportSales)
+    r.GET("/sales/:id/receipt", receiptHandler.GetReceipt)
+    r.POST("/sales/:id/void", salesHandler.VoidSale)
 
 	r.POST("/salary/pay", salaryHandler.PaySalary)
 	r.GET("/salary/:id", salaryHandler.GetSalaryByID)
+    r.GET("/salary", salaryHandler.ListSalary)
+    r.GET("/salary/employee/:employee_id", salaryHandler.GetEmployeePayHistory)
+    r.GET("/salary/:id/payslip", salaryHandler.GetPayslip)
+    r.POST("/salary/:id/reverse", salaryHandler.ReverseSalary)
+    r.POST("/salary/:id/reissue", salaryHandler.ReissueSalary)
+    r.POST("/salary/bank-export", bankHandler.ExportSalaryPayments)
+    r.POST("/salary/adjustments", adjustmentHandler.CreateAdjustment)
+    r.GET("/salary/adjustments", adjustmentHandler.ListAdjustments)
+    r.GET("/salary/adjustments/:id", adjustmentHandler.GetAdjustment)
+    r.POST("/salary/adjustments/:id/approve", adjustmentHandler.ApproveAdjustment)
+    r.POST("/salary/adjustments/:id/reject", adjustmentHandler.RejectAdjustment)
+    r.POST("/salary/adjustments/:id/pay", adjustmentHandler.PayAdjustment)
+
+    r.POST("/deductions/rules", deductionHandler.CreateRule)
+    r.GET("/deductions/rules", deductionHandler.ListRules)
+    r.DELETE("/deductions/rules/:id", deductionHandler.DeleteRule)
+
+    r.POST("/advances", advanceHandler.CreateAdvance)
+    r.GET("/advances", advanceHandler.ListAdvances)
+    r.GET("/advances/:id", advanceHandler.GetAdvance)
+    r.GET("/advances/employee/:employee_id", advanceHandler.GetEmployeeAdvances)
+
+    r.POST("/payroll/runs", payrollHandler.CreateRun)
+    r.GET("/payroll/runs", payrollHandler.ListRuns)
+    r.GET("/payroll/runs/:id", payrollHandler.GetRun)
+    r.DELETE("/payroll/runs/:id", payrollHandler.DeleteRun)
+    r.PUT("/payroll/runs/:id/lines/:line_id", payrollHandler.AdjustLine)
+    r.DELETE("/payroll/runs/:id/lines/:line_id", payrollHandler.RemoveLine)
+    r.POST("/payroll/runs/:id/approve", payrollHandler.ApproveRun)
+    r.POST("/payroll/runs/:id/reopen", payrollHandler.ReopenRun)
+    r.POST("/payroll/runs/:id/pay", payrollHandler.PayRun)
+    r.POST("/payroll/runs/:id/bank-export", bankHandler.ExportRun)
 
     r.POST("/attendance/clock-in", attendanceHandler.ClockIn)
     r.POST("/attendance/clock-out", attendanceHandler.ClockOut)
 
 	r.GET("/sales/employee/:employee_id", salesHandler.GetSalesByEmployeeAndDate)
+
+    r.GET("/reports/sales-heatmap", reportHandler.GetSalesHeatmap)
+    r.GET("/reports/leaderboard", reportHandler.GetEmployeeLeaderboard)
+    r.GET("/reports/tax-summary", reportHandler.GetTaxSummary)
+
+    r.POST("/commission/plans", commissionHandler.CreatePlan)
+    r.GET("/commission/plans/:id", commissionHandler.GetPlan)
+    r.POST("/commission/assignments", commissionHandler.AssignPlan)
+    r.GET("/commission/statement/:employee_id", commissionHandler.GetStatement)
+
+    r.POST("/promotions", promotionHandler.CreatePromotion)
+    r.GET("/promotions", promotionHandler.ListPromotions)
+    r.GET("/promotions/:id", promotionHandler.GetPromotion)
+
+    r.POST("/tax/rates", taxHandler.SetTaxRate)
+    r.GET("/tax/rates", taxHandler.ListTaxRates)
+    r.GET("/tax/shops/:shop_id", taxHandler.GetShopTaxSettings)
+    r.PUT("/tax/shops/:shop_id", taxHandler.SetShopTaxSettings)
+
+    r.POST("/shops", shopHandler.CreateShop)
+    r.GET("/shops/:id", shopHandler.GetShop)
+    r.PUT("/shops/:id", shopHandler.UpdateShop)
+    r.GET("/shops/:id/payment-methods", paymentHandler.GetShopPaymentMethods)
+    r.PUT("/shops/:id/payment-methods", paymentHandler.SetShopPaymentMethods)
     
+    r.POST("/employees", employeeHandler.CreateEmployee)
+    r.GET("/employees/:id", employeeHandler.GetEmployee)
+    r.PUT("/employees/:id/approval-code", employeeHandler.SetApprovalCode)
+    r.PUT("/employees/:id/pay", employeeHandler.SetPay)
+    r.PUT("/employees/:id/bank-account", bankHandler.SetBankAccount)
+    r.GET("/employees/:id/bank-account", bankHandler.GetBankAccount)
+
+    r.GET("/company", companyHandler.GetCompany)
+    r.PUT("/company", companyHandler.UpdateCompany)
+
+    r.GET("/gift-cards/:code", giftCardHandler.GetGiftCard)
+    r.GET("/gift-cards/:code/movements", giftCardHandler.GetGiftCardMovements)
+
+    r.POST("/customers", customerHandler.CreateCustomer)
+    r.GET("/customers/:id", customerHandler.GetCustomer)
+    r.GET("/customers/:id/loyalty", customerHandler.GetLoyalty)
+    r.GET("/customers/:id/purchases", customerHandler.GetPurchases)
+
+    r.POST("/loyalty/rules", loyaltyHandler.CreateRule)
+    r.GET("/loyalty/rules", loyaltyHandler.ListRules)
+    r.DELETE("/loyalty/rules/:id", loyaltyHandler.DeleteRule)
+
+    r.GET("/catalog/metrics", catalogHandler.GetMetrics)
+
     r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
     return r
@@ -1,15 +1,42 @@
 package delivery
 
 import (
+    "errors"
+    "fmt"
     "net/http"
     "strconv"
     "time"
 
     "github.com/dibsnvas/golang-2025/internal/models"
     "github.com/gin-gonic/gin"
+    "github.com/jackc/pgx/v5/pgconn"
     "gorm.io/gorm"
 )
 
+// SQLSTATE exclusion_violation: нарушено ограничение salary_payments_no_overlap
+const pgExclusionViolation = "23P01"
+
+func isExclusionViolation(err error) bool {
+    var pgErr *pgconn.PgError
+    return errors.As(err, &pgErr) && pgErr.Code == pgExclusionViolation
+}
+
+// overlappingPayment returns a regular payment of the employee, not reversed,
+// whose pay period overlaps start..end (inclusive), or nil.
+func overlappingPayment(db *gorm.DB, employeeID uint, start, end time.Time) (*models.SalaryPayment, error) {
+    var payment models.SalaryPayment
+    err := db.Where("employee_id = ? AND payment_type = ? AND reversed_at IS NULL AND pay_period_start <= ? AND pay_period_end >= ?",
+        employeeID, models.SalaryPaymentRegular, end, start).
+        Order("pay_period_start").Limit(1).Find(&payment).Error
+    if err != nil {
+        return nil, err
+    }
+    if payment.ID == 0 {
+        return nil, nil
+    }
+    return &payment, nil
+}
+
 type SalaryHandler struct {
     DB *gorm.DB
 }
@@ -23,17 +50,21 @@ type PaySalaryRequest struct {
     PayPeriodStart string  `json:"pay_period_start"` // строка, чтобы потом распарсить "YYYY-MM-DD"
     PayPeriodEnd   string  `json:"pay_period_end"`
     Amount         float64 `json:"amount"`
-    PaidAt         string  `json:"paid_at"` // можно не указывать и взять time.Now()
+    PaidAt         string  `json:"paid_at"`        // можно не указывать и взять time.Now()
+    PaymentType    string  `json:"payment_type"`   // regular (по умолчанию) или supplemental
+    AdjustmentIDs  []uint  `json:"adjustment_ids"` // утверждённые корректировки, выплачиваемые вместе с зарплатой
 }
 // PaySalary processes a salary payment
 // @Summary Pay salary to an employee
-// @Description Record a salary payment for an employee
+// @Description Record a salary payment of gross amount for an employee. The employee's deduction rules are applied, regular payments also recover the installments of due advances, and the deduction lines, net pay and employer contributions are stored with the payment. Approved adjustments listed in adjustment_ids are paid with it: bonuses and corrections are added to gross pay, reimbursements to net pay. The amount must be positive (it may be 0 when adjustments are given; such a payment is supplemental) and the pay period must not end before it starts. Adjustments must be effective on or before pay_period_end. Regular payments of an employee cannot cover overlapping pay periods (409); supplemental payments such as bonuses can
 // @Tags Salary
 // @Accept json
 // @Produce json
 // @Param PaySalaryRequest body PaySalaryRequest true "Salary payment data"
 // @Success 201 {object} map[string]interface{}
 // @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /salary/pay [post]
 func (h *SalaryHandler) PaySalary(c *gin.Context) {
@@ -55,6 +86,11 @@ func (h *SalaryHandler) PaySalary(c *gin.Context) {
         return
     }
 
+    if end.Before(start) {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "pay_period_end must not be before pay_period_start"})
+        return
+    }
+
     paidAt := time.Now()
     if req.PaidAt != "" {
         paidAt, err = time.Parse("2006-01-02", req.PaidAt)
@@ -64,24 +100,145 @@ func (h *SalaryHandler) PaySalary(c *gin.Context) {
         }
     }
 
-    salary := models.SalaryPayment{
-        EmployeeID:     req.EmployeeID,
-        PayPeriodStart: start,
-        PayPeriodEnd:   end,
-        Amount:         req.Amount,
-        PaidAt:         paidAt,
+    if req.Amount < 0 || (req.Amount == 0 && len(req.AdjustmentIDs) == 0) {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "amount must be positive"})
+        return
+    }
+    if req.PaymentType == "" {
+        req.PaymentType = models.SalaryPaymentRegular
+        // Выплата одних корректировок не закрывает период зарплаты
+        if req.Amount == 0 {
+            req.PaymentType = models.SalaryPaymentSupplemental
+        }
+    }
+    if req.PaymentType != models.SalaryPaymentRegular && req.PaymentType != models.SalaryPaymentSupplemental {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "payment_type must be regular or supplemental"})
+        return
+    }
+    if req.PaymentType == models.SalaryPaymentRegular && req.Amount == 0 {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "a regular payment must have a positive amount"})
+        return
+    }
+
+    var employee models.Employee
+    if err := h.DB.Where("id = ?", req.EmployeeID).Limit(1).Find(&employee).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    if employee.ID == 0 {
+        c.JSON(http.StatusNotFound, gin.H{"error": "employee not found"})
+        return
+    }
+
+    if req.PaymentType == models.SalaryPaymentRegular {
+        existing, err := overlappingPayment(h.DB, req.EmployeeID, start, end)
+        if err != nil {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+            return
+        }
+        if existing != nil {
+            c.JSON(http.StatusConflict, overlapConflict(existing))
+            return
+        }
+    }
+
+    adjustments, err := loadPaymentAdjustments(h.DB, req.EmployeeID, req.AdjustmentIDs)
+    if err != nil {
+        c.JSON(salaryPaymentErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+    for _, a := range adjustments {
+        if a.EffectiveDate.After(end) {
+            c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("adjustment %d is effective after pay_period_end", a.ID)})
+            return
+        }
     }
+    sums := sumAdjustments(adjustments)
 
-    if err := h.DB.Create(&salary).Error; err != nil {
+    gross := roundMoney(req.Amount + sums.Gross())
+    if gross < 0 || gross+sums.Reimbursements <= 0 {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "negative corrections exceed the amount"})
+        return
+    }
+    w, err := computeWithholding(h.DB, req.EmployeeID, gross, req.PaymentType == models.SalaryPaymentRegular, start, end)
+    if err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
         return
     }
 
-    c.JSON(http.StatusCreated, gin.H{"salary_id": salary.ID})
+    salary := models.SalaryPayment{
+        EmployeeID:            req.EmployeeID,
+        PayPeriodStart:        start,
+        PayPeriodEnd:          end,
+        Amount:                gross,
+        Deductions:            w.Deductions,
+        Reimbursements:        sums.Reimbursements,
+        NetAmount:             roundMoney(w.Net + sums.Reimbursements),
+        EmployerContributions: w.EmployerContributions,
+        PaidAt:                paidAt,
+        PaymentType:           req.PaymentType,
+        DeductionLines:        w.Lines,
+    }
+
+    if err := recordSalaryPayment(h.DB, &salary, adjustments); err != nil {
+        // Параллельный запрос успел записать выплату за тот же период
+        if isExclusionViolation(err) {
+            c.JSON(http.StatusConflict, gin.H{"error": "a regular payment already covers an overlapping pay period"})
+            return
+        }
+        c.JSON(salaryPaymentErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusCreated, gin.H{
+        "salary_id":              salary.ID,
+        "gross":                  salary.Amount,
+        "deductions":             salary.Deductions,
+        "reimbursements":         salary.Reimbursements,
+        "net_amount":             salary.NetAmount,
+        "employer_contributions": salary.EmployerContributions,
+    })
+}
+
+// recordSalaryPayment writes a payment with its deduction lines, marks the
+// adjustments it pays and recovers advance installments in one transaction.
+func recordSalaryPayment(db *gorm.DB, salary *models.SalaryPayment, adjustments []models.SalaryAdjustment) error {
+    return db.Transaction(func(db *gorm.DB) error {
+        if err := db.Create(salary).Error; err != nil {
+            return err
+        }
+        err := attachAdjustments(db, adjustments, map[string]interface{}{
+            "salary_payment_id": salary.ID,
+            "status":            models.AdjustmentPaid,
+        })
+        if err != nil {
+            return err
+        }
+        return recordAdvanceRecoveries(db, salary.DeductionLines)
+    })
+}
+
+func salaryPaymentErrorStatus(err error) int {
+    switch err {
+    case errAdvanceBalanceChanged, errAdjustmentsUnavailable, errSalaryPaymentReversed:
+        return http.StatusConflict
+    case errAdjustmentNotFound:
+        return http.StatusNotFound
+    }
+    return http.StatusInternalServerError
 }
+func overlapConflict(existing *models.SalaryPayment) gin.H {
+    return gin.H{
+        "error":            "a regular payment already covers an overlapping pay period",
+        "salary_id":        existing.ID,
+        "pay_period_start": existing.PayPeriodStart.Format("2006-01-02"),
+        "pay_period_end":   existing.PayPeriodEnd.Format("2006-01-02"),
+    }
+}
+
 // GetSalaryByID returns salary payment by ID
 // @Summary Get salary payment by ID
-// @Description Retrieve salary payment details using salary ID
+// @Description Retrieve salary payment details using salary ID, with its deduction lines
 // @Tags Salary
 // @Accept json
 // @Produce json
@@ -100,7 +257,8 @@ func (h *SalaryHandler) GetSalaryByID(c *gin.Context) {
     }
 
     var salary models.SalaryPayment
-    if err := h.DB.First(&salary, id).Error; err != nil {
+    if err := h.DB.Preload("DeductionLines", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+        First(&salary, id).Error; err != nil {
         if err == gorm.ErrRecordNotFound {
             c.JSON(http.StatusNotFound, gin.H{"error": "salary not found"})
         } else {
@@ -111,3 +269,185 @@ func (h *SalaryHandler) GetSalaryByID(c *gin.Context) {
 
     c.JSON(http.StatusOK, salary)
 }
+
+// ListSalary returns salary payments
+// @Summary List salary payments
+// @Description List salary payments, newest pay period first, optionally for one employee and for pay periods overlapping from..to, with gross, deduction, reimbursement, net and employer contribution totals. Reversal entries are listed with negated amounts, so the totals net out reversed payments
+// @Tags Salary
+// @Accept json
+// @Produce json
+// @Param employee_id query int false "Employee ID"
+// @Param from query string false "Period start in YYYY-MM-DD format"
+// @Param to query string false "Period end (inclusive) in YYYY-MM-DD format"
+// @Param limit query int false "Page size (default 50, max 500)"
+// @Param offset query int false "Entries to skip"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /salary [get]
+func (h *SalaryHandler) ListSalary(c *gin.Context) {
+    limit, offset, ok := parsePagination(c)
+    if !ok {
+        return
+    }
+
+    query := h.DB.Model(&models.SalaryPayment{})
+    if v := c.Query("employee_id"); v != "" {
+        employeeID, err := strconv.ParseUint(v, 10, 64)
+        if err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "invalid employee_id"})
+            return
+        }
+        query = query.Where("employee_id = ?", employeeID)
+    }
+    if v := c.Query("from"); v != "" {
+        from, err := time.Parse("2006-01-02", v)
+        if err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from, use YYYY-MM-DD"})
+            return
+        }
+        query = query.Where("pay_period_end >= ?", from)
+    }
+    if v := c.Query("to"); v != "" {
+        to, err := time.Parse("2006-01-02", v)
+        if err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to, use YYYY-MM-DD"})
+            return
+        }
+        query = query.Where("pay_period_start <= ?", to)
+    }
+
+    var total struct {
+        Count                 int64
+        Amount                float64
+        Deductions            float64
+        Reimbursements        float64
+        NetAmount             float64
+        EmployerContributions float64
+    }
+    if err := query.Session(&gorm.Session{}).
+        Select(`COUNT(*) AS count, COALESCE(SUM(amount), 0) AS amount,
+            COALESCE(SUM(deductions), 0) AS deductions, COALESCE(SUM(reimbursements), 0) AS reimbursements,
+            COALESCE(SUM(net_amount), 0) AS net_amount,
+            COALESCE(SUM(employer_contributions), 0) AS employer_contributions`).
+        Scan(&total).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    var payments []models.SalaryPayment
+    if err := query.Order("pay_period_start DESC, id DESC").Limit(limit).Offset(offset).
+        Find(&payments).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "payments":                     payments,
+        "total":                        total.Count,
+        "total_amount":                 roundMoney(total.Amount),
+        "total_deductions":             roundMoney(total.Deductions),
+        "total_reimbursements":         roundMoney(total.Reimbursements),
+        "total_net":                    roundMoney(total.NetAmount),
+        "total_employer_contributions": roundMoney(total.EmployerContributions),
+        "limit":                        limit,
+        "offset":                       offset,
+    })
+}
+
+type payTotals struct {
+    Gross                 float64 `json:"gross"`
+    Deductions            float64 `json:"deductions"`
+    Net                   float64 `json:"net"`
+    EmployerContributions float64 `json:"employer_contributions"`
+    Payments              int64   `json:"payments"`
+}
+
+// yearToDate sums the salary payments of an employee paid in the calendar
+// year of asOf, up to and including asOf. Reversal entries net out the
+// payments they reverse; neither is counted as a payment.
+func yearToDate(db *gorm.DB, employeeID uint, asOf time.Time) (payTotals, error) {
+    yearStart := time.Date(asOf.Year(), 1, 1, 0, 0, 0, 0, asOf.Location())
+    var t struct {
+        Count                 int64
+        Amount                float64
+        Deductions            float64
+        NetAmount             float64
+        EmployerContributions float64
+    }
+    err := db.Model(&models.SalaryPayment{}).
+        Select(`COUNT(*) FILTER (WHERE reversed_at IS NULL AND reversal_of_id IS NULL) AS count, COALESCE(SUM(amount), 0) AS amount,
+            COALESCE(SUM(deductions), 0) AS deductions, COALESCE(SUM(net_amount), 0) AS net_amount,
+            COALESCE(SUM(employer_contributions), 0) AS employer_contributions`).
+        Where("employee_id = ? AND paid_at >= ? AND paid_at <= ?", employeeID, yearStart, asOf).
+        Scan(&t).Error
+    if err != nil {
+        return payTotals{}, err
+    }
+    return payTotals{
+        Gross:                 roundMoney(t.Amount),
+        Deductions:            roundMoney(t.Deductions),
+        Net:                   roundMoney(t.NetAmount),
+        EmployerContributions: roundMoney(t.EmployerContributions),
+        Payments:              t.Count,
+    }, nil
+}
+
+// GetEmployeePayHistory returns the pay history of an employee
+// @Summary Get employee pay history
+// @Description Salary payments of an employee paid in a year, newest first, with year-to-date gross, deductions and net totals
+// @Tags Salary
+// @Accept json
+// @Produce json
+// @Param employee_id path int true "Employee ID"
+// @Param year query int false "Calendar year of payment (default: current year)"
+// @Param limit query int false "Page size (default 50, max 500)"
+// @Param offset query int false "Entries to skip"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /salary/employee/{employee_id} [get]
+func (h *SalaryHandler) GetEmployeePayHistory(c *gin.Context) {
+    employeeID, err := strconv.ParseUint(c.Param("employee_id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid employee_id"})
+        return
+    }
+    limit, offset, ok := parsePagination(c)
+    if !ok {
+        return
+    }
+
+    year := time.Now().Year()
+    if v := c.Query("year"); v != "" {
+        if year, err = strconv.Atoi(v); err != nil || year < 1 {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "invalid year"})
+            return
+        }
+    }
+    yearStart := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+    yearEnd := yearStart.AddDate(1, 0, 0)
+
+    var payments []models.SalaryPayment
+    if err := h.DB.Where("employee_id = ? AND paid_at >= ? AND paid_at < ?", employeeID, yearStart, yearEnd).
+        Order("paid_at DESC, id DESC").Limit(limit).Offset(offset).
+        Find(&payments).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    ytd, err := yearToDate(h.DB, uint(employeeID), yearEnd.Add(-time.Nanosecond))
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "employee_id":  employeeID,
+        "year":         year,
+        "payments":     payments,
+        "year_to_date": ytd,
+        "limit":        limit,
+        "offset":       offset,
+    })
+}
@@ -0,0 +1,120 @@
+package delivery
+
+import (
+    "errors"
+    "fmt"
+    "net/http"
+    "regexp"
+    "strings"
+    "time"
+
+    "github.com/gin-gonic/gin"
+)
+
+const (
+    maxSaleBatchSize = 500
+    // Допустимое опережение часов кассы относительно сервера
+    maxClockSkew = 5 * time.Minute
+
+    batchStatusCreated   = "created"
+    batchStatusDuplicate = "duplicate"
+    batchStatusError     = "error"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+type batchSaleRequest struct {
+    createSaleRequest
+    TransactionTime time.Time `json:"transaction_time"` // RFC 3339, время продажи на кассе
+}
+
+type createSalesBatchRequest struct {
+    Sales []batchSaleRequest `json:"sales"`
+}
+
+type batchSaleResult struct {
+    ClientID      string  `json:"client_id"`
+    Status        string  `json:"status"` // created, duplicate или error
+    TransactionID uint    `json:"transaction_id,omitempty"`
+    ReceiptNumber string  `json:"receipt_number,omitempty"`
+    TotalAmount   float64 `json:"total_amount,omitempty"`
+    Error         string  `json:"error,omitempty"`
+}
+
+// CreateSalesBatch stores sales queued by an offline POS
+// @Summary Sync offline sales
+// @Description Store sales queued by a POS while it was offline. Each sale needs a client-generated UUID (client_id) and its original transaction_time, which is kept as the sale time. Sales are processed in order and independently: a sale whose client_id is already stored is reported as duplicate and not stored again, so a batch can be resent safely. Price mismatches are flagged instead of rejected and stock is deducted after the fact. At most 500 sales per request
+// @Tags Sales
+// @Accept json
+// @Produce json
+// @Param createSalesBatchRequest body createSalesBatchRequest true "Queued sales"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /sales/batch [post]
+func (h *SalesHandler) CreateSalesBatch(c *gin.Context) {
+    var req createSalesBatchRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    if len(req.Sales) == 0 || len(req.Sales) > maxSaleBatchSize {
+        c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("batch must contain 1 to %d sales", maxSaleBatchSize)})
+        return
+    }
+
+    results := make([]batchSaleResult, 0, len(req.Sales))
+    counts := map[string]int{}
+    for _, sale := range req.Sales {
+        result := h.processBatchSale(c, sale)
+        counts[result.Status]++
+        results = append(results, result)
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "results":    results,
+        "created":    counts[batchStatusCreated],
+        "duplicates": counts[batchStatusDuplicate],
+        "failed":     counts[batchStatusError],
+    })
+}
+
+func (h *SalesHandler) processBatchSale(c *gin.Context, sale batchSaleRequest) batchSaleResult {
+    // UUID сравниваем без учёта регистра
+    sale.ClientID = strings.ToLower(sale.ClientID)
+    result := batchSaleResult{ClientID: sale.ClientID, Status: batchStatusError}
+
+    switch {
+    case !uuidPattern.MatchString(sale.ClientID):
+        result.Error = "client_id must be a UUID"
+        return result
+    case sale.TransactionTime.IsZero():
+        result.Error = "transaction_time is required"
+        return result
+    case sale.TransactionTime.After(time.Now().Add(maxClockSkew)):
+        result.Error = "transaction_time is in the future"
+        return result
+    }
+
+    tx, duplicate, err := h.processSale(c.Request.Context(), sale.createSaleRequest, saleOptions{
+        Time:    sale.TransactionTime,
+        Offline: true,
+    })
+    if err != nil {
+        var se *saleError
+        if errors.As(err, &se) {
+            result.Error = se.Message
+        } else {
+            result.Error = err.Error()
+        }
+        return result
+    }
+
+    result.Status = batchStatusCreated
+    if duplicate {
+        result.Status = batchStatusDuplicate
+    }
+    result.TransactionID = tx.ID
+    result.ReceiptNumber = tx.ReceiptNumber
+    result.TotalAmount = tx.TotalAmount
+    return result
+}
@@ -1,47 +1,95 @@
 package delivery
 
 import (
-    "bytes"
-    "encoding/json"
+    "context"
+    "errors"
     "fmt"
+    "log"
     "net/http"
+    "os"
     "strconv"
+    "strings"
     "time"
 
+    "github.com/dibsnvas/golang-2025/internal/catalog"
     "github.com/dibsnvas/golang-2025/internal/models"
     "github.com/gin-gonic/gin"
     "gorm.io/gorm"
 )
 
+const defaultVoidWindow = 15 * time.Minute
+
 type SalesHandler struct {
     DB *gorm.DB
+    // VoidWindow — сколько времени после продажи кассир может аннулировать её сам
+    VoidWindow time.Duration
+    // Prices — сверка цен с каталогом; nil отключает проверку
+    Prices *PriceCheck
+    // Stock — синхронное резервирование остатков (STOCK_MODE=reserve); nil — асинхронное списание после продажи
+    Stock catalog.StockReserver
+    // Inventory — списание и возврат остатков после записи продажи
+    Inventory catalog.StockUpdater
+    // PointValue — стоимость одного балла лояльности при списании
+    PointValue float64
 }
 
-func NewSalesHandler(db *gorm.DB) *SalesHandler {
-    return &SalesHandler{DB: db}
+func NewSalesHandler(db *gorm.DB, client *catalog.Client) *SalesHandler {
+    window := defaultVoidWindow
+    if v := os.Getenv("SALE_VOID_WINDOW"); v != "" {
+        if d, err := time.ParseDuration(v); err == nil {
+            window = d
+        } else {
+            log.Printf("Invalid SALE_VOID_WINDOW %q, using %s", v, window)
+        }
+    }
+    return &SalesHandler{
+        DB:         db,
+        VoidWindow: window,
+        Prices:     NewPriceCheckFromEnv(client),
+        Stock:      stockReserverFromEnv(client),
+        Inventory:  client,
+        PointValue: loyaltyPointValueFromEnv(),
+    }
 }
 
 type createSaleRequest struct {
-    EmployeeID    uint    `json:"employee_id"`
-    ShopID        uint    `json:"shop_id"`
-    PaymentMethod string  `json:"payment_method"`
-    Items         []struct {
+    ClientID          string            `json:"client_id"` // UUID с кассы; повторная отправка не создаёт второй чек
+    EmployeeID        uint              `json:"employee_id"`
+    ShopID            uint              `json:"shop_id"`
+    PaymentMethod     string            `json:"payment_method"` // если payments не переданы — оплата одним способом на всю сумму
+    Payments          []paymentRequest  `json:"payments"`
+    Type              string            `json:"type"`                // "sale" (по умолчанию) или "return"
+    PriceOverrideBy   *uint             `json:"price_override_by"`   // менеджер, разрешивший цены не из каталога
+    PriceOverrideCode string            `json:"price_override_code"` // его код подтверждения
+    GiftCards         []giftCardRequest `json:"gift_cards"`          // продаваемые подарочные карты
+    CustomerID        *uint             `json:"customer_id"`         // покупатель; можно указать loyalty_card вместо него
+    LoyaltyCard       string            `json:"loyalty_card"`        // номер карты лояльности
+    RedeemPoints      int               `json:"redeem_points"`       // баллы, списываемые в счёт скидки на чек
+    Items             []struct {
         ItemID      uint    `json:"item_id"`
         Quantity    int     `json:"quantity"`
         PriceAtSale float64 `json:"price_at_sale"`
+        Category    string  `json:"category"`
+        TaxCategory string  `json:"tax_category"` // по умолчанию "standard"
     } `json:"items"`
 }
 
 // CreateSale registers a new sales transaction
 // @Summary Create a sales transaction
-// @Description Register a new sales transaction for an employee
+// @Description Register a new sales transaction for an employee. Active promotions for the shop are applied automatically, then tax is calculated per line using the shop tax settings; an item whose tax category has neither a shop nor a default rate is rejected with 400. Payments (split tender) must cover the total; cash overpayment is returned as change. With type "return" the item quantities and total are stored as negative amounts and stock is restocked. Sale prices are checked against the catalog: mismatches beyond PRICE_TOLERANCE and items unknown to the catalog are rejected with 422, and a catalog outage with 503 (both are flagged instead when PRICE_MISMATCH_MODE=flag), unless a manager approves the override with price_override_by and price_override_code. With STOCK_MODE=reserve all items are reserved in the catalog before the sale is stored; out-of-stock items return 409 and reservations are released on any failure. A repeated client_id returns the existing sale with 200. With customer_id or loyalty_card the sale is attached to a customer and earns loyalty points by the loyalty rules; points are redeemed as a check discount (redeem_points) or as a loyalty_points payment, at LOYALTY_POINT_VALUE per point
 // @Tags Sales
 // @Accept json
 // @Produce json
 // @Param createSaleRequest body createSaleRequest true "Sale data"
+// @Success 200 {object} map[string]interface{}
 // @Success 201 {object} map[string]interface{}
 // @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 422 {object} map[string]interface{}
+// @Failure 429 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
+// @Failure 503 {object} map[string]interface{}
 // @Router /sales [post]
 func (h *SalesHandler) CreateSale(c *gin.Context) {
     var req createSaleRequest
@@ -50,63 +98,435 @@ func (h *SalesHandler) CreateSale(c *gin.Context) {
         return
     }
 
-    tx := models.SalesTransaction{
+    if req.ClientID != "" {
+        req.ClientID = strings.ToLower(req.ClientID)
+        if !uuidPattern.MatchString(req.ClientID) {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "client_id must be a UUID"})
+            return
+        }
+    }
+
+    tx, duplicate, err := h.processSale(c.Request.Context(), req, saleOptions{Time: time.Now()})
+    if err != nil {
+        var se *saleError
+        if errors.As(err, &se) {
+            resp := gin.H{"error": se.Message}
+            if se.Details != nil {
+                resp["items"] = se.Details
+            }
+            c.JSON(se.Status, resp)
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
+        return
+    }
+
+    status := http.StatusCreated
+    if duplicate {
+        status = http.StatusOK
+    }
+    resp := gin.H{
+        "transaction_id": tx.ID,
+        "receipt_number": tx.ReceiptNumber,
+        "total_amount":   tx.TotalAmount,
+        "change_given":   changeGiven(tx),
+    }
+    // Коды выданных карт и возвратного кредита нужно передать покупателю
+    cards, err := issuedGiftCards(h.DB, tx.ID)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    if len(cards) > 0 {
+        resp["gift_cards"] = cards
+    }
+    c.JSON(status, resp)
+}
+
+// saleError is a processSale failure caused by the request. Status is the
+// HTTP status to answer with.
+type saleError struct {
+    Status  int
+    Message string
+    Details interface{}
+}
+
+func (e *saleError) Error() string {
+    return e.Message
+}
+
+type saleOptions struct {
+    Time time.Time
+    // Offline — продажа уже состоялась на кассе: расхождения цен только помечаются, остатки не резервируются
+    Offline bool
+}
+
+// processSale validates, prices and stores a sale and updates stock. Errors
+// of type *saleError are client errors. If a sale with the same client_id is
+// already stored it is returned with duplicate set and nothing else happens.
+func (h *SalesHandler) processSale(ctx context.Context, req createSaleRequest, opts saleOptions) (tx *models.SalesTransaction, duplicate bool, err error) {
+    if req.ClientID != "" {
+        if existing, err := findSaleByClientID(h.DB, req.ClientID); err != nil || existing != nil {
+            return existing, existing != nil, err
+        }
+    }
+
+    if req.Type == "" {
+        req.Type = models.TransactionTypeSale
+    }
+    if req.Type != models.TransactionTypeSale && req.Type != models.TransactionTypeReturn {
+        return nil, false, &saleError{Status: http.StatusBadRequest, Message: "type must be sale or return"}
+    }
+
+    tx = &models.SalesTransaction{
         EmployeeID:      req.EmployeeID,
         ShopID:          req.ShopID,
-        TransactionTime: time.Now(),
-        PaymentMethod:   req.PaymentMethod,
+        TransactionTime: opts.Time,
+        TransactionType: req.Type,
+    }
+    if req.ClientID != "" {
+        tx.ClientID = &req.ClientID
+    }
+
+    customer, err := findCustomer(h.DB, req.CustomerID, strings.TrimSpace(req.LoyaltyCard))
+    if err != nil {
+        return nil, false, err
+    }
+    if customer != nil {
+        tx.CustomerID = &customer.ID
     }
 
-    var total float64
+    // Возвраты храним с отрицательным количеством, чтобы суммы сворачивались сами
+    sign := 1
+    if req.Type == models.TransactionTypeReturn {
+        sign = -1
+    }
+
+    var subtotal float64
     var saleItems []models.SaleItem
     for _, item := range req.Items {
-        total += float64(item.Quantity) * item.PriceAtSale
+        if item.Quantity <= 0 || item.PriceAtSale < 0 {
+            return nil, false, &saleError{Status: http.StatusBadRequest, Message: "item quantity must be positive and price_at_sale must not be negative"}
+        }
+        quantity := sign * item.Quantity
+        subtotal += float64(quantity) * item.PriceAtSale
         saleItems = append(saleItems, models.SaleItem{
-            ItemID:      item.ItemID,
-            Quantity:    item.Quantity,
-            PriceAtSale: item.PriceAtSale,
+            ItemID:        item.ItemID,
+            Quantity:      quantity,
+            OriginalPrice: item.PriceAtSale,
+            PriceAtSale:   item.PriceAtSale,
+            Category:      item.Category,
+            TaxCategory:   item.TaxCategory,
         })
     }
-    tx.TotalAmount = total
+    tx.SubtotalAmount = roundMoney(subtotal)
+
+    // Цены возвратов берутся из исходного чека, сверяем только продажи
+    if req.Type == models.TransactionTypeSale {
+        if req.PriceOverrideBy != nil {
+            ok, err := checkManagerApproval(h.DB, *req.PriceOverrideBy, req.PriceOverrideCode)
+            if errors.Is(err, errApprovalLocked) {
+                return nil, false, &saleError{Status: http.StatusTooManyRequests, Message: err.Error()}
+            }
+            if err != nil {
+                return nil, false, err
+            }
+            if !ok {
+                return nil, false, &saleError{Status: http.StatusForbidden, Message: "invalid price override approval"}
+            }
+        }
+        prices := h.Prices
+        if opts.Offline && prices != nil {
+            flagOnly := *prices
+            flagOnly.Mode = priceMismatchFlag
+            prices = &flagOnly
+        }
+        mismatches, err := prices.Apply(ctx, saleItems, req.PriceOverrideBy)
+        if err != nil {
+            return nil, false, err
+        }
+        if len(mismatches) > 0 {
+            return nil, false, &saleError{Status: http.StatusUnprocessableEntity, Message: "prices differ from catalog or items are unknown to it", Details: mismatches}
+        }
+        for _, it := range saleItems {
+            tx.PriceFlagged = tx.PriceFlagged || it.PriceFlagged
+        }
+    }
     tx.SaleItems = saleItems
 
-    if err := h.DB.Create(&tx).Error; err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-        return
+    // Акции применяются только к продажам; возврат проводится по ценам из запроса
+    if req.Type == models.TransactionTypeSale {
+        if err := applyPromotions(h.DB, tx); err != nil {
+            return nil, false, err
+        }
     }
 
-    for _, item := range saleItems {
-        go func(it models.SaleItem) {
-            payload := map[string]interface{}{
-                "item_id":  it.ItemID,
-                "quantity": it.Quantity,
-            }
+    // Баллы списываются как скидка на чек после акций
+    if req.RedeemPoints != 0 {
+        switch {
+        case req.RedeemPoints < 0 || req.Type != models.TransactionTypeSale:
+            return nil, false, &saleError{Status: http.StatusBadRequest, Message: "redeem_points must be positive and can only be used in a sale"}
+        case customer == nil:
+            return nil, false, &saleError{Status: http.StatusBadRequest, Message: "redeem_points requires a customer"}
+        }
+        var total float64
+        for _, item := range tx.SaleItems {
+            total += lineRevenue(item)
+        }
+        discount := roundMoney(float64(req.RedeemPoints) * h.PointValue)
+        if discount > roundMoney(total) {
+            return nil, false, &saleError{Status: http.StatusBadRequest, Message: "redeemed points exceed the sale amount"}
+        }
+        applyPointsDiscount(tx, discount)
+        tx.PointsRedeemed = req.RedeemPoints
+    }
 
-            body, err := json.Marshal(payload)
-            if err != nil {
-                fmt.Printf("Failed to marshal payload: %v\n", err)
-                return
-            }
+    // Подарочные карты добавляются после акций, чтобы скидки на них не распределялись
+    if len(req.GiftCards) > 0 && req.Type != models.TransactionTypeSale {
+        return nil, false, &saleError{Status: http.StatusBadRequest, Message: "gift cards can only be sold in a sale"}
+    }
+    for _, gc := range req.GiftCards {
+        if gc.Amount <= 0 {
+            return nil, false, &saleError{Status: http.StatusBadRequest, Message: "gift card amount must be positive"}
+        }
+        tx.SaleItems = append(tx.SaleItems, giftCardLine(gc))
+        tx.SubtotalAmount = roundMoney(tx.SubtotalAmount + gc.Amount)
+    }
+    if err := applyTax(h.DB, tx); err != nil {
+        return nil, false, err
+    }
+    if customer != nil {
+        if tx.PointsEarned, err = earnedPoints(h.DB, tx); err != nil {
+            return nil, false, err
+        }
+    }
 
-            resp, err := http.Post(
-                "http://catalog-service/inventory/deduct",
-                "application/json",
-                bytes.NewBuffer(body),
-            )
-            if err != nil {
-                fmt.Printf("Failed to notify catalog service: %v\n", err)
-                return
+    if len(req.Payments) == 0 && req.PaymentMethod != "" {
+        req.Payments = []paymentRequest{{Method: req.PaymentMethod, Amount: tx.TotalAmount}}
+    }
+    allowed, err := allowedPaymentMethods(h.DB, tx.ShopID)
+    if err != nil {
+        return nil, false, err
+    }
+    tx.Payments, tx.PaymentMethod, err = buildPayments(allowed, req.Payments, tx.TotalAmount)
+    if err != nil {
+        return nil, false, &saleError{Status: http.StatusBadRequest, Message: err.Error()}
+    }
+    for _, p := range tx.Payments {
+        if p.Method == models.PaymentMethodLoyaltyPoints && customer == nil {
+            return nil, false, &saleError{Status: http.StatusBadRequest, Message: "loyalty_points payments require a customer"}
+        }
+    }
+
+    // В режиме резервирования продажа записывается, только если зарезервированы все позиции
+    var reservation *stockReservation
+    if h.Stock != nil && !opts.Offline && req.Type == models.TransactionTypeSale && len(stockItems(tx.SaleItems)) > 0 {
+        reservation, err = reserveStock(ctx, h.Stock, tx.SaleItems)
+        if errors.Is(err, catalog.ErrOutOfStock) {
+            return nil, false, &saleError{Status: http.StatusConflict, Message: err.Error()}
+        }
+        if err != nil {
+            return nil, false, &saleError{Status: http.StatusServiceUnavailable, Message: "stock reservation failed: " + err.Error()}
+        }
+    }
+
+    err = h.DB.Transaction(func(db *gorm.DB) error {
+        if err := allocateReceiptNumber(db, tx); err != nil {
+            return err
+        }
+        // Балансы карт меняются в той же транзакции, что и запись продажи
+        movements, err := settleGiftCards(db, tx, req.GiftCards)
+        if err != nil {
+            return err
+        }
+        points, err := settleLoyalty(db, tx, h.PointValue)
+        if err != nil {
+            return err
+        }
+        if err := db.Create(tx).Error; err != nil {
+            return err
+        }
+        if err := recordLoyaltyTransactions(db, tx.ID, points); err != nil {
+            return err
+        }
+        return recordGiftCardMovements(db, tx.ID, movements)
+    })
+    if err != nil {
+        if reservation != nil {
+            reservation.release()
+        }
+        var se *saleError
+        if errors.As(err, &se) {
+            return nil, false, se
+        }
+        // Параллельный запрос с тем же client_id успел записать продажу первым
+        if req.ClientID != "" {
+            if existing, findErr := findSaleByClientID(h.DB, req.ClientID); findErr == nil && existing != nil {
+                return existing, true, nil
             }
-            defer resp.Body.Close()
-            fmt.Printf("Notified catalog service for item_id=%d, status=%s\n", it.ItemID, resp.Status)
-        }(item)
+        }
+        return nil, false, err
+    }
+
+    switch {
+    case reservation != nil:
+        reservation.commit(h.Inventory, fmt.Sprintf("sale-%d-deduct", tx.ID), tx.SaleItems)
+    case req.Type == models.TransactionTypeReturn:
+        updateStockAsync(h.Inventory, false, fmt.Sprintf("sale-%d-restock", tx.ID), tx.SaleItems)
+    default:
+        updateStockAsync(h.Inventory, true, fmt.Sprintf("sale-%d-deduct", tx.ID), tx.SaleItems)
+    }
+
+    return tx, false, nil
+}
+
+// findSaleByClientID returns the sale stored with clientID, or nil.
+func findSaleByClientID(db *gorm.DB, clientID string) (*models.SalesTransaction, error) {
+    var tx models.SalesTransaction
+    if err := db.Preload("Payments").Where("client_id = ?", clientID).Limit(1).Find(&tx).Error; err != nil {
+        return nil, err
+    }
+    if tx.ID == 0 {
+        return nil, nil
+    }
+    return &tx, nil
+}
+
+func changeGiven(tx *models.SalesTransaction) float64 {
+    var change float64
+    for _, p := range tx.Payments {
+        change += p.ChangeGiven
+    }
+    return change
+}
+
+type voidSaleRequest struct {
+    EmployeeID   uint   `json:"employee_id"` // кто аннулирует
+    Reason       string `json:"reason"`
+    ManagerID    uint   `json:"manager_id"`    // нужен после окна аннулирования
+    ApprovalCode string `json:"approval_code"` // код подтверждения менеджера
+}
+
+// VoidSale voids a sales transaction
+// @Summary Void a sales transaction
+// @Description Mark a sale as voided. The cashier who rang it up can void it within the void window (SALE_VOID_WINDOW, default 15m); otherwise a manager approval code is required. Voided sales are kept, excluded from all totals, and their stock movement, gift card movements and loyalty points are reversed
+// @Tags Sales
+// @Accept json
+// @Produce json
+// @Param id path int true "Transaction ID"
+// @Param voidSaleRequest body voidSaleRequest true "Void data"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 429 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /sales/{id}/void [post]
+func (h *SalesHandler) VoidSale(c *gin.Context) {
+    id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+        return
     }
 
-    c.JSON(http.StatusCreated, gin.H{"transaction_id": tx.ID})
+    var req voidSaleRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    if req.EmployeeID == 0 || req.Reason == "" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "employee_id and reason are required"})
+        return
+    }
+
+    var tx models.SalesTransaction
+    if err := h.DB.Preload("SaleItems").First(&tx, id).Error; err != nil {
+        if err == gorm.ErrRecordNotFound {
+            c.JSON(http.StatusNotFound, gin.H{"error": "transaction not found"})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
+        return
+    }
+    if tx.Status == models.SaleStatusVoided {
+        c.JSON(http.StatusConflict, gin.H{"error": "transaction is already voided"})
+        return
+    }
+
+    now := time.Now()
+    updates := map[string]interface{}{
+        "status":      models.SaleStatusVoided,
+        "voided_at":   now,
+        "voided_by":   req.EmployeeID,
+        "void_reason": req.Reason,
+    }
+
+    // Без менеджера — только свой чек и только в пределах окна
+    ownInWindow := req.EmployeeID == tx.EmployeeID && now.Sub(tx.TransactionTime) <= h.VoidWindow
+    if !ownInWindow {
+        if req.ManagerID == 0 || req.ApprovalCode == "" {
+            c.JSON(http.StatusForbidden, gin.H{"error": "manager approval is required to void this sale"})
+            return
+        }
+        ok, err := checkManagerApproval(h.DB, req.ManagerID, req.ApprovalCode)
+        if err != nil {
+            c.JSON(approvalErrorStatus(err), gin.H{"error": err.Error()})
+            return
+        }
+        if !ok {
+            c.JSON(http.StatusForbidden, gin.H{"error": "invalid manager approval"})
+            return
+        }
+        updates["void_approved_by"] = req.ManagerID
+    }
+
+    // Условие на статус защищает от двойного аннулирования параллельными запросами
+    err = h.DB.Transaction(func(db *gorm.DB) error {
+        res := db.Model(&models.SalesTransaction{}).
+            Where("id = ? AND status <> ?", tx.ID, models.SaleStatusVoided).
+            Updates(updates)
+        if res.Error != nil {
+            return res.Error
+        }
+        if res.RowsAffected == 0 {
+            return &saleError{Status: http.StatusConflict, Message: "transaction is already voided"}
+        }
+        if err := reverseGiftCards(db, tx.ID); err != nil {
+            return err
+        }
+        return reverseLoyalty(db, tx.ID)
+    })
+    if err != nil {
+        var se *saleError
+        if errors.As(err, &se) {
+            c.JSON(se.Status, gin.H{"error": se.Message})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
+        return
+    }
+
+    // Аннулирование продажи возвращает товар на склад, аннулирование возврата — списывает
+    isReturn := tx.TransactionType == models.TransactionTypeReturn
+    updateStockAsync(h.Inventory, isReturn, fmt.Sprintf("sale-%d-void", tx.ID), tx.SaleItems)
+
+    c.JSON(http.StatusOK, gin.H{
+        "transaction_id": tx.ID,
+        "status":         models.SaleStatusVoided,
+        "voided_at":      now,
+    })
 }
+
+// completedSales excludes voided transactions; every total over
+// sales_transactions must use it.
+func completedSales(db *gorm.DB) *gorm.DB {
+    return db.Where("sales_transactions.status <> ?", models.SaleStatusVoided)
+}
+
 // GetSalesByEmployeeAndDate returns sales for a specific employee on a specific date
 // @Summary Get sales by employee and date
-// @Description Get total sales count and amount by employee ID and date
+// @Description Get total sales count and amount by employee ID and date; voided sales are excluded
 // @Tags Sales
 // @Accept json
 // @Produce json
@@ -141,7 +561,7 @@ func (h *SalesHandler) GetSalesByEmployeeAndDate(c *gin.Context) {
 
     // Выбираем все транзакции, где employee_id=? и transaction_time в этот день
     var sales []models.SalesTransaction
-    if err := h.DB.Where(
+    if err := h.DB.Scopes(completedSales).Where(
         "employee_id = ? AND transaction_time >= ? AND transaction_time < ?",
         employeeID, startOfDay, endOfDay,
     ).Find(&sales).Error; err != nil {
@@ -149,9 +569,13 @@ func (h *SalesHandler) GetSalesByEmployeeAndDate(c *gin.Context) {
         return
     }
 
-    countChecks := len(sales)
+    // Возвраты уменьшают сумму, но чеком продажи не считаются
+    var countChecks int
     var totalAmount float64
     for _, s := range sales {
+        if s.TransactionType != models.TransactionTypeReturn {
+            countChecks++
+        }
         totalAmount += s.TotalAmount
     }
 
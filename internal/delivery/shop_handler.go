@@ -0,0 +1,177 @@
+package delivery
+
+import (
+    htmltemplate "html/template"
+    "net/http"
+    "strconv"
+    "text/template"
+
+    "github.com/dibsnvas/golang-2025/internal/models"
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+)
+
+type ShopHandler struct {
+    DB *gorm.DB
+}
+
+func NewShopHandler(db *gorm.DB) *ShopHandler {
+    return &ShopHandler{DB: db}
+}
+
+type shopRequest struct {
+    Name                string `json:"name"`
+    Address             string `json:"address"`
+    Phone               string `json:"phone"`
+    TaxNumber           string `json:"tax_number"`
+    ReceiptHeader       string `json:"receipt_header"`
+    ReceiptFooter       string `json:"receipt_footer"`
+    ReceiptPrefix       string `json:"receipt_prefix"`     // {SHOP}, {YYYY}, {YY}
+    ReceiptContinuous   bool   `json:"receipt_continuous"` // не сбрасывать нумерацию в начале года
+    ReceiptTextTemplate string `json:"receipt_text_template"`
+    ReceiptHTMLTemplate string `json:"receipt_html_template"`
+}
+
+// validate checks the request and that custom receipt templates parse.
+func (r shopRequest) validate() string {
+    if r.Name == "" {
+        return "name is required"
+    }
+    if r.ReceiptTextTemplate != "" {
+        if _, err := template.New("receipt").Funcs(receiptTextFuncs(receiptDefaultWidth)).Parse(r.ReceiptTextTemplate); err != nil {
+            return "invalid receipt_text_template: " + err.Error()
+        }
+    }
+    if r.ReceiptHTMLTemplate != "" {
+        if _, err := htmltemplate.New("receipt").Funcs(receiptHTMLFuncs).Parse(r.ReceiptHTMLTemplate); err != nil {
+            return "invalid receipt_html_template: " + err.Error()
+        }
+    }
+    return ""
+}
+
+func (r shopRequest) apply(shop *models.Shop) {
+    shop.Name = r.Name
+    shop.Address = r.Address
+    shop.Phone = r.Phone
+    shop.TaxNumber = r.TaxNumber
+    shop.ReceiptHeader = r.ReceiptHeader
+    shop.ReceiptFooter = r.ReceiptFooter
+    shop.ReceiptPrefix = r.ReceiptPrefix
+    shop.ReceiptContinuous = r.ReceiptContinuous
+    shop.ReceiptTextTemplate = r.ReceiptTextTemplate
+    shop.ReceiptHTMLTemplate = r.ReceiptHTMLTemplate
+}
+
+// CreateShop registers a new shop
+// @Summary Create a shop
+// @Description Register a shop with its receipt details, receipt numbering settings and optional receipt templates
+// @Tags Shops
+// @Accept json
+// @Produce json
+// @Param shopRequest body shopRequest true "Shop data"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /shops [post]
+func (h *ShopHandler) CreateShop(c *gin.Context) {
+    var req shopRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    if msg := req.validate(); msg != "" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+        return
+    }
+
+    var shop models.Shop
+    req.apply(&shop)
+    if err := h.DB.Create(&shop).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusCreated, gin.H{"shop_id": shop.ID})
+}
+
+// GetShop returns a shop by ID
+// @Summary Get shop by ID
+// @Description Retrieve shop details
+// @Tags Shops
+// @Accept json
+// @Produce json
+// @Param id path int true "Shop ID"
+// @Success 200 {object} models.Shop
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /shops/{id} [get]
+func (h *ShopHandler) GetShop(c *gin.Context) {
+    id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+        return
+    }
+
+    var shop models.Shop
+    if err := h.DB.First(&shop, id).Error; err != nil {
+        if err == gorm.ErrRecordNotFound {
+            c.JSON(http.StatusNotFound, gin.H{"error": "shop not found"})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
+        return
+    }
+
+    c.JSON(http.StatusOK, shop)
+}
+
+// UpdateShop replaces shop details
+// @Summary Update a shop
+// @Description Replace shop details, including receipt header, footer and templates
+// @Tags Shops
+// @Accept json
+// @Produce json
+// @Param id path int true "Shop ID"
+// @Param shopRequest body shopRequest true "Shop data"
+// @Success 200 {object} models.Shop
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /shops/{id} [put]
+func (h *ShopHandler) UpdateShop(c *gin.Context) {
+    id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+        return
+    }
+
+    var req shopRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    if msg := req.validate(); msg != "" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+        return
+    }
+
+    var shop models.Shop
+    if err := h.DB.First(&shop, id).Error; err != nil {
+        if err == gorm.ErrRecordNotFound {
+            c.JSON(http.StatusNotFound, gin.H{"error": "shop not found"})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
+        return
+    }
+
+    req.apply(&shop)
+    if err := h.DB.Save(&shop).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, shop)
+}
@@ -0,0 +1,157 @@
+package delivery
+
+import (
+    "context"
+    "crypto/rand"
+    "encoding/hex"
+    "log"
+    "os"
+    "time"
+
+    "github.com/dibsnvas/golang-2025/internal/catalog"
+    "github.com/dibsnvas/golang-2025/internal/models"
+)
+
+const (
+    stockModeAsync   = "async"
+    stockModeReserve = "reserve"
+
+    // Асинхронное обновление остатков не ограничено запросом, но должно завершиться
+    stockUpdateTimeout = 30 * time.Second
+
+    // Подтверждение резервации повторяется с паузами 5, 10 и 20 секунд
+    stockCommitAttempts   = 4
+    stockCommitRetryDelay = 5 * time.Second
+)
+
+// stockReserverFromEnv returns the client as a reserver when
+// STOCK_MODE=reserve and nil in the default async mode.
+func stockReserverFromEnv(client *catalog.Client) catalog.StockReserver {
+    mode := os.Getenv("STOCK_MODE")
+    switch mode {
+    case "", stockModeAsync:
+        return nil
+    case stockModeReserve:
+        return client
+    default:
+        log.Printf("Invalid STOCK_MODE %q, using %s", mode, stockModeAsync)
+        return nil
+    }
+}
+
+// stockItems converts sale lines to catalog lines with positive quantities.
+// Gift card lines are not stock.
+func stockItems(items []models.SaleItem) []catalog.StockItem {
+    out := make([]catalog.StockItem, 0, len(items))
+    for _, it := range items {
+        if isGiftCardLine(it) {
+            continue
+        }
+        quantity := it.Quantity
+        if quantity < 0 {
+            quantity = -quantity
+        }
+        out = append(out, catalog.StockItem{ItemID: it.ItemID, Quantity: quantity})
+    }
+    return out
+}
+
+// updateStockAsync deducts or restocks the items of a stored sale in the
+// background. The key makes retries safe on the catalog side.
+func updateStockAsync(stock catalog.StockUpdater, deduct bool, key string, items []models.SaleItem) {
+    lines := stockItems(items)
+    if stock == nil || len(lines) == 0 {
+        return
+    }
+    go func() {
+        ctx, cancel := context.WithTimeout(context.Background(), stockUpdateTimeout)
+        defer cancel()
+
+        var err error
+        if deduct {
+            err = stock.Deduct(ctx, key, lines)
+        } else {
+            err = stock.Restock(ctx, key, lines)
+        }
+        if err != nil {
+            log.Printf("Failed to update stock (%s): %v", key, err)
+        }
+    }()
+}
+
+func newRequestKey() string {
+    b := make([]byte, 16)
+    if _, err := rand.Read(b); err != nil {
+        return ""
+    }
+    return hex.EncodeToString(b)
+}
+
+// stockReservation is the saga around a sale: the items are reserved before
+// the sale is written, the reservation is released if anything fails and
+// committed once the sale is stored.
+type stockReservation struct {
+    stock catalog.StockReserver
+    id    string
+}
+
+// reserveStock reserves all items of a sale in one call. The error wraps
+// catalog.ErrOutOfStock when an item is not available.
+func reserveStock(ctx context.Context, stock catalog.StockReserver, items []models.SaleItem) (*stockReservation, error) {
+    id, err := stock.Reserve(ctx, "reserve-"+newRequestKey(), stockItems(items))
+    if err != nil {
+        return nil, err
+    }
+    return &stockReservation{stock: stock, id: id}, nil
+}
+
+// release is the compensation step. It runs detached from the request
+// context so a cancelled request still returns its stock; a reservation that
+// cannot be released expires on the catalog side.
+func (r *stockReservation) release() {
+    ctx, cancel := context.WithTimeout(context.Background(), stockUpdateTimeout)
+    defer cancel()
+    if err := r.stock.Release(ctx, r.id); err != nil {
+        log.Printf("Failed to release stock reservation %s: %v", r.id, err)
+    }
+}
+
+// commit confirms the reservation after the sale has been stored. The sale
+// is already final, so commit retries in the background. If the reservation
+// still cannot be committed it is released and the items are deducted with
+// the sale's idempotency key instead, so the stock does not come back when
+// the reservation expires.
+func (r *stockReservation) commit(inventory catalog.StockUpdater, key string, items []models.SaleItem) {
+    lines := stockItems(items)
+    go func() {
+        delay := stockCommitRetryDelay
+        for attempt := 1; ; attempt++ {
+            ctx, cancel := context.WithTimeout(context.Background(), stockUpdateTimeout)
+            err := r.stock.Commit(ctx, r.id)
+            cancel()
+            if err == nil {
+                return
+            }
+            log.Printf("Failed to commit stock reservation %s (attempt %d): %v", r.id, attempt, err)
+            if attempt == stockCommitAttempts {
+                break
+            }
+            time.Sleep(delay)
+            delay *= 2
+        }
+
+        ctx, cancel := context.WithTimeout(context.Background(), stockUpdateTimeout)
+        defer cancel()
+        // Если резервацию не снять, неизвестно, списан ли уже остаток: второе списание задвоило бы его
+        if err := r.stock.Release(ctx, r.id); err != nil {
+            log.Printf("Failed to release stock reservation %s, stock for %s needs a manual check: %v", r.id, key, err)
+            return
+        }
+        if inventory == nil {
+            return
+        }
+        if err := inventory.Deduct(ctx, key, lines); err != nil {
+            log.Printf("Failed to update stock (%s): %v", key, err)
+        }
+    }()
+}
@@ -0,0 +1,297 @@
+package delivery
+
+import (
+    "fmt"
+    "math"
+    "net/http"
+    "strconv"
+
+    "github.com/dibsnvas/golang-2025/internal/models"
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+    "gorm.io/gorm/clause"
+)
+
+type TaxHandler struct {
+    DB *gorm.DB
+}
+
+func NewTaxHandler(db *gorm.DB) *TaxHandler {
+    return &TaxHandler{DB: db}
+}
+
+type setTaxRateRequest struct {
+    Category string  `json:"category"`
+    ShopID   uint    `json:"shop_id"` // 0 — ставка по умолчанию для всех магазинов
+    Rate     float64 `json:"rate"`    // проценты: 12 = 12%
+}
+
+// SetTaxRate creates or updates the rate of a tax category
+// @Summary Set a tax rate
+// @Description Create or update the rate (in percent) of a tax category, for all shops (shop_id 0) or one shop. Sales with a category that has no rate for their shop and no default rate are rejected, so exempt categories need a rate of 0
+// @Tags Tax
+// @Accept json
+// @Produce json
+// @Param setTaxRateRequest body setTaxRateRequest true "Tax rate"
+// @Success 200 {object} models.TaxRate
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /tax/rates [post]
+func (h *TaxHandler) SetTaxRate(c *gin.Context) {
+    var req setTaxRateRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    if req.Category == "" || req.Rate < 0 || req.Rate >= 100 {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "category is required and rate must be between 0 and 100"})
+        return
+    }
+
+    rate := models.TaxRate{Category: req.Category, ShopID: req.ShopID, Rate: req.Rate}
+    if err := h.DB.Clauses(clause.OnConflict{
+        Columns:   []clause.Column{{Name: "category"}, {Name: "shop_id"}},
+        DoUpdates: clause.AssignmentColumns([]string{"rate", "updated_at"}),
+    }).Create(&rate).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, rate)
+}
+
+// ListTaxRates returns configured tax rates
+// @Summary List tax rates
+// @Description List tax rates, optionally only those that apply to a shop (shop-specific and defaults)
+// @Tags Tax
+// @Accept json
+// @Produce json
+// @Param shop_id query int false "Shop ID"
+// @Success 200 {array} models.TaxRate
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /tax/rates [get]
+func (h *TaxHandler) ListTaxRates(c *gin.Context) {
+    query := h.DB.Order("shop_id, category")
+    if v := c.Query("shop_id"); v != "" {
+        shopID, err := strconv.ParseUint(v, 10, 64)
+        if err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "invalid shop_id"})
+            return
+        }
+        query = query.Where("shop_id IN ?", []uint64{0, shopID})
+    }
+
+    var rates []models.TaxRate
+    if err := query.Find(&rates).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, rates)
+}
+
+type shopTaxSettingsRequest struct {
+    PricesIncludeTax bool   `json:"prices_include_tax"`
+    RoundingMode     string `json:"rounding_mode"`  // half_up (по умолчанию), half_even, down
+    RoundingLevel    string `json:"rounding_level"` // line (по умолчанию) или total
+}
+
+// SetShopTaxSettings configures tax pricing of a shop
+// @Summary Set shop tax settings
+// @Description Configure whether shop prices include tax and how tax is rounded
+// @Tags Tax
+// @Accept json
+// @Produce json
+// @Param shop_id path int true "Shop ID"
+// @Param shopTaxSettingsRequest body shopTaxSettingsRequest true "Tax settings"
+// @Success 200 {object} models.ShopTaxSettings
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /tax/shops/{shop_id} [put]
+func (h *TaxHandler) SetShopTaxSettings(c *gin.Context) {
+    shopID, err := strconv.ParseUint(c.Param("shop_id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid shop_id"})
+        return
+    }
+
+    var req shopTaxSettingsRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    if req.RoundingMode == "" {
+        req.RoundingMode = models.TaxRoundingHalfUp
+    }
+    if req.RoundingLevel == "" {
+        req.RoundingLevel = models.TaxRoundingPerLine
+    }
+    switch req.RoundingMode {
+    case models.TaxRoundingHalfUp, models.TaxRoundingHalfEven, models.TaxRoundingDown:
+    default:
+        c.JSON(http.StatusBadRequest, gin.H{"error": "rounding_mode must be half_up, half_even or down"})
+        return
+    }
+    if req.RoundingLevel != models.TaxRoundingPerLine && req.RoundingLevel != models.TaxRoundingPerTotal {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "rounding_level must be line or total"})
+        return
+    }
+
+    settings := models.ShopTaxSettings{
+        ShopID:           uint(shopID),
+        PricesIncludeTax: req.PricesIncludeTax,
+        RoundingMode:     req.RoundingMode,
+        RoundingLevel:    req.RoundingLevel,
+    }
+    if err := h.DB.Save(&settings).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, settings)
+}
+
+// GetShopTaxSettings returns tax pricing of a shop
+// @Summary Get shop tax settings
+// @Description Retrieve tax settings of a shop; shops without settings use tax-inclusive prices with half-up rounding per line
+// @Tags Tax
+// @Accept json
+// @Produce json
+// @Param shop_id path int true "Shop ID"
+// @Success 200 {object} models.ShopTaxSettings
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /tax/shops/{shop_id} [get]
+func (h *TaxHandler) GetShopTaxSettings(c *gin.Context) {
+    shopID, err := strconv.ParseUint(c.Param("shop_id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid shop_id"})
+        return
+    }
+
+    settings, err := loadShopTaxSettings(h.DB, uint(shopID))
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, settings)
+}
+
+func loadShopTaxSettings(db *gorm.DB, shopID uint) (models.ShopTaxSettings, error) {
+    settings := models.ShopTaxSettings{
+        ShopID:           shopID,
+        PricesIncludeTax: true,
+        RoundingMode:     models.TaxRoundingHalfUp,
+        RoundingLevel:    models.TaxRoundingPerLine,
+    }
+    err := db.Where("shop_id = ?", shopID).Limit(1).Find(&settings).Error
+    return settings, err
+}
+
+// roundTax rounds a tax amount to cents using one of the TaxRounding* modes.
+// Negative amounts (returns) are rounded symmetrically.
+func roundTax(v float64, mode string) float64 {
+    cents := toCents(v)
+    switch mode {
+    case models.TaxRoundingHalfEven:
+        return math.RoundToEven(cents) / 100
+    case models.TaxRoundingDown:
+        return math.Trunc(cents) / 100
+    default:
+        return math.Round(cents) / 100
+    }
+}
+
+// applyTax fills net, tax and gross amounts of every item and of the
+// transaction according to the shop tax settings. It must run after
+// applyPromotions, because tax is charged on discounted amounts. A category
+// with neither a shop nor a default rate is a *saleError; only gift cards are
+// untaxed without a rate.
+func applyTax(db *gorm.DB, tx *models.SalesTransaction) error {
+    settings, err := loadShopTaxSettings(db, tx.ShopID)
+    if err != nil {
+        return err
+    }
+
+    var rates []models.TaxRate
+    if err := db.Where("shop_id IN ?", []uint{0, tx.ShopID}).Find(&rates).Error; err != nil {
+        return err
+    }
+    byCategory := map[string]float64{}
+    for _, r := range rates {
+        // Ставка магазина важнее ставки по умолчанию
+        if _, ok := byCategory[r.Category]; !ok || r.ShopID != 0 {
+            byCategory[r.Category] = r.Rate
+        }
+    }
+
+    tx.PricesIncludeTax = settings.PricesIncludeTax
+    exactTax := make([]float64, len(tx.SaleItems))
+    var exactTotal float64
+    tx.NetAmount, tx.TaxAmount, tx.TotalAmount = 0, 0, 0
+    for i := range tx.SaleItems {
+        item := &tx.SaleItems[i]
+        if item.TaxCategory == "" {
+            item.TaxCategory = models.DefaultTaxCategory
+        }
+        rate, ok := byCategory[item.TaxCategory]
+        if !ok && item.TaxCategory != models.GiftCardTaxCategory {
+            return &saleError{Status: http.StatusBadRequest, Message: fmt.Sprintf("no tax rate for category %s in shop %d", item.TaxCategory, tx.ShopID)}
+        }
+        item.TaxRate = rate
+        amount := roundMoney(lineRevenue(*item))
+        r := item.TaxRate / 100
+
+        if settings.PricesIncludeTax {
+            exactTax[i] = amount - amount/(1+r)
+        } else {
+            exactTax[i] = amount * r
+        }
+        exactTotal += exactTax[i]
+
+        if settings.RoundingLevel == models.TaxRoundingPerTotal {
+            item.TaxAmount = roundMoney(exactTax[i])
+        } else {
+            item.TaxAmount = roundTax(exactTax[i], settings.RoundingMode)
+        }
+        if settings.PricesIncludeTax {
+            item.GrossAmount = amount
+            item.NetAmount = roundMoney(amount - item.TaxAmount)
+        } else {
+            item.NetAmount = amount
+            item.GrossAmount = roundMoney(amount + item.TaxAmount)
+        }
+    }
+
+    // При округлении по итогу разница округления уходит в последнюю строку
+    if settings.RoundingLevel == models.TaxRoundingPerTotal && len(tx.SaleItems) > 0 {
+        var lineTotal float64
+        for _, item := range tx.SaleItems {
+            lineTotal += item.TaxAmount
+        }
+        if diff := roundMoney(roundTax(exactTotal, settings.RoundingMode) - lineTotal); diff != 0 {
+            last := &tx.SaleItems[len(tx.SaleItems)-1]
+            last.TaxAmount = roundMoney(last.TaxAmount + diff)
+            if settings.PricesIncludeTax {
+                last.NetAmount = roundMoney(last.GrossAmount - last.TaxAmount)
+            } else {
+                last.GrossAmount = roundMoney(last.NetAmount + last.TaxAmount)
+            }
+        }
+    }
+
+    for _, item := range tx.SaleItems {
+        tx.NetAmount += item.NetAmount
+        tx.TaxAmount += item.TaxAmount
+        tx.TotalAmount += item.GrossAmount
+    }
+    tx.NetAmount = roundMoney(tx.NetAmount)
+    tx.TaxAmount = roundMoney(tx.TaxAmount)
+    tx.TotalAmount = roundMoney(tx.TotalAmount)
+
+    return nil
+}
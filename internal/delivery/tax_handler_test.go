@@ -0,0 +1,40 @@
+package delivery
+
+import (
+    "testing"
+
+    "github.com/dibsnvas/golang-2025/internal/models"
+)
+
+func TestRoundTax(t *testing.T) {
+    tests := []struct {
+        v                      float64
+        halfUp, halfEven, down float64
+    }{
+        {0.29, 0.29, 0.29, 0.29},
+        {1.005, 1.01, 1.00, 1.00},
+        {1.015, 1.02, 1.02, 1.01},
+        {0.125, 0.13, 0.12, 0.12},
+        {2.675, 2.68, 2.68, 2.67},
+        {1.999, 2.00, 2.00, 1.99},
+        {-0.29, -0.29, -0.29, -0.29},
+        {-1.005, -1.01, -1.00, -1.00},
+        {-0.125, -0.13, -0.12, -0.12},
+        {-1.999, -2.00, -2.00, -1.99},
+        {0, 0, 0, 0},
+    }
+    for _, tt := range tests {
+        for _, m := range []struct {
+            mode string
+            want float64
+        }{
+            {models.TaxRoundingHalfUp, tt.halfUp},
+            {models.TaxRoundingHalfEven, tt.halfEven},
+            {models.TaxRoundingDown, tt.down},
+        } {
+            if got := roundTax(tt.v, m.mode); got != m.want {
+                t.Errorf("roundTax(%v, %s) = %v, want %v", tt.v, m.mode, got, m.want)
+            }
+        }
+    }
+}
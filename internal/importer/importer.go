@@ -0,0 +1,491 @@
+// Package importer loads historical sales from CSV files exported by a shop's
+// previous system.
+//
+// Every CSV row is one sale item. Rows of the same sale share external_id and
+// must be consecutive; the sale columns (transaction_time, employee_id, type,
+// payment_method) are taken from its first row and must repeat on the others.
+// A sale whose rows are split is rejected with an error on each of its rows,
+// unless its first rows were already stored in an earlier chunk. A row that
+// is not valid CSV is reported on its own line; it fails the sale only when
+// the rows around it belong to the same sale.
+//
+// Required columns: external_id, transaction_time, employee_id, item_id,
+// quantity, price. Optional: type (sale or return), payment_method (default
+// cash), discount (line total), category, tax_category, tax_rate (percent,
+// included in the price). Column order does not matter.
+package importer
+
+import (
+    "context"
+    "encoding/csv"
+    "errors"
+    "fmt"
+    "io"
+    "math"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/dibsnvas/golang-2025/internal/models"
+    "gorm.io/gorm"
+)
+
+const (
+    DefaultChunkSize = 1000
+
+    // Отчёт не должен разрастаться на файлах, где ошибка в каждой строке
+    maxReportedErrors = 1000
+)
+
+var requiredColumns = []string{"external_id", "transaction_time", "employee_id", "item_id", "quantity", "price"}
+
+// Options control an import run.
+type Options struct {
+    ShopID uint
+    // DryRun — только проверить файл, ничего не записывая
+    DryRun bool
+    // ChunkSize — сколько продаж записывается в одной транзакции
+    ChunkSize int
+}
+
+// RowError describes a rejected row. A sale with any bad row is rejected as a
+// whole.
+type RowError struct {
+    Line       int    `json:"line"`
+    ExternalID string `json:"external_id,omitempty"`
+    Error      string `json:"error"`
+}
+
+// Report summarises an import run.
+type Report struct {
+    DryRun   bool       `json:"dry_run"`
+    Rows     int        `json:"rows"`
+    Sales    int        `json:"sales"`
+    Imported int        `json:"imported"` // в режиме dry_run — сколько было бы записано
+    Skipped  int        `json:"skipped"`  // уже импортированы ранее
+    Failed   int        `json:"failed"`
+    Errors   []RowError `json:"errors"`
+    // ErrorsTruncated — в Errors попали не все ошибки
+    ErrorsTruncated bool `json:"errors_truncated"`
+}
+
+func (r *Report) addError(line int, externalID, msg string) {
+    if len(r.Errors) >= maxReportedErrors {
+        r.ErrorsTruncated = true
+        return
+    }
+    r.Errors = append(r.Errors, RowError{Line: line, ExternalID: externalID, Error: msg})
+}
+
+// ClientID is the SalesTransaction.ClientID of an imported sale. It makes a
+// repeated import of the same file skip sales that are already stored.
+func ClientID(shopID uint, externalID string) string {
+    return fmt.Sprintf("import:%d:%s", shopID, externalID)
+}
+
+// pendingSale is a sale being assembled from consecutive rows.
+type pendingSale struct {
+    externalID string
+    line       int
+    lines      []int // все строки продажи, для ошибок
+    tx         models.SalesTransaction
+    headerOK   bool
+    invalid    bool
+    // split — строки уже встречавшейся продажи; они не импортируются и не считаются отдельной продажей
+    split bool
+}
+
+// seenSale is what is remembered of a finished sale to report rows of it
+// that come later.
+type seenSale struct {
+    line   int
+    failed bool
+}
+
+type importer struct {
+    db      *gorm.DB
+    opts    Options
+    report  *Report
+    columns map[string]int
+    seen    map[string]*seenSale
+    chunk   []*pendingSale
+}
+
+// ImportSales reads CSV from r and stores the sales for opts.ShopID in chunks
+// of opts.ChunkSize, each in its own database transaction. Imported sales get
+// no receipt numbers. Row problems end up in the report; the returned error
+// is for problems that stop the run (unreadable header, database errors).
+// Chunks committed before such an error stay, so the run can be repeated.
+func ImportSales(ctx context.Context, db *gorm.DB, r io.Reader, opts Options) (*Report, error) {
+    if opts.ShopID == 0 {
+        return nil, errors.New("shop id is required")
+    }
+    if opts.ChunkSize <= 0 {
+        opts.ChunkSize = DefaultChunkSize
+    }
+
+    im := &importer{
+        db:     db,
+        opts:   opts,
+        report: &Report{DryRun: opts.DryRun, Errors: []RowError{}},
+        seen:   map[string]*seenSale{},
+    }
+
+    cr := csv.NewReader(r)
+    cr.ReuseRecord = true
+    cr.FieldsPerRecord = -1
+
+    header, err := cr.Read()
+    if err != nil {
+        return nil, fmt.Errorf("read header: %w", err)
+    }
+    if err := im.mapColumns(header); err != nil {
+        return nil, err
+    }
+
+    var current *pendingSale
+    // unreadable — строка последней ошибки разбора CSV, пока неизвестно, к какой продаже она относится
+    var unreadable int
+    for {
+        if err := ctx.Err(); err != nil {
+            return im.report, err
+        }
+
+        record, err := cr.Read()
+        if err == io.EOF {
+            break
+        }
+        if err != nil {
+            var parseErr *csv.ParseError
+            if errors.As(err, &parseErr) {
+                im.report.Rows++
+                im.report.addError(parseErr.StartLine, "", "row is not valid CSV and was not imported: "+err.Error())
+                unreadable = parseErr.StartLine
+                continue
+            }
+            return im.report, err
+        }
+        im.report.Rows++
+        // Номер строки в файле с учётом многострочных полей в кавычках
+        line, _ := cr.FieldPos(0)
+
+        externalID := strings.TrimSpace(im.field(record, "external_id"))
+        if externalID == "" {
+            im.report.addError(line, "", "external_id is required")
+            continue
+        }
+
+        // Нечитаемая строка между строками одной продажи делает её неполной
+        if unreadable > 0 && current != nil && current.externalID == externalID && !current.split {
+            im.report.addError(line, externalID, fmt.Sprintf("sale has an unreadable row at line %d and was not imported", unreadable))
+            current.invalid = true
+        }
+        unreadable = 0
+
+        if current == nil || current.externalID != externalID {
+            if current != nil {
+                if err := im.finish(current); err != nil {
+                    return im.report, err
+                }
+            }
+            if first, ok := im.seen[externalID]; ok {
+                im.rejectSplit(externalID, first, line)
+                current = &pendingSale{externalID: externalID, line: first.line, split: true}
+            } else {
+                current = &pendingSale{externalID: externalID, line: line}
+                im.seen[externalID] = &seenSale{line: line}
+                if msg := im.parseHeader(current, record); msg != "" {
+                    im.report.addError(line, externalID, msg)
+                    current.invalid = true
+                } else {
+                    current.headerOK = true
+                }
+            }
+        } else if current.invalid && !current.headerOK {
+            // Строки продажи с ошибкой в первой строке пропускаем без отдельных ошибок
+            continue
+        } else if msg := im.checkHeader(current, record); msg != "" {
+            im.report.addError(line, externalID, msg)
+            current.invalid = true
+        }
+
+        if current.split {
+            im.report.addError(line, externalID, fmt.Sprintf("rows of a sale must be consecutive (the sale starts at line %d); this row was not imported", current.line))
+            continue
+        }
+        current.lines = append(current.lines, line)
+        if msg := im.parseItem(current, record); msg != "" {
+            im.report.addError(line, externalID, msg)
+            current.invalid = true
+        }
+    }
+
+    if current != nil {
+        if err := im.finish(current); err != nil {
+            return im.report, err
+        }
+    }
+    if err := im.flush(); err != nil {
+        return im.report, err
+    }
+    return im.report, nil
+}
+
+func (im *importer) mapColumns(header []string) error {
+    im.columns = map[string]int{}
+    for i, name := range header {
+        name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+        im.columns[name] = i
+    }
+    var missing []string
+    for _, name := range requiredColumns {
+        if _, ok := im.columns[name]; !ok {
+            missing = append(missing, name)
+        }
+    }
+    if len(missing) > 0 {
+        return fmt.Errorf("missing columns: %s", strings.Join(missing, ", "))
+    }
+    return nil
+}
+
+func (im *importer) field(record []string, name string) string {
+    i, ok := im.columns[name]
+    if !ok || i >= len(record) {
+        return ""
+    }
+    return strings.TrimSpace(record[i])
+}
+
+func parseTime(s string) (time.Time, error) {
+    if t, err := time.Parse(time.RFC3339, s); err == nil {
+        return t, nil
+    }
+    return time.Parse("2006-01-02 15:04:05", s)
+}
+
+// parseHeader fills the sale columns from the first row of a sale.
+func (im *importer) parseHeader(s *pendingSale, record []string) string {
+    at, err := parseTime(im.field(record, "transaction_time"))
+    if err != nil {
+        return "invalid transaction_time, expected RFC 3339 or YYYY-MM-DD HH:MM:SS (UTC)"
+    }
+    if at.After(time.Now()) {
+        return "transaction_time is in the future"
+    }
+    employeeID, err := strconv.ParseUint(im.field(record, "employee_id"), 10, 64)
+    if err != nil || employeeID == 0 {
+        return "invalid employee_id"
+    }
+
+    txType := im.field(record, "type")
+    if txType == "" {
+        txType = models.TransactionTypeSale
+    }
+    if txType != models.TransactionTypeSale && txType != models.TransactionTypeReturn {
+        return "type must be sale or return"
+    }
+    method := im.field(record, "payment_method")
+    if method == "" {
+        method = models.PaymentMethodCash
+    }
+
+    clientID := ClientID(im.opts.ShopID, s.externalID)
+    s.tx = models.SalesTransaction{
+        ClientID:        &clientID,
+        EmployeeID:      uint(employeeID),
+        ShopID:          im.opts.ShopID,
+        TransactionTime: at,
+        TransactionType: txType,
+        PaymentMethod:   method,
+        // tax_rate в файле входит в цену
+        PricesIncludeTax: true,
+    }
+    return ""
+}
+
+// checkHeader makes sure the sale columns repeat on every row of a sale.
+func (im *importer) checkHeader(s *pendingSale, record []string) string {
+    if !s.headerOK {
+        return ""
+    }
+    other := pendingSale{externalID: s.externalID}
+    if msg := im.parseHeader(&other, record); msg != "" {
+        return msg
+    }
+    if !other.tx.TransactionTime.Equal(s.tx.TransactionTime) ||
+        other.tx.EmployeeID != s.tx.EmployeeID ||
+        other.tx.TransactionType != s.tx.TransactionType ||
+        other.tx.PaymentMethod != s.tx.PaymentMethod {
+        return "sale columns differ from the first row of the sale"
+    }
+    return ""
+}
+
+func (im *importer) parseItem(s *pendingSale, record []string) string {
+    itemID, err := strconv.ParseUint(im.field(record, "item_id"), 10, 64)
+    if err != nil || itemID == 0 {
+        return "invalid item_id"
+    }
+    quantity, err := strconv.Atoi(im.field(record, "quantity"))
+    if err != nil || quantity <= 0 {
+        return "quantity must be a positive integer"
+    }
+    price, err := strconv.ParseFloat(im.field(record, "price"), 64)
+    if err != nil || price < 0 || math.IsInf(price, 0) || math.IsNaN(price) {
+        return "price must be a non-negative number"
+    }
+
+    var discount, taxRate float64
+    if v := im.field(record, "discount"); v != "" {
+        discount, err = strconv.ParseFloat(v, 64)
+        if err != nil || discount < 0 || discount > float64(quantity)*price {
+            return "discount must be between 0 and quantity * price"
+        }
+    }
+    if v := im.field(record, "tax_rate"); v != "" {
+        taxRate, err = strconv.ParseFloat(v, 64)
+        if err != nil || taxRate < 0 || taxRate > 100 {
+            return "tax_rate must be a percentage between 0 and 100"
+        }
+    }
+    taxCategory := im.field(record, "tax_category")
+    if taxCategory == "" {
+        taxCategory = models.DefaultTaxCategory
+    }
+
+    // Возвраты храним с отрицательными суммами, как и при продаже через API
+    sign := 1.0
+    if s.tx.TransactionType == models.TransactionTypeReturn {
+        sign = -1
+    }
+    gross := round(sign * (float64(quantity)*price - discount))
+    net := round(gross / (1 + taxRate/100))
+    s.tx.SaleItems = append(s.tx.SaleItems, models.SaleItem{
+        ItemID:         uint(itemID),
+        Quantity:       int(sign) * quantity,
+        OriginalPrice:  price,
+        PriceAtSale:    round(price - discount/float64(quantity)),
+        DiscountAmount: round(sign * discount),
+        Category:       im.field(record, "category"),
+        TaxCategory:    taxCategory,
+        TaxRate:        taxRate,
+        NetAmount:      net,
+        TaxAmount:      round(gross - net),
+        GrossAmount:    gross,
+    })
+    return ""
+}
+
+// rejectSplit handles rows of a sale that already ended at an earlier row.
+// If the sale is still queued in the current chunk it is taken out and failed
+// with an error on each of its rows; a sale that already failed or was stored
+// is left as it is. The new rows are reported by the caller.
+func (im *importer) rejectSplit(externalID string, first *seenSale, line int) {
+    if first.failed {
+        return
+    }
+    for i, s := range im.chunk {
+        if s.externalID != externalID {
+            continue
+        }
+        im.chunk = append(im.chunk[:i], im.chunk[i+1:]...)
+        first.failed = true
+        im.report.Failed++
+        for _, l := range s.lines {
+            im.report.addError(l, externalID, fmt.Sprintf("rows of a sale must be consecutive; more rows of the sale at line %d", line))
+        }
+        return
+    }
+}
+
+// finish totals a completed sale and queues it for the current chunk. Rows of
+// a split sale are not a sale of their own.
+func (im *importer) finish(s *pendingSale) error {
+    if s.split {
+        return nil
+    }
+    im.report.Sales++
+    if s.invalid {
+        im.seen[s.externalID].failed = true
+        im.report.Failed++
+        return nil
+    }
+
+    tx := &s.tx
+    for _, it := range tx.SaleItems {
+        tx.SubtotalAmount += float64(it.Quantity) * it.OriginalPrice
+        tx.DiscountAmount += it.DiscountAmount
+        tx.NetAmount += it.NetAmount
+        tx.TaxAmount += it.TaxAmount
+        tx.TotalAmount += it.GrossAmount
+    }
+    tx.SubtotalAmount = round(tx.SubtotalAmount)
+    tx.DiscountAmount = round(tx.DiscountAmount)
+    tx.NetAmount = round(tx.NetAmount)
+    tx.TaxAmount = round(tx.TaxAmount)
+    tx.TotalAmount = round(tx.TotalAmount)
+    tx.Payments = []models.SalePayment{{Method: tx.PaymentMethod, Amount: tx.TotalAmount}}
+
+    im.chunk = append(im.chunk, s)
+    if len(im.chunk) >= im.opts.ChunkSize {
+        return im.flush()
+    }
+    return nil
+}
+
+// flush stores the queued sales in one transaction, skipping those already
+// imported.
+func (im *importer) flush() error {
+    if len(im.chunk) == 0 {
+        return nil
+    }
+    chunk := im.chunk
+    im.chunk = nil
+
+    ids := make([]string, 0, len(chunk))
+    for _, s := range chunk {
+        ids = append(ids, *s.tx.ClientID)
+    }
+    var existing []string
+    if err := im.db.Model(&models.SalesTransaction{}).
+        Where("client_id IN ?", ids).
+        Pluck("client_id", &existing).Error; err != nil {
+        return err
+    }
+    stored := map[string]bool{}
+    for _, id := range existing {
+        stored[id] = true
+    }
+
+    var txs []*models.SalesTransaction
+    for _, s := range chunk {
+        if stored[*s.tx.ClientID] {
+            im.report.Skipped++
+            continue
+        }
+        txs = append(txs, &s.tx)
+    }
+    if len(txs) == 0 {
+        return nil
+    }
+    if im.opts.DryRun {
+        im.report.Imported += len(txs)
+        return nil
+    }
+
+    err := im.db.Transaction(func(db *gorm.DB) error {
+        return db.CreateInBatches(txs, 200).Error
+    })
+    if err != nil {
+        return fmt.Errorf("store sales starting at line %d: %w", chunk[0].line, err)
+    }
+    im.report.Imported += len(txs)
+    return nil
+}
+
+// round rounds to cents; v is snapped to a millionth of a cent first, since
+// v*100 is not exact in binary (1.005*100 = 100.49999999999999).
+func round(v float64) float64 {
+    return math.Round(math.Round(v*1e8)/1e6) / 100
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+const (
+    AdjustmentBonus         = "bonus"
+    AdjustmentCorrection    = "correction"    // может быть отрицательной
+    AdjustmentReimbursement = "reimbursement" // возмещение расходов, не облагается
+
+    AdjustmentPending  = "pending"
+    AdjustmentApproved = "approved"
+    AdjustmentRejected = "rejected"
+    AdjustmentPaid     = "paid"
+)
+
+// SalaryAdjustment is a one-off bonus, correction or reimbursement for an
+// employee. Once approved by a manager it is paid with the employee's next
+// payroll run, attached to a salary payment, or paid on its own. Bonuses and
+// corrections are part of gross pay; reimbursements are added to net pay.
+type SalaryAdjustment struct {
+    ID              uint       `gorm:"primaryKey;column:id"`
+    EmployeeID      uint       `gorm:"column:employee_id;index"`
+    Type            string     `gorm:"column:type"`
+    ReasonCode      string     `gorm:"column:reason_code"`
+    Description     string     `gorm:"column:description"`
+    Amount          float64    `gorm:"column:amount"`
+    EffectiveDate   time.Time  `gorm:"column:effective_date"` // к какому дню относится
+    Status          string     `gorm:"column:status;index"`
+    ApprovedBy      *uint      `gorm:"column:approved_by"` // менеджер, утвердивший или отклонивший
+    ApprovedAt      *time.Time `gorm:"column:approved_at"`
+    PayrollLineID   *uint      `gorm:"column:payroll_line_id;index"`
+    SalaryPaymentID *uint      `gorm:"column:salary_payment_id;index"`
+    CreatedAt       time.Time  `gorm:"column:created_at"`
+    UpdatedAt       time.Time  `gorm:"column:updated_at"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+const (
+    AdvanceKindAdvance = "advance" // аванс, обычно гасится одной-двумя выплатами
+    AdvanceKindLoan    = "loan"    // заём, гасится долями по графику
+
+    AdvanceOpen   = "open"
+    AdvanceRepaid = "repaid"
+)
+
+// SalaryAdvance is money paid to an employee ahead of salary. Installment is
+// recovered from every regular salary payment for a pay period ending on or
+// after RecoverFrom until Balance reaches zero; the recoveries are the
+// employee's salary deduction lines with this AdvanceID.
+type SalaryAdvance struct {
+    ID          uint      `gorm:"primaryKey;column:id"`
+    EmployeeID  uint      `gorm:"column:employee_id;index"`
+    Kind        string    `gorm:"column:kind"`
+    Principal   float64   `gorm:"column:principal"`
+    Installment float64   `gorm:"column:installment"` // удерживается с каждой зарплаты
+    Balance     float64   `gorm:"column:balance"`     // осталось вернуть
+    IssuedAt    time.Time `gorm:"column:issued_at"`
+    RecoverFrom time.Time `gorm:"column:recover_from"` // первый период, с которого удерживаем
+    Note        string    `gorm:"column:note"`
+    Status      string    `gorm:"column:status;index"`
+    CreatedAt   time.Time `gorm:"column:created_at"`
+    UpdatedAt   time.Time `gorm:"column:updated_at"`
+}
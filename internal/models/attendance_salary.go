@@ -5,6 +5,7 @@ import "time"
 type EmployeeAttendance struct {
     ID         uint       `gorm:"primaryKey;column:id"`
     EmployeeID uint       `gorm:"column:employee_id"`
+    ShopID     uint       `gorm:"column:shop_id;index"`
     ClockIn    time.Time  `gorm:"column:clock_in"`
     ClockOut   *time.Time `gorm:"column:clock_out"`
     CreatedAt  time.Time  `gorm:"column:created_at"`
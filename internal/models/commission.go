@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+const (
+    CommissionPlanFlat     = "flat"
+    CommissionPlanTiered   = "tiered"
+    CommissionPlanCategory = "category"
+)
+
+// CommissionPlan describes how a cashier's commission is computed. Rates are
+// percentages: 2.5 means 2.5% of revenue.
+type CommissionPlan struct {
+    ID        uint      `gorm:"primaryKey;column:id"`
+    Name      string    `gorm:"column:name"`
+    PlanType  string    `gorm:"column:plan_type"`
+    Rate      float64   `gorm:"column:rate"` // flat rate, or default rate for categories without their own
+    CreatedAt time.Time `gorm:"column:created_at"`
+    UpdatedAt time.Time `gorm:"column:updated_at"`
+
+    Tiers         []CommissionTier         `gorm:"foreignKey:PlanID"`
+    CategoryRates []CommissionCategoryRate `gorm:"foreignKey:PlanID"`
+}
+
+// CommissionTier applies Rate to the part of monthly revenue above MinRevenue
+// (up to the next tier).
+type CommissionTier struct {
+    ID         uint    `gorm:"primaryKey;column:id"`
+    PlanID     uint    `gorm:"column:plan_id;index"`
+    MinRevenue float64 `gorm:"column:min_revenue"`
+    Rate       float64 `gorm:"column:rate"`
+}
+
+type CommissionCategoryRate struct {
+    ID       uint    `gorm:"primaryKey;column:id"`
+    PlanID   uint    `gorm:"column:plan_id;index"`
+    Category string  `gorm:"column:category"`
+    Rate     float64 `gorm:"column:rate"`
+}
+
+type CommissionAssignment struct {
+    ID            uint       `gorm:"primaryKey;column:id"`
+    EmployeeID    uint       `gorm:"column:employee_id;index"`
+    PlanID        uint       `gorm:"column:plan_id"`
+    EffectiveFrom time.Time  `gorm:"column:effective_from"`
+    EffectiveTo   *time.Time `gorm:"column:effective_to"`
+    CreatedAt     time.Time  `gorm:"column:created_at"`
+
+    Plan CommissionPlan `gorm:"foreignKey:PlanID"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// CompanySettings is the single row (ID 1) with company-wide details used on
+// payslips and in payroll.
+type CompanySettings struct {
+    ID                  uint      `gorm:"primaryKey;column:id"`
+    Name                string    `gorm:"column:name"`
+    Address             string    `gorm:"column:address"`
+    TaxNumber           string    `gorm:"column:tax_number"`
+    PayslipFooter       string    `gorm:"column:payslip_footer"`
+    PayslipTextTemplate string    `gorm:"column:payslip_text_template"` // text/template для PDF; пусто — шаблон по умолчанию
+    PayslipHTMLTemplate string    `gorm:"column:payslip_html_template"` // html/template; пусто — шаблон по умолчанию
+    OvertimeWeeklyHours float64   `gorm:"column:overtime_weekly_hours"` // 0 — 40 часов
+    OvertimeMultiplier  float64   `gorm:"column:overtime_multiplier"`   // 0 — 1.5
+    BankIBAN            string    `gorm:"column:bank_iban"`             // счёт, с которого платится зарплата
+    BankBIC             string    `gorm:"column:bank_bic"`
+    Currency            string    `gorm:"column:currency"`           // ISO 4217; пусто — EUR
+    BankCSVLayout       string    `gorm:"column:bank_csv_layout"`    // колонки через запятую; пусто — по умолчанию
+    BankCSVDelimiter    string    `gorm:"column:bank_csv_delimiter"` // один символ; пусто — ","
+    CreatedAt           time.Time `gorm:"column:created_at"`
+    UpdatedAt           time.Time `gorm:"column:updated_at"`
+}
+
+// EmployeeBankAccount is where an employee's salary is transferred. The IBAN
+// is stored encrypted; IBANMasked is safe to show.
+type EmployeeBankAccount struct {
+    ID            uint      `gorm:"primaryKey;column:id"`
+    EmployeeID    uint      `gorm:"column:employee_id;uniqueIndex"`
+    HolderName    string    `gorm:"column:holder_name"`
+    IBANEncrypted string    `gorm:"column:iban_encrypted" json:"-"` // AES-GCM, ключ BANK_DATA_KEY
+    IBANMasked    string    `gorm:"column:iban_masked"`
+    BIC           string    `gorm:"column:bic"`
+    CreatedAt     time.Time `gorm:"column:created_at"`
+    UpdatedAt     time.Time `gorm:"column:updated_at"`
+}
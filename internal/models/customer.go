@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+const (
+    PaymentMethodLoyaltyPoints = "loyalty_points" // оплата баллами, сумма в деньгах
+
+    LoyaltyEarn   = "earn"   // начисление за покупку (отрицательное — за возврат)
+    LoyaltyRedeem = "redeem" // списание скидкой или оплатой (положительное — возврат баллов при возврате товара)
+    LoyaltyVoid   = "void"   // сторно при аннулировании чека
+)
+
+type Customer struct {
+    ID            uint      `gorm:"primaryKey;column:id"`
+    Name          string    `gorm:"column:name"`
+    Phone         string    `gorm:"column:phone"`
+    Email         string    `gorm:"column:email"`
+    LoyaltyCard   *string   `gorm:"column:loyalty_card;uniqueIndex"`
+    PointsBalance int       `gorm:"column:points_balance"`
+    CreatedAt     time.Time `gorm:"column:created_at"`
+    UpdatedAt     time.Time `gorm:"column:updated_at"`
+}
+
+// LoyaltyRule awards PointsPerUnit points per currency unit of line revenue.
+// Zero ShopID, ItemID or empty Category match anything; the most specific
+// matching rule wins.
+type LoyaltyRule struct {
+    ID            uint      `gorm:"primaryKey;column:id"`
+    ShopID        uint      `gorm:"column:shop_id;index"`
+    ItemID        uint      `gorm:"column:item_id"`
+    Category      string    `gorm:"column:category"`
+    PointsPerUnit float64   `gorm:"column:points_per_unit"`
+    Active        bool      `gorm:"column:active"`
+    CreatedAt     time.Time `gorm:"column:created_at"`
+    UpdatedAt     time.Time `gorm:"column:updated_at"`
+}
+
+// LoyaltyTransaction is one entry of a customer's points history. Points are
+// positive when the balance grows.
+type LoyaltyTransaction struct {
+    ID            uint      `gorm:"primaryKey;column:id"`
+    CustomerID    uint      `gorm:"column:customer_id;index"`
+    TransactionID *uint     `gorm:"column:transaction_id;index"`
+    Type          string    `gorm:"column:type"`
+    Points        int       `gorm:"column:points"`
+    BalanceAfter  int       `gorm:"column:balance_after"`
+    CreatedAt     time.Time `gorm:"column:created_at"`
+}
@@ -0,0 +1,63 @@
+package models
+
+import "time"
+
+const (
+    DeductionIncomeTax   = "income_tax"
+    DeductionPension     = "pension"
+    DeductionSocial      = "social"
+    DeductionFixed       = "fixed"
+    DeductionGarnishment = "garnishment"
+    DeductionAdvance     = "advance" // погашение аванса или займа, не правило
+)
+
+// DeductionRule is withheld from the gross pay of every salary payment of an
+// employee, or of all employees when EmployeeID is nil. Rates are
+// percentages: 2.5 means 2.5%.
+//
+// Pension and social contributions are taken first and reduce the taxable
+// pay; income tax is progressive by Brackets (or flat EmployeeRate); advance
+// recoveries, fixed deductions and garnishments are taken from what is left,
+// from regular pay only. BaseCeiling and Brackets apply to all pay of a pay
+// period, so supplemental pay is taxed on top of the period's regular pay.
+type DeductionRule struct {
+    ID           uint      `gorm:"primaryKey;column:id"`
+    Name         string    `gorm:"column:name"`
+    Kind         string    `gorm:"column:kind"`
+    EmployeeID   *uint     `gorm:"column:employee_id;index"` // nil — для всех сотрудников
+    EmployeeRate float64   `gorm:"column:employee_rate"`     // удерживается с сотрудника
+    EmployerRate float64   `gorm:"column:employer_rate"`     // платит работодатель сверх начисленного
+    Amount       float64   `gorm:"column:amount"`            // фиксированная сумма за выплату
+    BaseCeiling  float64   `gorm:"column:base_ceiling"`      // предел базы взносов за выплату; 0 — без предела
+    ProtectedNet float64   `gorm:"column:protected_net"`     // для garnishment: сколько должно остаться к выплате
+    Active       bool      `gorm:"column:active"`
+    CreatedAt    time.Time `gorm:"column:created_at"`
+    UpdatedAt    time.Time `gorm:"column:updated_at"`
+
+    Brackets []TaxBracket `gorm:"foreignKey:RuleID"`
+}
+
+// TaxBracket applies Rate to the part of taxable pay above Threshold (up to
+// the next bracket).
+type TaxBracket struct {
+    ID        uint    `gorm:"primaryKey;column:id"`
+    RuleID    uint    `gorm:"column:rule_id;index"`
+    Threshold float64 `gorm:"column:threshold"`
+    Rate      float64 `gorm:"column:rate"`
+}
+
+// SalaryDeduction is one deduction line of a payroll line or salary payment.
+// Lines of a payroll line get the payment ID when the run is paid.
+type SalaryDeduction struct {
+    ID              uint      `gorm:"primaryKey;column:id"`
+    SalaryPaymentID *uint     `gorm:"column:salary_payment_id;index"`
+    PayrollLineID   *uint     `gorm:"column:payroll_line_id;index"`
+    RuleID          *uint     `gorm:"column:rule_id"`
+    AdvanceID       *uint     `gorm:"column:advance_id;index"`
+    Kind            string    `gorm:"column:kind"`
+    Description     string    `gorm:"column:description"`
+    Base            float64   `gorm:"column:base"`            // сумма, с которой считали
+    Amount          float64   `gorm:"column:amount"`          // удержано с сотрудника
+    EmployerAmount  float64   `gorm:"column:employer_amount"` // взнос работодателя
+    CreatedAt       time.Time `gorm:"column:created_at"`
+}
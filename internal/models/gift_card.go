@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+const (
+    PaymentMethodGiftCard = "gift_card" // оплата подарочной картой или возвратным кредитом
+
+    GiftCardKindGiftCard    = "gift_card"    // продана как позиция чека
+    GiftCardKindStoreCredit = "store_credit" // выдана вместо денег при возврате
+
+    // Позиции с подарочными картами не облагаются налогом, пока для этой категории не задана ставка
+    GiftCardTaxCategory = "gift_card"
+
+    GiftCardMovementIssue  = "issue"  // продажа карты или выдача кредита
+    GiftCardMovementRedeem = "redeem" // оплата картой
+    GiftCardMovementRefund = "refund" // возврат на существующую карту
+    GiftCardMovementVoid   = "void"   // сторно при аннулировании чека
+)
+
+// GiftCard is a prepaid balance identified by its code. Balance only changes
+// together with a GiftCardMovement.
+type GiftCard struct {
+    ID                  uint      `gorm:"primaryKey;column:id"`
+    Code                string    `gorm:"column:code;uniqueIndex"`
+    Kind                string    `gorm:"column:kind"`
+    ShopID              uint      `gorm:"column:shop_id"` // магазин, где карта выпущена
+    InitialAmount       float64   `gorm:"column:initial_amount"`
+    Balance             float64   `gorm:"column:balance"`
+    Active              bool      `gorm:"column:active"`
+    IssuedTransactionID *uint     `gorm:"column:issued_transaction_id;index"`
+    CreatedAt           time.Time `gorm:"column:created_at"`
+    UpdatedAt           time.Time `gorm:"column:updated_at"`
+}
+
+// GiftCardMovement is one entry of the gift card ledger. Amount is positive
+// when the balance grows and negative when it is spent.
+type GiftCardMovement struct {
+    ID            uint      `gorm:"primaryKey;column:id"`
+    GiftCardID    uint      `gorm:"column:gift_card_id;index"`
+    TransactionID *uint     `gorm:"column:transaction_id;index"`
+    Type          string    `gorm:"column:type"`
+    Amount        float64   `gorm:"column:amount"`
+    BalanceAfter  float64   `gorm:"column:balance_after"`
+    CreatedAt     time.Time `gorm:"column:created_at"`
+}
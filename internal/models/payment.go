@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+const (
+    PaymentMethodCash  = "cash"
+    PaymentMethodCard  = "card"
+    PaymentMethodSplit = "split" // в SalesTransaction.PaymentMethod, если способов оплаты несколько
+)
+
+// PaymentMethods are all methods a sale can be paid with.
+var PaymentMethods = []string{PaymentMethodCash, PaymentMethodCard, PaymentMethodGiftCard, PaymentMethodLoyaltyPoints}
+
+// DefaultPaymentMethods are accepted in shops that have no configured methods.
+var DefaultPaymentMethods = PaymentMethods
+
+// IsPaymentMethod reports whether m is one of PaymentMethods.
+func IsPaymentMethod(m string) bool {
+    for _, known := range PaymentMethods {
+        if m == known {
+            return true
+        }
+    }
+    return false
+}
+
+// SalePayment is one tender of a sales transaction. Amount is what the
+// customer handed over; ChangeGiven is returned from it (cash only).
+type SalePayment struct {
+    ID            uint      `gorm:"primaryKey;column:id"`
+    TransactionID uint      `gorm:"column:transaction_id;index"`
+    Method        string    `gorm:"column:method"`
+    Amount        float64   `gorm:"column:amount"`
+    Reference     string    `gorm:"column:reference"`
+    ChangeGiven   float64   `gorm:"column:change_given"`
+    CreatedAt     time.Time `gorm:"column:created_at"`
+}
+
+type ShopPaymentMethod struct {
+    ID        uint      `gorm:"primaryKey;column:id"`
+    ShopID    uint      `gorm:"column:shop_id;uniqueIndex:idx_shop_payment_method"`
+    Method    string    `gorm:"column:method;uniqueIndex:idx_shop_payment_method"`
+    CreatedAt time.Time `gorm:"column:created_at"`
+}
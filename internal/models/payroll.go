@@ -0,0 +1,90 @@
+package models
+
+import "time"
+
+const (
+    PayrollRunDraft    = "draft"
+    PayrollRunApproved = "approved"
+    PayrollRunPaid     = "paid"
+)
+
+// PayrollRun is a batch of salary payments for one pay period and a set of
+// shops. It goes draft → approved → paid; lines can only change in draft.
+type PayrollRun struct {
+    ID          uint       `gorm:"primaryKey;column:id"`
+    PeriodStart time.Time  `gorm:"column:period_start"`
+    PeriodEnd   time.Time  `gorm:"column:period_end"` // включительно
+    ShopIDs     string     `gorm:"column:shop_ids"`   // через запятую, например "1,3"
+    Status      string     `gorm:"column:status;index"`
+    TotalAmount float64    `gorm:"column:total_amount"`
+    ApprovedBy  *uint      `gorm:"column:approved_by"`
+    ApprovedAt  *time.Time `gorm:"column:approved_at"`
+    PaidAt      *time.Time `gorm:"column:paid_at"`
+    CreatedAt   time.Time  `gorm:"column:created_at"`
+    UpdatedAt   time.Time  `gorm:"column:updated_at"`
+
+    Lines []PayrollLine `gorm:"foreignKey:RunID"`
+}
+
+// PayrollLine is the pay of one employee in a run: base pay by pay type,
+// overtime, commission for the period, a manual adjustment and the approved
+// bonuses and corrections make up the gross Amount; deductions are withheld
+// from it and reimbursements added to the net.
+type PayrollLine struct {
+    ID                    uint    `gorm:"primaryKey;column:id"`
+    RunID                 uint    `gorm:"column:run_id;index"`
+    EmployeeID            uint    `gorm:"column:employee_id;index"`
+    ShopID                uint    `gorm:"column:shop_id"`
+    PayType               string  `gorm:"column:pay_type"`
+    PayRate               float64 `gorm:"column:pay_rate"`
+    Hours                 float64 `gorm:"column:hours"`          // обычные часы для почасовой оплаты
+    OvertimeHours         float64 `gorm:"column:overtime_hours"` // часы сверх недельной нормы
+    BasePay               float64 `gorm:"column:base_pay"`
+    OvertimePay           float64 `gorm:"column:overtime_pay"`
+    Commission            float64 `gorm:"column:commission"`
+    Adjustment            float64 `gorm:"column:adjustment"` // ручная корректировка, может быть отрицательной
+    AdjustmentNote        string  `gorm:"column:adjustment_note"`
+    Bonuses               float64 `gorm:"column:bonuses;default:0"`
+    Corrections           float64 `gorm:"column:corrections;default:0"`
+    Reimbursements        float64 `gorm:"column:reimbursements;default:0"` // не входят в брутто
+    Amount                float64 `gorm:"column:amount"`                   // брутто
+    Deductions            float64 `gorm:"column:deductions"`
+    NetAmount             float64 `gorm:"column:net_amount"` // к перечислению
+    EmployerContributions float64 `gorm:"column:employer_contributions"`
+    SalaryPaymentID       *uint   `gorm:"column:salary_payment_id"`
+
+    DeductionLines  []SalaryDeduction  `gorm:"foreignKey:PayrollLineID"`
+    AdjustmentLines []SalaryAdjustment `gorm:"foreignKey:PayrollLineID"`
+}
+
+// PayrollBankExport records a bank payment file produced for a run, so the
+// same transfers are not sent to the bank twice by accident.
+type PayrollBankExport struct {
+    ID         uint      `gorm:"primaryKey;column:id"`
+    RunID      uint      `gorm:"column:run_id;index"`
+    MessageID  string    `gorm:"column:message_id"` // MsgId файла pain.001
+    Format     string    `gorm:"column:format"`     // pain001 или csv
+    Transfers  int       `gorm:"column:transfers"`
+    ControlSum string    `gorm:"column:control_sum"`
+    CreatedAt  time.Time `gorm:"column:created_at"`
+}
+
+// SalaryBankExport records a bank payment file produced for salary payments
+// made outside payroll runs, such as supplemental payments and reissues.
+type SalaryBankExport struct {
+    ID         uint      `gorm:"primaryKey;column:id"`
+    MessageID  string    `gorm:"column:message_id"` // MsgId файла pain.001
+    Format     string    `gorm:"column:format"`     // pain001 или csv
+    Transfers  int       `gorm:"column:transfers"`
+    ControlSum string    `gorm:"column:control_sum"`
+    CreatedAt  time.Time `gorm:"column:created_at"`
+
+    Payments []SalaryBankExportPayment `gorm:"foreignKey:ExportID"`
+}
+
+// SalaryBankExportPayment is a salary payment included in a SalaryBankExport.
+type SalaryBankExportPayment struct {
+    ID              uint `gorm:"primaryKey;column:id"`
+    ExportID        uint `gorm:"column:export_id;index"`
+    SalaryPaymentID uint `gorm:"column:salary_payment_id;index"`
+}
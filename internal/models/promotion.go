@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+const (
+    PromotionPercentage  = "percentage"
+    PromotionFixedAmount = "fixed_amount"
+    PromotionBuyXGetY    = "buy_x_get_y"
+    PromotionBundle      = "bundle"
+
+    PromotionScopeLine  = "line"
+    PromotionScopeCheck = "check"
+)
+
+// Promotion is a discount rule applied automatically by CreateSale while it is
+// active and within its validity window.
+//
+// Value means: percentage — percent off; fixed_amount — amount off per unit
+// (line) or per check; bundle — price of BuyQuantity units together.
+// buy_x_get_y gives FreeQuantity units free for every BuyQuantity bought.
+type Promotion struct {
+    ID            uint      `gorm:"primaryKey;column:id"`
+    Name          string    `gorm:"column:name"`
+    PromotionType string    `gorm:"column:promotion_type"`
+    Scope         string    `gorm:"column:scope"`
+    ShopID        uint      `gorm:"column:shop_id;index"` // 0 — во всех магазинах
+    ItemID        uint      `gorm:"column:item_id"`       // 0 — любой товар (только для line)
+    Value         float64   `gorm:"column:value"`
+    BuyQuantity   int       `gorm:"column:buy_quantity"`
+    FreeQuantity  int       `gorm:"column:free_quantity"`
+    MinSubtotal   float64   `gorm:"column:min_subtotal"` // порог суммы чека для check-скидок
+    ValidFrom     time.Time `gorm:"column:valid_from"`
+    ValidTo       time.Time `gorm:"column:valid_to"` // последний день, включительно
+    Active        bool      `gorm:"column:active;default:true"`
+    CreatedAt     time.Time `gorm:"column:created_at"`
+    UpdatedAt     time.Time `gorm:"column:updated_at"`
+}
@@ -0,0 +1,9 @@
+package models
+
+// ReceiptSequence is the last receipt number issued by a shop in a fiscal
+// year. FiscalYear is 0 for shops with continuous numbering.
+type ReceiptSequence struct {
+    ShopID     uint `gorm:"primaryKey;column:shop_id;autoIncrement:false"`
+    FiscalYear int  `gorm:"primaryKey;column:fiscal_year;autoIncrement:false"`
+    LastNumber int  `gorm:"column:last_number"`
+}
@@ -2,13 +2,40 @@ package models
 
 import "time"
 
+const (
+    SalaryPaymentRegular      = "regular"      // оплата за период; периоды не могут пересекаться
+    SalaryPaymentSupplemental = "supplemental" // премия или доплата, может пересекаться с regular
+    SalaryPaymentReversal     = "reversal"     // сторно: суммы исходной выплаты с обратным знаком
+)
+
+// SalaryPayment is a payment of gross pay Amount. Deductions are withheld
+// from it, Reimbursements are added, and NetAmount is transferred to the
+// employee; EmployerContributions are paid by the company on top.
+//
+// Payments are never edited. A wrong payment is reversed by a reversal entry
+// with the negated amounts and ReversalOfID pointing to it; the original only
+// gets ReversedAt set. A reissued payment replaces it with ReissueOfID set.
 type SalaryPayment struct {
-    ID             uint      `gorm:"primaryKey;column:id"`
-    EmployeeID     uint      `gorm:"column:employee_id"`
-    PayPeriodStart time.Time `gorm:"column:pay_period_start"`
-    PayPeriodEnd   time.Time `gorm:"column:pay_period_end"`
-    Amount         float64   `gorm:"column:amount"`
-    PaidAt         time.Time `gorm:"column:paid_at"`
+    ID                    uint       `gorm:"primaryKey;column:id"`
+    EmployeeID            uint       `gorm:"column:employee_id"`
+    PayPeriodStart        time.Time  `gorm:"column:pay_period_start"`
+    PayPeriodEnd          time.Time  `gorm:"column:pay_period_end"`
+    Amount                float64    `gorm:"column:amount"` // начислено (брутто)
+    Deductions            float64    `gorm:"column:deductions"`
+    Reimbursements        float64    `gorm:"column:reimbursements;default:0"`
+    NetAmount             float64    `gorm:"column:net_amount"`
+    EmployerContributions float64    `gorm:"column:employer_contributions"`
+    PaidAt                time.Time  `gorm:"column:paid_at"`
+    PaymentType           string     `gorm:"column:payment_type;default:regular"`
+    PayrollRunID          *uint      `gorm:"column:payroll_run_id;index"` // ведомость, по которой выплачено
+    ReversalOfID          *uint      `gorm:"column:reversal_of_id;index"` // для reversal: сторнируемая выплата
+    ReissueOfID           *uint      `gorm:"column:reissue_of_id;index"`  // выплата взамен сторнированной
+    ReversedAt            *time.Time `gorm:"column:reversed_at"`          // выплата сторнирована
+    Reason                string     `gorm:"column:reason"`               // причина сторно или перевыпуска
+    ApprovedBy            *uint      `gorm:"column:approved_by"`          // менеджер, утвердивший сторно
+
+    DeductionLines  []SalaryDeduction  `gorm:"foreignKey:SalaryPaymentID"`
+    AdjustmentLines []SalaryAdjustment `gorm:"foreignKey:SalaryPaymentID"`
 }
 
 func (SalaryPayment) TableName() string {
@@ -4,25 +4,67 @@ import (
     "time"
 )
 
+const (
+    TransactionTypeSale   = "sale"
+    TransactionTypeReturn = "return"
+
+    SaleStatusCompleted = "completed"
+    SaleStatusVoided    = "voided"
+)
+
 type SalesTransaction struct {
     ID              uint           `gorm:"primaryKey;column:id"`
     EmployeeID      uint           `gorm:"column:employee_id"`
-    ShopID          uint           `gorm:"column:shop_id"`
+    ClientID        *string        `gorm:"column:client_id;uniqueIndex"` // UUID, присвоенный кассой
+    CustomerID      *uint          `gorm:"column:customer_id;index"`
+    ShopID          uint           `gorm:"column:shop_id;uniqueIndex:idx_shop_receipt_seq,where:receipt_seq > 0"`
     TransactionTime time.Time      `gorm:"column:transaction_time"`
-    TotalAmount     float64        `gorm:"column:total_amount"`
+    ReceiptNumber   string         `gorm:"column:receipt_number"`
+    FiscalYear      int            `gorm:"column:fiscal_year;uniqueIndex:idx_shop_receipt_seq,where:receipt_seq > 0"`
+    ReceiptSeq      int            `gorm:"column:receipt_seq;uniqueIndex:idx_shop_receipt_seq,where:receipt_seq > 0"`
+    SubtotalAmount  float64        `gorm:"column:subtotal_amount"` // до скидок
+    DiscountAmount  float64        `gorm:"column:discount_amount"` // все скидки чека, включая построчные
+    NetAmount       float64        `gorm:"column:net_amount"`
+    TaxAmount       float64        `gorm:"column:tax_amount"`
+    TotalAmount     float64        `gorm:"column:total_amount"` // с налогом
+    PricesIncludeTax bool          `gorm:"column:prices_include_tax"` // настройка магазина на момент продажи
+    PromotionID     *uint          `gorm:"column:promotion_id"` // акция на весь чек
     PaymentMethod   string         `gorm:"column:payment_method"`
+    TransactionType string         `gorm:"column:transaction_type;default:sale"`
+    Status          string         `gorm:"column:status;default:completed;index"`
+    VoidedAt        *time.Time     `gorm:"column:voided_at"`
+    VoidedBy        *uint          `gorm:"column:voided_by"`
+    VoidApprovedBy  *uint          `gorm:"column:void_approved_by"` // менеджер, подтвердивший аннулирование после окна
+    VoidReason      string         `gorm:"column:void_reason"`
+    PriceFlagged    bool           `gorm:"column:price_flagged;index"` // есть строки с несверенной ценой
+    PointsRedeemed  int            `gorm:"column:points_redeemed"`     // баллы, списанные скидкой
+    PointsEarned    int            `gorm:"column:points_earned"`
     CreatedAt       time.Time      `gorm:"column:created_at"`
     UpdatedAt       time.Time      `gorm:"column:updated_at"`
 
-    SaleItems []SaleItem `gorm:"foreignKey:TransactionID"`
+    SaleItems []SaleItem    `gorm:"foreignKey:TransactionID"`
+    Payments  []SalePayment `gorm:"foreignKey:TransactionID"`
 }
 
 type SaleItem struct {
-    ID            uint    `gorm:"primaryKey;column:id"`
-    TransactionID uint    `gorm:"column:transaction_id"`
-    ItemID        uint    `gorm:"column:item_id"`
-    Quantity      int     `gorm:"column:quantity"`
-    PriceAtSale   float64 `gorm:"column:price_at_sale"`
-    CreatedAt     time.Time
-    UpdatedAt     time.Time
+    ID              uint     `gorm:"primaryKey;column:id"`
+    TransactionID   uint     `gorm:"column:transaction_id"`
+    ItemID          uint     `gorm:"column:item_id"`
+    Quantity        int      `gorm:"column:quantity"`
+    OriginalPrice   float64  `gorm:"column:original_price"`    // цена за единицу до скидок
+    PriceAtSale     float64  `gorm:"column:price_at_sale"`     // фактическая цена за единицу после скидок
+    DiscountAmount  float64  `gorm:"column:discount_amount"`   // скидка по строке, включая долю скидки на чек
+    PromotionID     *uint    `gorm:"column:promotion_id"`
+    Category        string   `gorm:"column:category"`
+    TaxCategory     string   `gorm:"column:tax_category"`
+    TaxRate         float64  `gorm:"column:tax_rate"`          // проценты
+    NetAmount       float64  `gorm:"column:net_amount"`
+    TaxAmount       float64  `gorm:"column:tax_amount"`
+    GrossAmount     float64  `gorm:"column:gross_amount"`
+    CatalogPrice    *float64 `gorm:"column:catalog_price"`     // цена из каталога на момент продажи
+    PriceFlagged    bool     `gorm:"column:price_flagged"`     // цена не сверена или расходится с каталогом
+    PriceOverrideBy *uint    `gorm:"column:price_override_by"` // менеджер, разрешивший цену не из каталога
+    GiftCardID      *uint    `gorm:"column:gift_card_id"`      // проданная подарочная карта
+    CreatedAt       time.Time
+    UpdatedAt       time.Time
 }
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+type Shop struct {
+    ID                  uint      `gorm:"primaryKey;column:id"`
+    Name                string    `gorm:"column:name"`
+    Address             string    `gorm:"column:address"`
+    Phone               string    `gorm:"column:phone"`
+    TaxNumber           string    `gorm:"column:tax_number"`
+    ReceiptHeader       string    `gorm:"column:receipt_header"`
+    ReceiptFooter       string    `gorm:"column:receipt_footer"`
+    ReceiptPrefix       string    `gorm:"column:receipt_prefix"`        // {SHOP}, {YYYY}, {YY}; пусто — "{SHOP}-{YYYY}-"
+    ReceiptContinuous   bool      `gorm:"column:receipt_continuous"`    // true — нумерация без сброса в начале года
+    ReceiptTextTemplate string    `gorm:"column:receipt_text_template"` // text/template; пусто — шаблон по умолчанию
+    ReceiptHTMLTemplate string    `gorm:"column:receipt_html_template"` // html/template; пусто — шаблон по умолчанию
+    CreatedAt           time.Time `gorm:"column:created_at"`
+    UpdatedAt           time.Time `gorm:"column:updated_at"`
+}
+
+const (
+    EmployeeRoleCashier = "cashier"
+    EmployeeRoleManager = "manager"
+
+    PayTypeMonthly = "monthly"
+    PayTypeHourly  = "hourly"
+)
+
+type Employee struct {
+    ID                  uint       `gorm:"primaryKey;column:id"`
+    Name                string     `gorm:"column:name"`
+    ShopID              uint       `gorm:"column:shop_id;index"`
+    Role                string     `gorm:"column:role;default:cashier"`
+    ApprovalCodeHash    string     `gorm:"column:approval_code_hash" json:"-"`                   // bcrypt кода подтверждения менеджера
+    ApprovalFailures    int        `gorm:"column:approval_failures;not null;default:0" json:"-"` // неудачные попытки подряд
+    ApprovalLockedUntil *time.Time `gorm:"column:approval_locked_until" json:"-"`
+    Active              bool       `gorm:"column:active"`
+    PayType             string     `gorm:"column:pay_type"` // monthly, hourly или пусто — без оклада
+    PayRate             float64    `gorm:"column:pay_rate"` // оклад в месяц или ставка в час
+    CreatedAt           time.Time  `gorm:"column:created_at"`
+    UpdatedAt           time.Time  `gorm:"column:updated_at"`
+}
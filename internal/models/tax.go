@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+const (
+    TaxRoundingHalfUp   = "half_up"
+    TaxRoundingHalfEven = "half_even"
+    TaxRoundingDown     = "down"
+
+    TaxRoundingPerLine  = "line"
+    TaxRoundingPerTotal = "total"
+
+    DefaultTaxCategory = "standard"
+)
+
+// TaxRate is the rate (in percent) of a tax category. ShopID 0 is the default
+// for all shops; a shop-specific rate for the same category takes precedence.
+type TaxRate struct {
+    ID        uint      `gorm:"primaryKey;column:id"`
+    Category  string    `gorm:"column:category;uniqueIndex:idx_tax_rate_shop_category"`
+    ShopID    uint      `gorm:"column:shop_id;uniqueIndex:idx_tax_rate_shop_category"`
+    Rate      float64   `gorm:"column:rate"`
+    CreatedAt time.Time `gorm:"column:created_at"`
+    UpdatedAt time.Time `gorm:"column:updated_at"`
+}
+
+type ShopTaxSettings struct {
+    ShopID           uint      `gorm:"primaryKey;column:shop_id;autoIncrement:false"`
+    PricesIncludeTax bool      `gorm:"column:prices_include_tax"`
+    RoundingMode     string    `gorm:"column:rounding_mode"`  // half_up, half_even, down
+    RoundingLevel    string    `gorm:"column:rounding_level"` // line или total
+    UpdatedAt        time.Time `gorm:"column:updated_at"`
+}
+
+func (ShopTaxSettings) TableName() string {
+    return "shop_tax_settings"
+}
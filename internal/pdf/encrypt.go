@@ -0,0 +1,178 @@
+package pdf
+
+import (
+    "crypto/aes"
+    "crypto/cipher"
+    "crypto/rand"
+    "crypto/sha256"
+    "crypto/sha512"
+    "encoding/binary"
+    "encoding/hex"
+    "fmt"
+    "hash"
+)
+
+// Стандартный обработчик защиты PDF 2.0 (в PDF 1.7 — расширение Adobe уровня 8): AES-256, V 5, R 6, фильтр AESV3
+const (
+    keyLength = 32
+    // Разрешены все действия; пароль владельца нужен только для смены защиты
+    permissions int32 = -4
+    // Длиннее пароли обрезаются (PDF 2.0, 7.6.4.3.3)
+    maxPasswordLength = 127
+)
+
+type security struct {
+    key    []byte
+    owner  []byte // /O
+    user   []byte // /U
+    ownerE []byte // /OE
+    userE  []byte // /UE
+    perms  []byte // /Perms
+    fileID []byte
+}
+
+func truncPassword(password string) []byte {
+    p := []byte(password)
+    if len(p) > maxPasswordLength {
+        p = p[:maxPasswordLength]
+    }
+    return p
+}
+
+// cbcNoPadding encrypts data, a multiple of the block size, with AES-CBC.
+func cbcNoPadding(key, iv, data []byte) []byte {
+    block, err := aes.NewCipher(key)
+    if err != nil {
+        panic(err) // ключ всегда 16 или 32 байта
+    }
+    out := make([]byte, len(data))
+    cipher.NewCBCEncrypter(block, iv).CryptBlocks(out, data)
+    return out
+}
+
+// hardenedHash is the password hash of revision 6 (algorithm 2.B): SHA-256
+// of the password and salt, then at least 64 rounds of AES-128 and SHA-2.
+func hardenedHash(password, salt, userKey []byte) []byte {
+    h := sha256.New()
+    h.Write(password)
+    h.Write(salt)
+    h.Write(userKey)
+    k := h.Sum(nil)
+
+    for round := 1; ; round++ {
+        var k1 []byte
+        for i := 0; i < 64; i++ {
+            k1 = append(k1, password...)
+            k1 = append(k1, k...)
+            k1 = append(k1, userKey...)
+        }
+        e := cbcNoPadding(k[:16], k[16:32], k1)
+
+        // Хэш следующего раунда выбирается по остатку от деления первых 16 байт на 3
+        sum := 0
+        for _, b := range e[:16] {
+            sum += int(b)
+        }
+        var next hash.Hash
+        switch sum % 3 {
+        case 0:
+            next = sha256.New()
+        case 1:
+            next = sha512.New384()
+        default:
+            next = sha512.New()
+        }
+        next.Write(e)
+        k = next.Sum(nil)
+
+        if round >= 64 && int(e[len(e)-1]) <= round-32 {
+            break
+        }
+    }
+    return k[:32]
+}
+
+func randomBytes(n int) ([]byte, error) {
+    b := make([]byte, n)
+    if _, err := rand.Read(b); err != nil {
+        return nil, err
+    }
+    return b, nil
+}
+
+// newSecurity generates a random document key and derives the /U, /UE, /O,
+// /OE and /Perms entries. Without an owner password a random one is used, so
+// the protection cannot be lifted.
+func newSecurity(userPassword, ownerPassword string) (*security, error) {
+    s := &security{}
+    var err error
+    if s.fileID, err = randomBytes(16); err != nil {
+        return nil, err
+    }
+    if s.key, err = randomBytes(keyLength); err != nil {
+        return nil, err
+    }
+    if ownerPassword == "" {
+        random, err := randomBytes(16)
+        if err != nil {
+            return nil, err
+        }
+        ownerPassword = hex.EncodeToString(random)
+    }
+    zeroIV := make([]byte, aes.BlockSize)
+
+    // Алгоритм 8: /U и /UE, по 8 байт соли для проверки пароля и для ключа
+    salts, err := randomBytes(16)
+    if err != nil {
+        return nil, err
+    }
+    user := truncPassword(userPassword)
+    s.user = append(hardenedHash(user, salts[:8], nil), salts...)
+    s.userE = cbcNoPadding(hardenedHash(user, salts[8:], nil), zeroIV, s.key)
+
+    // Алгоритм 9: /O и /OE зависят ещё и от /U
+    if salts, err = randomBytes(16); err != nil {
+        return nil, err
+    }
+    owner := truncPassword(ownerPassword)
+    s.owner = append(hardenedHash(owner, salts[:8], s.user), salts...)
+    s.ownerE = cbcNoPadding(hardenedHash(owner, salts[8:], s.user), zeroIV, s.key)
+
+    // Алгоритм 10: /Perms — права доступа, зашифрованные ключом документа
+    perms := make([]byte, 16)
+    perm := permissions
+    binary.LittleEndian.PutUint32(perms[0:4], uint32(perm))
+    copy(perms[4:], []byte{0xff, 0xff, 0xff, 0xff, 'T', 'a', 'd', 'b'})
+    if _, err := rand.Read(perms[12:]); err != nil {
+        return nil, err
+    }
+    block, err := aes.NewCipher(s.key)
+    if err != nil {
+        return nil, err
+    }
+    s.perms = make([]byte, 16)
+    block.Encrypt(s.perms, perms)
+    return s, nil
+}
+
+// encrypt encrypts a stream with the document key in AES-256-CBC. The random
+// IV goes first and the data is padded as in PKCS#7 (algorithm 1.A).
+func (s *security) encrypt(data []byte) []byte {
+    iv, err := randomBytes(aes.BlockSize)
+    if err != nil {
+        panic(err) // crypto/rand не возвращает ошибок на поддерживаемых системах
+    }
+    n := aes.BlockSize - len(data)%aes.BlockSize
+    padded := append(append([]byte{}, data...), make([]byte, n)...)
+    for i := len(data); i < len(padded); i++ {
+        padded[i] = byte(n)
+    }
+    return append(iv, cbcNoPadding(s.key, iv, padded)...)
+}
+
+func (s *security) dictionary() string {
+    return fmt.Sprintf("<< /Filter /Standard /V 5 /R 6 /Length %d "+
+        "/CF << /StdCF << /CFM /AESV3 /AuthEvent /DocOpen /Length %d >> >> /StmF /StdCF /StrF /StdCF "+
+        "/O <%x> /U <%x> /OE <%x> /UE <%x> /Perms <%x> /P %d >>",
+        keyLength*8, keyLength, s.owner, s.user, s.ownerE, s.userE, s.perms, permissions)
+}
@@ -0,0 +1,133 @@
+package pdf
+
+import (
+    "bytes"
+    "crypto/aes"
+    "crypto/cipher"
+    "encoding/binary"
+    "encoding/hex"
+    "regexp"
+    "strconv"
+    "testing"
+)
+
+func TestHardenedHash(t *testing.T) {
+    // Ожидаемые значения посчитаны отдельной реализацией алгоритма 2.B на OpenSSL
+    ownerKey := make([]byte, 48)
+    for i := range ownerKey {
+        ownerKey[i] = byte(i)
+    }
+    tests := []struct {
+        name     string
+        password string
+        salt     string
+        userKey  []byte
+        want     string
+    }{
+        {"user password", "secret", "saltsalt", nil, "f28f56ef5747965c3a052f8e074560c8fb02d13283508c02219717d36ef23e4c"},
+        {"owner password", "owner-password", "12345678", ownerKey, "e91cbf2832bf90c13e2b919cad3bb1f0bd3c98751866432d8af2f8f28e0075f0"},
+        {"empty password", "", "\x00\x00\x00\x00\x00\x00\x00\x00", nil, "439feba099a63d0d035a1e5fb67ff307329189584956425aff2d3bd3d15edc60"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got := hex.EncodeToString(hardenedHash([]byte(tt.password), []byte(tt.salt), tt.userKey))
+            if got != tt.want {
+                t.Errorf("hardenedHash() = %s, want %s", got, tt.want)
+            }
+        })
+    }
+}
+
+// cbcDecrypt decrypts AES-CBC data without removing padding.
+func cbcDecrypt(t *testing.T, key, iv, data []byte) []byte {
+    t.Helper()
+    block, err := aes.NewCipher(key)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(data)%aes.BlockSize != 0 {
+        t.Fatalf("encrypted data of %d bytes is not a multiple of the block size", len(data))
+    }
+    out := make([]byte, len(data))
+    cipher.NewCBCDecrypter(block, iv).CryptBlocks(out, data)
+    return out
+}
+
+// securityEntry returns a hex string entry of the /Encrypt dictionary.
+func securityEntry(t *testing.T, doc []byte, name string) []byte {
+    t.Helper()
+    m := regexp.MustCompile(`/` + name + ` <([0-9a-f]+)>`).FindSubmatch(doc)
+    if m == nil {
+        t.Fatalf("no /%s in the encryption dictionary", name)
+    }
+    b, err := hex.DecodeString(string(m[1]))
+    if err != nil {
+        t.Fatal(err)
+    }
+    return b
+}
+
+func TestEncryptedDocument(t *testing.T) {
+    const password = "payslip-2025"
+    doc, err := Render([]string{"Net pay: 1234.56", "(c) Shop"}, Options{UserPassword: password, OwnerPassword: "owner"})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !bytes.Contains(doc, []byte("/Filter /Standard /V 5 /R 6")) {
+        t.Fatal("document is not encrypted with the AES-256 security handler")
+    }
+    u := securityEntry(t, doc, "U")
+    ue := securityEntry(t, doc, "UE")
+    o := securityEntry(t, doc, "O")
+    oe := securityEntry(t, doc, "OE")
+    if len(u) != 48 || len(o) != 48 || len(ue) != 32 || len(oe) != 32 {
+        t.Fatalf("entry lengths /U %d /O %d /UE %d /OE %d, want 48, 48, 32, 32", len(u), len(o), len(ue), len(oe))
+    }
+
+    // Алгоритм 2.A: проверка пароля по соли проверки, затем ключ документа из /UE
+    if !bytes.Equal(hardenedHash([]byte(password), u[32:40], nil), u[:32]) {
+        t.Fatal("user password does not validate against /U")
+    }
+    if bytes.Equal(hardenedHash([]byte("wrong"), u[32:40], nil), u[:32]) {
+        t.Fatal("wrong password validates against /U")
+    }
+    zeroIV := make([]byte, aes.BlockSize)
+    key := cbcDecrypt(t, hardenedHash([]byte(password), u[40:48], nil), zeroIV, ue)
+
+    if !bytes.Equal(hardenedHash([]byte("owner"), o[32:40], u), o[:32]) {
+        t.Fatal("owner password does not validate against /O")
+    }
+    if ownerKey := cbcDecrypt(t, hardenedHash([]byte("owner"), o[40:48], u), zeroIV, oe); !bytes.Equal(ownerKey, key) {
+        t.Fatal("/OE gives another document key than /UE")
+    }
+
+    // Алгоритм 13: /Perms, расшифрованный ключом документа, повторяет /P
+    block, err := aes.NewCipher(key)
+    if err != nil {
+        t.Fatal(err)
+    }
+    perms := make([]byte, 16)
+    block.Decrypt(perms, securityEntry(t, doc, "Perms"))
+    if string(perms[9:12]) != "adb" || int32(binary.LittleEndian.Uint32(perms[:4])) != permissions {
+        t.Fatalf("decrypted /Perms = %x, want P %d and \"adb\"", perms, permissions)
+    }
+
+    // Поток содержимого первой страницы: IV, затем AES-256-CBC с дополнением PKCS#7
+    m := regexp.MustCompile(`(?s)5 0 obj\n<< /Length (\d+) >>\nstream\n`).FindSubmatchIndex(doc)
+    if m == nil {
+        t.Fatal("no content stream for the first page")
+    }
+    length, _ := strconv.Atoi(string(doc[m[2]:m[3]]))
+    stream := doc[m[1] : m[1]+length]
+    plain := cbcDecrypt(t, key, stream[:aes.BlockSize], stream[aes.BlockSize:])
+    pad := int(plain[len(plain)-1])
+    if pad < 1 || pad > aes.BlockSize || !bytes.Equal(plain[len(plain)-pad:], bytes.Repeat([]byte{byte(pad)}, pad)) {
+        t.Fatalf("invalid padding in the decrypted stream: %x", plain[len(plain)-aes.BlockSize:])
+    }
+    plain = plain[:len(plain)-pad]
+    for _, want := range []string{"(Net pay: 1234.56) '", `(\(c\) Shop) '`} {
+        if !bytes.Contains(plain, []byte(want)) {
+            t.Errorf("decrypted stream %q does not contain %q", plain, want)
+        }
+    }
+}
@@ -0,0 +1,169 @@
+// Package pdf renders plain monospaced text as a minimal PDF document. It is
+// enough for receipts and payslips and has no dependencies outside the
+// standard library. Documents can be password protected.
+package pdf
+
+import (
+    "bytes"
+    "fmt"
+    "strings"
+)
+
+// Options control the page layout. Sizes are in points (1/72 inch); zero
+// values fall back to A4 with 10pt text. With a UserPassword the document is
+// encrypted and viewers ask for the password before opening it.
+type Options struct {
+    FontSize      float64
+    PageWidth     float64
+    PageHeight    float64
+    Margin        float64
+    UserPassword  string
+    OwnerPassword string // пусто — случайный
+}
+
+const (
+    a4Width  = 595
+    a4Height = 842
+)
+
+// CharWidth is the advance of one Courier character at the given font size.
+func CharWidth(fontSize float64) float64 {
+    return fontSize * 0.6
+}
+
+// Leading is the distance between two text lines at the given font size.
+func Leading(fontSize float64) float64 {
+    return fontSize * 1.2
+}
+
+func (o *Options) defaults() {
+    if o.FontSize == 0 {
+        o.FontSize = 10
+    }
+    if o.PageWidth == 0 {
+        o.PageWidth = a4Width
+    }
+    if o.PageHeight == 0 {
+        o.PageHeight = a4Height
+    }
+    if o.Margin == 0 {
+        o.Margin = 36
+    }
+}
+
+// Render lays out lines top to bottom in Courier, starting a new page when
+// one is full. Characters outside Latin-1 are replaced with '?'.
+func Render(lines []string, opts Options) ([]byte, error) {
+    opts.defaults()
+
+    perPage := int((opts.PageHeight - 2*opts.Margin) / Leading(opts.FontSize))
+    if perPage < 1 {
+        perPage = 1
+    }
+    var pages [][]string
+    for len(lines) > perPage {
+        pages = append(pages, lines[:perPage])
+        lines = lines[perPage:]
+    }
+    pages = append(pages, lines)
+
+    w := &writer{}
+    if opts.UserPassword != "" {
+        sec, err := newSecurity(opts.UserPassword, opts.OwnerPassword)
+        if err != nil {
+            return nil, err
+        }
+        w.sec = sec
+    }
+    w.buf.WriteString("%PDF-1.7\n%\xe2\xe3\xcf\xd3\n")
+
+    // 1 — каталог, 2 — дерево страниц, 3 — шрифт, далее пары страница/содержимое
+    kids := make([]string, len(pages))
+    for i := range pages {
+        kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+    }
+    if w.sec != nil {
+        // AES-256 в PDF 1.7 — расширение Adobe уровня 8
+        w.object("<< /Type /Catalog /Pages 2 0 R /Extensions << /ADBE << /BaseVersion /1.7 /ExtensionLevel 8 >> >> >>")
+    } else {
+        w.object("<< /Type /Catalog /Pages 2 0 R >>")
+    }
+    w.object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+    w.object("<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+
+    for i, page := range pages {
+        var content bytes.Buffer
+        // Оператор ' сначала переводит строку, поэтому начинаем от верхнего поля
+        fmt.Fprintf(&content, "BT /F1 %.2f Tf %.2f TL %.2f %.2f Td\n",
+            opts.FontSize, Leading(opts.FontSize), opts.Margin, opts.PageHeight-opts.Margin)
+        for _, line := range page {
+            fmt.Fprintf(&content, "(%s) '\n", escape(line))
+        }
+        content.WriteString("ET\n")
+
+        w.object(fmt.Sprintf(
+            "<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+            opts.PageWidth, opts.PageHeight, 5+2*i,
+        ))
+        w.stream(content.Bytes())
+    }
+
+    return w.finish(), nil
+}
+
+type writer struct {
+    buf     bytes.Buffer
+    offsets []int
+    sec     *security
+}
+
+func (w *writer) object(body string) {
+    w.offsets = append(w.offsets, w.buf.Len())
+    fmt.Fprintf(&w.buf, "%d 0 obj\n%s\nendobj\n", len(w.offsets), body)
+}
+
+func (w *writer) stream(data []byte) {
+    w.offsets = append(w.offsets, w.buf.Len())
+    if w.sec != nil {
+        data = w.sec.encrypt(data)
+    }
+    fmt.Fprintf(&w.buf, "%d 0 obj\n<< /Length %d >>\nstream\n", len(w.offsets), len(data))
+    w.buf.Write(data)
+    w.buf.WriteString("\nendstream\nendobj\n")
+}
+
+func (w *writer) finish() []byte {
+    trailer := ""
+    if w.sec != nil {
+        // Словарь защиты сам не шифруется
+        w.object(w.sec.dictionary())
+        trailer = fmt.Sprintf(" /Encrypt %d 0 R /ID [<%x> <%x>]", len(w.offsets), w.sec.fileID, w.sec.fileID)
+    }
+
+    xref := w.buf.Len()
+    fmt.Fprintf(&w.buf, "xref\n0 %d\n0000000000 65535 f \n", len(w.offsets)+1)
+    for _, off := range w.offsets {
+        fmt.Fprintf(&w.buf, "%010d 00000 n \n", off)
+    }
+    fmt.Fprintf(&w.buf, "trailer\n<< /Size %d /Root 1 0 R%s >>\nstartxref\n%d\n%%%%EOF\n", len(w.offsets)+1, trailer, xref)
+    return w.buf.Bytes()
+}
+
+// escape converts s to Latin-1 and escapes it for a PDF literal string.
+func escape(s string) string {
+    var b strings.Builder
+    for _, r := range s {
+        switch {
+        case r == '\\' || r == '(' || r == ')':
+            b.WriteByte('\\')
+            b.WriteByte(byte(r))
+        case r == '\t':
+            b.WriteString("    ")
+        case r < 0x20 || r > 0xff:
+            b.WriteByte('?')
+        default:
+            b.WriteByte(byte(r))
+        }
+    }
+    return b.String()
+}
@@ -20,11 +20,196 @@ func NewDB(dsn string) (*gorm.DB, error) {
         &models.SaleItem{},
         &models.EmployeeAttendance{},
         &models.SalaryPayment{},
+        &models.CommissionPlan{},
+        &models.CommissionTier{},
+        &models.CommissionCategoryRate{},
+        &models.CommissionAssignment{},
+        &models.Promotion{},
+        &models.TaxRate{},
+        &models.ShopTaxSettings{},
+        &models.SalePayment{},
+        &models.ShopPaymentMethod{},
+        &models.Shop{},
+        &models.Employee{},
+        &models.ReceiptSequence{},
+        &models.GiftCard{},
+        &models.GiftCardMovement{},
+        &models.Customer{},
+        &models.LoyaltyRule{},
+        &models.LoyaltyTransaction{},
+        &models.PayrollRun{},
+        &models.PayrollLine{},
+        &models.PayrollBankExport{},
+        &models.SalaryBankExport{},
+        &models.SalaryBankExportPayment{},
+        &models.CompanySettings{},
+        &models.EmployeeBankAccount{},
+        &models.DeductionRule{},
+        &models.TaxBracket{},
+        &models.SalaryDeduction{},
+        &models.SalaryAdvance{},
+        &models.SalaryAdjustment{},
     )
     if err != nil {
         return nil, err
     }
 
+    if err := migrateEmployees(db); err != nil {
+        return nil, err
+    }
+    if err := migrateSalaryPeriods(db); err != nil {
+        return nil, err
+    }
+    if err := migrateNetPay(db); err != nil {
+        return nil, err
+    }
+    if err := migratePromotionValidTo(db); err != nil {
+        return nil, err
+    }
+    if err := migratePricesIncludeTax(db); err != nil {
+        return nil, err
+    }
+    if err := migrateApprovalCodes(db); err != nil {
+        return nil, err
+    }
+
     log.Println("Database migrated successfully!")
     return db, nil
 }
+
+// migrateEmployees creates employees rows for employee IDs used by sales,
+// attendance and salary payments recorded before the employees table
+// existed. They get no name, the shop of their latest sale or shift, and the
+// cashier role. The ID sequence is moved past the inserted IDs.
+func migrateEmployees(db *gorm.DB) error {
+    return db.Transaction(func(db *gorm.DB) error {
+        res := db.Exec(`
+            INSERT INTO employees (id, name, shop_id, role, active, created_at, updated_at)
+            SELECT r.employee_id, '', COALESCE(
+                    (SELECT s.shop_id FROM sales_transactions s WHERE s.employee_id = r.employee_id
+                        ORDER BY s.transaction_time DESC LIMIT 1),
+                    (SELECT a.shop_id FROM employee_attendances a WHERE a.employee_id = r.employee_id
+                        ORDER BY a.clock_in DESC LIMIT 1),
+                    0), 'cashier', true, now(), now()
+            FROM (SELECT employee_id FROM sales_transactions
+                UNION SELECT employee_id FROM employee_attendances
+                UNION SELECT employee_id FROM salary_payments) r
+            WHERE r.employee_id > 0 AND NOT EXISTS (SELECT 1 FROM employees e WHERE e.id = r.employee_id)`)
+        if res.Error != nil || res.RowsAffected == 0 {
+            return res.Error
+        }
+        log.Printf("Created %d employees referenced by earlier records", res.RowsAffected)
+        return db.Exec(`SELECT setval(pg_get_serial_sequence('employees', 'id'), (SELECT MAX(id) FROM employees))`).Error
+    })
+}
+
+// migrateSalaryPeriods adds the constraint that regular salary payments of an
+// employee never cover overlapping pay periods. Pay period bounds are
+// inclusive days; reversed payments no longer count, so they can be reissued.
+// AutoMigrate cannot express exclusion constraints. The constraint from
+// before reversals existed is replaced.
+//
+// Payments recorded before the constraint may overlap, and Postgres cannot
+// add an exclusion constraint as NOT VALID. The later of two overlapping
+// historical payments is therefore reclassified as supplemental first.
+func migrateSalaryPeriods(db *gorm.DB) error {
+    if err := db.Exec(`CREATE EXTENSION IF NOT EXISTS btree_gist`).Error; err != nil {
+        return err
+    }
+
+    var current bool
+    if err := db.Raw(`SELECT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'salary_payments_no_overlap'
+            AND pg_get_constraintdef(oid) LIKE '%reversed_at%')`).Scan(&current).Error; err != nil {
+        return err
+    }
+    if current {
+        return nil
+    }
+
+    return db.Transaction(func(db *gorm.DB) error {
+        res := db.Exec(`
+            UPDATE salary_payments p SET payment_type = 'supplemental'
+            WHERE p.payment_type = 'regular' AND p.reversed_at IS NULL AND EXISTS (
+                SELECT 1 FROM salary_payments q
+                WHERE q.employee_id = p.employee_id AND q.id < p.id
+                    AND q.payment_type = 'regular' AND q.reversed_at IS NULL
+                    AND q.pay_period_start <= p.pay_period_end AND q.pay_period_end >= p.pay_period_start)`)
+        if res.Error != nil {
+            return res.Error
+        }
+        if res.RowsAffected > 0 {
+            log.Printf("Reclassified %d salary payments overlapping an earlier regular payment as supplemental", res.RowsAffected)
+        }
+
+        if err := db.Exec(`ALTER TABLE salary_payments DROP CONSTRAINT IF EXISTS salary_payments_no_overlap`).Error; err != nil {
+            return err
+        }
+        return db.Exec(`
+            ALTER TABLE salary_payments ADD CONSTRAINT salary_payments_no_overlap
+                EXCLUDE USING gist (
+                    employee_id WITH =,
+                    tstzrange(pay_period_start, pay_period_end, '[]') WITH &&
+                ) WHERE (payment_type = 'regular' AND reversed_at IS NULL)`).Error
+    })
+}
+
+// migrateNetPay fills the gross/net columns of payments and payroll lines
+// recorded before deductions existed: nothing was withheld, so net is the
+// whole amount.
+func migrateNetPay(db *gorm.DB) error {
+    for _, table := range []string{"salary_payments", "payroll_lines"} {
+        if err := db.Exec(`UPDATE ` + table + ` SET net_amount = amount,
+            deductions = COALESCE(deductions, 0),
+            employer_contributions = COALESCE(employer_contributions, 0)
+            WHERE net_amount IS NULL`).Error; err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// migratePromotionValidTo moves valid_to of promotions created when it held
+// the day after the last one back to the last day itself, and adds the check
+// that a promotion ends no earlier than it starts. The check marks that the
+// shift has been done.
+func migratePromotionValidTo(db *gorm.DB) error {
+    var done bool
+    if err := db.Raw(`SELECT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'promotions_valid_range')`).
+        Scan(&done).Error; err != nil {
+        return err
+    }
+    if done {
+        return nil
+    }
+
+    return db.Transaction(func(db *gorm.DB) error {
+        if err := db.Exec(`UPDATE promotions SET valid_to = valid_to - interval '1 day'`).Error; err != nil {
+            return err
+        }
+        return db.Exec(`ALTER TABLE promotions ADD CONSTRAINT promotions_valid_range
+            CHECK (valid_to >= valid_from)`).Error
+    })
+}
+
+// migratePricesIncludeTax fills prices_include_tax of sales recorded before
+// it was stored, from the current tax settings of their shop. Shops without
+// settings use tax-inclusive prices.
+func migratePricesIncludeTax(db *gorm.DB) error {
+    return db.Exec(`UPDATE sales_transactions t SET prices_include_tax = COALESCE(
+            (SELECT s.prices_include_tax FROM shop_tax_settings s WHERE s.shop_id = t.shop_id), true)
+        WHERE t.prices_include_tax IS NULL`).Error
+}
+
+// migrateApprovalCodes clears manager approval codes stored as unsalted
+// SHA-256 before bcrypt was used. Those managers have to set a new code.
+func migrateApprovalCodes(db *gorm.DB) error {
+    res := db.Exec(`UPDATE employees SET approval_code_hash = ''
+        WHERE approval_code_hash <> '' AND approval_code_hash NOT LIKE '$2%'`)
+    if res.Error != nil {
+        return res.Error
+    }
+    if res.RowsAffected > 0 {
+        log.Printf("Cleared %d manager approval codes stored without bcrypt; they have to be set again", res.RowsAffected)
+    }
+    return nil
+}